@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
+	"io/fs"
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/chazu/lignin/pkg/engine"
 	"github.com/chazu/lignin/pkg/graph"
@@ -21,9 +24,10 @@ var colorPalette = []string{
 
 // App is the Wails backend. It exposes methods to the frontend via bindings.
 type App struct {
-	ctx    context.Context
-	engine *engine.Engine
-	kernel kernel.Kernel
+	ctx     context.Context
+	engine  *engine.Engine
+	kernel  kernel.Kernel
+	species graph.SpeciesTable // nil for the built-in table
 }
 
 // MeshData is the JSON-serializable mesh format sent to the frontend.
@@ -42,11 +46,39 @@ type EvalErrorData struct {
 	Message string `json:"message"`
 }
 
+// ParamData is a JSON-serializable design parameter for the frontend,
+// which renders one slider per parameter.
+type ParamData struct {
+	Name    string   `json:"name"`
+	Value   float64  `json:"value"`
+	Default float64  `json:"default"`
+	Min     *float64 `json:"min,omitempty"`
+	Max     *float64 `json:"max,omitempty"`
+	Step    float64  `json:"step"`
+	Doc     string   `json:"doc,omitempty"`
+	Line    int      `json:"line"`
+}
+
+// ConsoleData is one captured print call for the frontend console pane.
+type ConsoleData struct {
+	Line int    `json:"line"`
+	Col  int    `json:"col"`
+	Text string `json:"text"`
+}
+
 // EvalResult is the full result returned to the frontend.
 type EvalResult struct {
 	Meshes   []MeshData      `json:"meshes"`
 	Errors   []EvalErrorData `json:"errors"`
 	Warnings []EvalErrorData `json:"warnings"`
+	Params   []ParamData     `json:"params"`
+	Variants []string        `json:"variants"`          // declared variant names
+	Variant  string          `json:"variant,omitempty"` // variant that was evaluated
+	Console  []ConsoleData   `json:"console"`
+
+	// Fingerprint identifies the evaluated design (see graph.Fingerprint).
+	// It is empty when evaluation failed.
+	Fingerprint string `json:"fingerprint,omitempty"`
 }
 
 // FileResult is returned by OpenFile with the file contents and path.
@@ -58,11 +90,33 @@ type FileResult struct {
 // NewApp creates a new App with an engine and the sdfx kernel.
 func NewApp() *App {
 	return &App{
-		engine: engine.NewEngine(),
-		kernel: sdfx.New(),
+		engine:  engine.NewEngine(),
+		kernel:  sdfx.New(),
+		species: loadSpecies(),
 	}
 }
 
+// speciesFile holds the user's species overrides, relative to the user
+// configuration directory.
+const speciesFile = "lignin/species.json"
+
+// loadSpecies returns the species table with the user's overrides applied,
+// or nil for the built-in table if there are none or they cannot be read.
+func loadSpecies() graph.SpeciesTable {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil
+	}
+	t, err := graph.LoadSpeciesFile(filepath.Join(dir, speciesFile))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("species overrides: %v", err)
+		}
+		return nil
+	}
+	return t
+}
+
 // startup is called by Wails on app startup. The context is saved
 // so we can call Wails runtime methods later if needed.
 func (a *App) startup(ctx context.Context) {
@@ -72,14 +126,38 @@ func (a *App) startup(ctx context.Context) {
 // Evaluate takes Lisp source and returns mesh data + errors.
 // This is the primary binding called by the frontend editor.
 func (a *App) Evaluate(source string) EvalResult {
+	return a.evaluate(source, engine.EvalOptions{})
+}
+
+// EvaluateWithParams is like Evaluate but overrides the values of declared
+// (param ...) forms, so a design can be rebuilt at new sizes without
+// editing its source.
+func (a *App) EvaluateWithParams(source string, overrides map[string]float64) EvalResult {
+	return a.evaluate(source, engine.EvalOptions{Overrides: overrides})
+}
+
+// EvaluateVariant is like Evaluate but uses the parameter values of the
+// named (variant ...) declaration.
+func (a *App) EvaluateVariant(source string, variant string) EvalResult {
+	return a.evaluate(source, engine.EvalOptions{Variant: variant})
+}
+
+// evaluate runs the full pipeline (engine, validation, tessellation) and
+// converts the outcome to the frontend format.
+func (a *App) evaluate(source string, opts engine.EvalOptions) EvalResult {
+	opts.Species = a.species
 	result := EvalResult{
 		Meshes:   []MeshData{},
 		Errors:   []EvalErrorData{},
 		Warnings: []EvalErrorData{},
+		Params:   []ParamData{},
+		Variants: []string{},
+		Variant:  opts.Variant,
+		Console:  []ConsoleData{},
 	}
 
 	// Step 1: Evaluate the Lisp source into a design graph.
-	g, evalErrs, err := a.engine.Evaluate(source)
+	res, err := a.engine.EvaluateWithOptions(source, opts)
 	if err != nil {
 		// Fatal error (panic, timeout, etc.)
 		log.Printf("Evaluate fatal error: %v", err)
@@ -91,9 +169,39 @@ func (a *App) Evaluate(source string) EvalResult {
 		return result
 	}
 
+	for _, p := range res.Params {
+		result.Params = append(result.Params, ParamData{
+			Name:    p.Name,
+			Value:   p.Value,
+			Default: p.Default,
+			Min:     p.Min,
+			Max:     p.Max,
+			Step:    p.Step,
+			Doc:     p.Doc,
+			Line:    p.Line,
+		})
+	}
+	for _, v := range res.Variants {
+		result.Variants = append(result.Variants, v.Name)
+	}
+	for _, c := range res.Console {
+		result.Console = append(result.Console, ConsoleData{
+			Line: c.Line,
+			Col:  c.Col,
+			Text: c.Text,
+		})
+	}
+	for _, w := range res.Warnings {
+		result.Warnings = append(result.Warnings, EvalErrorData{
+			Line:    w.Line,
+			Col:     w.Col,
+			Message: w.Message,
+		})
+	}
+
 	// Step 2: Convert eval errors to the frontend format.
-	if len(evalErrs) > 0 {
-		for _, e := range evalErrs {
+	if len(res.Errors) > 0 {
+		for _, e := range res.Errors {
 			result.Errors = append(result.Errors, EvalErrorData{
 				Line:    e.Line,
 				Col:     e.Col,
@@ -104,6 +212,10 @@ func (a *App) Evaluate(source string) EvalResult {
 	}
 
 	// Step 2.5: Run multi-tier graph validation (structural + geometric + material).
+	g := res.Graph
+	if g.Fingerprint != nil {
+		result.Fingerprint = g.Fingerprint.String()
+	}
 	valResult := graph.ValidateAll(g)
 	if len(valResult.Errors) > 0 {
 		for _, e := range valResult.Errors {
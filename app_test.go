@@ -115,3 +115,73 @@ func TestE2ESingleBoard(t *testing.T) {
 		t.Errorf("expected part name 'shelf', got %q", result.Meshes[0].PartName)
 	}
 }
+
+// TestE2EEvaluateWithParams ensures parameter overrides reach the geometry and
+// the parameter table is returned for the frontend.
+func TestE2EEvaluateWithParams(t *testing.T) {
+	app := NewApp()
+	source := `(def shelf-len (param "length" 600 :min 300 :max 900 :doc "Shelf length"))
+(defpart "shelf" (board :length shelf-len :width 300 :thickness 18 :grain :x))`
+
+	result := app.EvaluateWithParams(source, map[string]float64{"length": 450})
+	if len(result.Errors) > 0 {
+		for _, e := range result.Errors {
+			t.Errorf("eval error: %s", e.Message)
+		}
+		t.FailNow()
+	}
+	if len(result.Params) != 1 {
+		t.Fatalf("expected 1 param, got %d", len(result.Params))
+	}
+	p := result.Params[0]
+	if p.Name != "length" || p.Value != 450 || p.Default != 600 || p.Doc != "Shelf length" {
+		t.Errorf("unexpected param data: %+v", p)
+	}
+	if len(result.Meshes) != 1 {
+		t.Fatalf("expected 1 mesh, got %d", len(result.Meshes))
+	}
+}
+
+// TestE2EEvaluateVariant ensures variants are listed and a named variant is
+// evaluated with its parameter values.
+func TestE2EEvaluateVariant(t *testing.T) {
+	app := NewApp()
+	source := `(def shelf-len (param "length" 600 :min 300 :max 900))
+(variant "short" :length 400)
+(defpart "shelf" (board :length shelf-len :width 300 :thickness 18 :grain :x))`
+
+	result := app.EvaluateVariant(source, "short")
+	if len(result.Errors) > 0 {
+		for _, e := range result.Errors {
+			t.Errorf("eval error: %s", e.Message)
+		}
+		t.FailNow()
+	}
+	if result.Variant != "short" {
+		t.Errorf("expected variant %q, got %q", "short", result.Variant)
+	}
+	if len(result.Variants) != 1 || result.Variants[0] != "short" {
+		t.Errorf("expected variants [short], got %v", result.Variants)
+	}
+	if len(result.Params) != 1 || result.Params[0].Value != 400 {
+		t.Errorf("expected length param at 400, got %+v", result.Params)
+	}
+}
+
+// TestE2EConsoleOutput ensures print output from user code is returned to the
+// frontend with its source position, even when evaluation fails.
+func TestE2EConsoleOutput(t *testing.T) {
+	app := NewApp()
+	result := app.Evaluate("(println \"debug\")\n(part \"missing\")")
+
+	if len(result.Errors) == 0 {
+		t.Fatal("expected an eval error")
+	}
+	if len(result.Console) != 1 {
+		t.Fatalf("expected 1 console entry, got %d", len(result.Console))
+	}
+	c := result.Console[0]
+	if c.Text != "debug\n" || c.Line != 1 {
+		t.Errorf("unexpected console entry: %+v", c)
+	}
+}
@@ -0,0 +1,361 @@
+// Command lignin evaluates Lignin designs outside the desktop app.
+//
+// Usage:
+//
+//	lignin export [-format json|dot|mermaid] [-variant name] [-param name=value]... [-check-determinism] [-species file] [-o file] design.lignin
+//	lignin cutlist [-format json|csv|markdown] [-stock] [-variant name] [-param name=value]... [-o file] design.lignin
+//	lignin estimate [-format json|csv|markdown] [-prices file] [-waste fraction] [-variant name] [-param name=value]... [-o file] design.lignin
+//	lignin hardware [-format json|csv|markdown] [-variant name] [-param name=value]... [-o file] design.lignin
+//	lignin verify design.lignin output
+//
+// export writes the evaluated design graph. Every output carries the
+// design's fingerprint. With -check-determinism the design is evaluated
+// twice and any difference is reported as a warning; -species loads
+// species overrides for material checks (see graph.LoadSpecies).
+//
+// cutlist writes the design's cut list (see package cutlist), stamped with
+// the same fingerprint. With -stock the parts are allocated to the design's
+// (stock ...) declarations and the list is grouped by stock board;
+// allocation failures are written to standard error as warnings.
+//
+// estimate writes a priced bill of materials: lumber in board feet or
+// square metres with a waste factor, and the design's fasteners, costed
+// from the price table given with -prices (see cutlist.LoadPrices). Items
+// without a price are listed unpriced and reported as warnings.
+//
+// hardware writes the design's fastener schedule: every fastener size with
+// its quantity, pilot and clearance hole sizes and the joints that use it.
+//
+// verify checks whether an output produced earlier still matches the
+// current source, evaluated for the variant and parameter overrides stamped
+// in the output, exiting with status 1 if it does not.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/chazu/lignin/pkg/cutlist"
+	"github.com/chazu/lignin/pkg/engine"
+	"github.com/chazu/lignin/pkg/graph"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = runExport(os.Args[2:])
+	case "cutlist":
+		err = runCutlist(os.Args[2:])
+	case "estimate":
+		err = runEstimate(os.Args[2:])
+	case "hardware":
+		err = runHardware(os.Args[2:])
+	case "verify":
+		var ok bool
+		ok, err = runVerify(os.Args[2:], os.Stdout)
+		if err == nil && !ok {
+			os.Exit(1)
+		}
+	case "version":
+		fmt.Println("lignin", engine.Version)
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "lignin:", err)
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: lignin export [-format json|dot|mermaid] [-variant name] [-param name=value]... [-check-determinism] [-species file] [-o file] design.lignin")
+	fmt.Fprintln(os.Stderr, "       lignin cutlist [-format json|csv|markdown] [-stock] [-variant name] [-param name=value]... [-o file] design.lignin")
+	fmt.Fprintln(os.Stderr, "       lignin estimate [-format json|csv|markdown] [-prices file] [-waste fraction] [-variant name] [-param name=value]... [-o file] design.lignin")
+	fmt.Fprintln(os.Stderr, "       lignin hardware [-format json|csv|markdown] [-variant name] [-param name=value]... [-o file] design.lignin")
+	fmt.Fprintln(os.Stderr, "       lignin verify design.lignin output")
+	fmt.Fprintln(os.Stderr, "       lignin version")
+	os.Exit(2)
+}
+
+// paramFlags collects repeated -param name=value flags.
+type paramFlags map[string]float64
+
+func (p paramFlags) String() string { return "" }
+
+func (p paramFlags) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, got %q", s)
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("param %s: %w", name, err)
+	}
+	p[name] = v
+	return nil
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "json", "output format: json, dot or mermaid")
+	variant := fs.String("variant", "", "design variant to evaluate")
+	out := fs.String("o", "", "output file (default standard output)")
+	check := fs.Bool("check-determinism", false, "evaluate twice and warn if the results differ")
+	speciesFile := fs.String("species", "", "JSON file of species overrides")
+	params := paramFlags{}
+	fs.Var(params, "param", "override a design parameter, as name=value (repeatable)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	opts := engine.EvalOptions{Overrides: params, Variant: *variant, CheckDeterminism: *check}
+	if *speciesFile != "" {
+		species, err := graph.LoadSpeciesFile(*speciesFile)
+		if err != nil {
+			return err
+		}
+		opts.Species = species
+	}
+	g, err := evaluate(fs.Arg(0), opts)
+	if err != nil {
+		return err
+	}
+
+	findings := graph.ValidateAll(g)
+	var data []byte
+	switch *format {
+	case "json":
+		if data, err = g.MarshalJSON(); err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	case "dot":
+		data = []byte(graph.ExportDOT(g, graph.ExportOptions{Findings: &findings}))
+	case "mermaid":
+		data = []byte(graph.ExportMermaid(g, graph.ExportOptions{Findings: &findings}))
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	return writeOutput(*out, data)
+}
+
+func runCutlist(args []string) error {
+	fs := flag.NewFlagSet("cutlist", flag.ExitOnError)
+	format := fs.String("format", "markdown", "output format: json, csv or markdown")
+	byStock := fs.Bool("stock", false, "allocate parts to declared stock and group them by stock board")
+	variant := fs.String("variant", "", "design variant to evaluate")
+	out := fs.String("o", "", "output file (default standard output)")
+	params := paramFlags{}
+	fs.Var(params, "param", "override a design parameter, as name=value (repeatable)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	g, err := evaluate(fs.Arg(0), engine.EvalOptions{Overrides: params, Variant: *variant})
+	if err != nil {
+		return err
+	}
+	cl, err := cutlist.Build(g)
+	if err != nil {
+		return err
+	}
+
+	var list interface {
+		WriteJSON(io.Writer) error
+		WriteCSV(io.Writer) error
+		WriteMarkdown(io.Writer) error
+	} = cl
+	if *byStock {
+		a := cutlist.Allocate(cl, g.Stock)
+		if len(g.Stock) == 0 {
+			fmt.Fprintf(os.Stderr, "%s: warning: no stock declared\n", fs.Arg(0))
+		}
+		for _, w := range a.Warnings {
+			fmt.Fprintf(os.Stderr, "%s: warning: %s\n", fs.Arg(0), w)
+		}
+		list = a
+	}
+
+	var buf bytes.Buffer
+	switch *format {
+	case "json":
+		err = list.WriteJSON(&buf)
+	case "csv":
+		err = list.WriteCSV(&buf)
+	case "markdown":
+		err = list.WriteMarkdown(&buf)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, buf.Bytes())
+}
+
+func runEstimate(args []string) error {
+	fs := flag.NewFlagSet("estimate", flag.ExitOnError)
+	format := fs.String("format", "markdown", "output format: json, csv or markdown")
+	pricesFile := fs.String("prices", "", "JSON price table")
+	waste := fs.Float64("waste", -1, "waste fraction added to material quantities (default from the price table)")
+	variant := fs.String("variant", "", "design variant to evaluate")
+	out := fs.String("o", "", "output file (default standard output)")
+	params := paramFlags{}
+	fs.Var(params, "param", "override a design parameter, as name=value (repeatable)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	prices := &cutlist.PriceTable{}
+	if *pricesFile != "" {
+		var err error
+		if prices, err = cutlist.LoadPricesFile(*pricesFile); err != nil {
+			return err
+		}
+	}
+	if *waste >= 0 {
+		prices.Waste = waste
+	}
+
+	g, err := evaluate(fs.Arg(0), engine.EvalOptions{Overrides: params, Variant: *variant})
+	if err != nil {
+		return err
+	}
+	e, err := cutlist.BuildEstimate(g, prices)
+	if err != nil {
+		return err
+	}
+	for _, w := range e.Warnings {
+		fmt.Fprintf(os.Stderr, "%s: warning: %s\n", fs.Arg(0), w)
+	}
+
+	var buf bytes.Buffer
+	switch *format {
+	case "json":
+		err = e.WriteJSON(&buf)
+	case "csv":
+		err = e.WriteCSV(&buf)
+	case "markdown":
+		err = e.WriteMarkdown(&buf)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, buf.Bytes())
+}
+
+func runHardware(args []string) error {
+	fs := flag.NewFlagSet("hardware", flag.ExitOnError)
+	format := fs.String("format", "markdown", "output format: json, csv or markdown")
+	variant := fs.String("variant", "", "design variant to evaluate")
+	out := fs.String("o", "", "output file (default standard output)")
+	params := paramFlags{}
+	fs.Var(params, "param", "override a design parameter, as name=value (repeatable)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	g, err := evaluate(fs.Arg(0), engine.EvalOptions{Overrides: params, Variant: *variant})
+	if err != nil {
+		return err
+	}
+	s, err := cutlist.BuildSchedule(g)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	switch *format {
+	case "json":
+		err = s.WriteJSON(&buf)
+	case "csv":
+		err = s.WriteCSV(&buf)
+	case "markdown":
+		err = s.WriteMarkdown(&buf)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, buf.Bytes())
+}
+
+// writeOutput writes data to the named file, or to standard output if the
+// name is empty.
+func writeOutput(path string, data []byte) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// runVerify reports whether the output still matches the design, writing
+// a summary to w.
+func runVerify(args []string, w io.Writer) (bool, error) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		usage()
+	}
+
+	source, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return false, err
+	}
+	output, err := os.ReadFile(fs.Arg(1))
+	if err != nil {
+		return false, err
+	}
+	v, err := engine.NewEngine().Verify(string(source), string(output), engine.EvalOptions{})
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprintln(w, v.Summary())
+	return v.OK(), nil
+}
+
+// evaluate reads and evaluates a design file, failing on evaluation errors.
+// Warnings are written to standard error.
+func evaluate(path string, opts engine.EvalOptions) (*graph.DesignGraph, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	res, err := engine.NewEngine().EvaluateWithOptions(string(source), opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Errors) > 0 {
+		msgs := make([]string, len(res.Errors))
+		for i, e := range res.Errors {
+			msgs[i] = e.Error()
+		}
+		return nil, fmt.Errorf("%s: %s", path, strings.Join(msgs, "; "))
+	}
+	for _, w := range res.Warnings {
+		if w.Line > 0 {
+			fmt.Fprintf(os.Stderr, "%s:%d:%d: warning: %s\n", path, w.Line, w.Col, w.Message)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: warning: %s\n", path, w.Message)
+		}
+	}
+	return res.Graph, nil
+}
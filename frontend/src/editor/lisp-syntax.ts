@@ -3,8 +3,10 @@ import { tags as t } from '@lezer/highlight';
 import { HighlightStyle, syntaxHighlighting } from '@codemirror/language';
 
 const KEYWORDS = new Set([
-  'defpart', 'board', 'material', 'butt-joint', 'assembly',
-  'part', 'place', 'def', 'list', 'vec3', 'screw', 'define',
+  'defpart', 'redefpart', 'board', 'material', 'butt-joint', 'assembly',
+  'part', 'place', 'def', 'list', 'vec3', 'screw', 'fastener-rule', 'define', 'param', 'variant',
+  'dim', 'thickness-of', 'bbox', 'face-center', 'vec3-x', 'vec3-y', 'vec3-z',
+  'random-seed', 'random', 'wood-movement', 'stock', 'milling', 'units',
 ]);
 
 interface LispState {
@@ -47,7 +47,7 @@ const DEFAULT_SOURCE = `;; Open-top box -- 5 boards with butt joints
   (place (part "bottom") :at (vec3 19 0 19))
 
   (butt-joint
-    :part-a (part "front") :face-a :left
+    :part-a (part "front") :face-a :back
     :part-b (part "left")  :face-b :front
     :fasteners
       (list
@@ -55,7 +55,7 @@ const DEFAULT_SOURCE = `;; Open-top box -- 5 boards with butt joints
         (screw :diameter 4 :length 50 :position (vec3 0 150 0))))
 
   (butt-joint
-    :part-a (part "front") :face-a :right
+    :part-a (part "front") :face-a :back
     :part-b (part "right") :face-b :front
     :fasteners
       (list
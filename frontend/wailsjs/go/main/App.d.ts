@@ -4,6 +4,10 @@ import {main} from '../models';
 
 export function Evaluate(arg1:string):Promise<main.EvalResult>;
 
+export function EvaluateVariant(arg1:string,arg2:string):Promise<main.EvalResult>;
+
+export function EvaluateWithParams(arg1:string,arg2:{[key: string]: number}):Promise<main.EvalResult>;
+
 export function OpenFile():Promise<main.FileResult>;
 
 export function SaveFile(arg1:string,arg2:string):Promise<string>;
@@ -6,6 +6,14 @@ export function Evaluate(arg1) {
   return window['go']['main']['App']['Evaluate'](arg1);
 }
 
+export function EvaluateVariant(arg1, arg2) {
+  return window['go']['main']['App']['EvaluateVariant'](arg1, arg2);
+}
+
+export function EvaluateWithParams(arg1, arg2) {
+  return window['go']['main']['App']['EvaluateWithParams'](arg1, arg2);
+}
+
 export function OpenFile() {
   return window['go']['main']['App']['OpenFile']();
 }
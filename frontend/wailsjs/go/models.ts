@@ -1,5 +1,21 @@
 export namespace main {
 	
+	export class ConsoleData {
+	    line: number;
+	    col: number;
+	    text: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConsoleData(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.line = source["line"];
+	        this.col = source["col"];
+	        this.text = source["text"];
+	    }
+	}
 	export class EvalErrorData {
 	    line: number;
 	    col: number;
@@ -36,10 +52,41 @@ export namespace main {
 	        this.color = source["color"];
 	    }
 	}
+	export class ParamData {
+	    name: string;
+	    value: number;
+	    default: number;
+	    min?: number;
+	    max?: number;
+	    step: number;
+	    doc?: string;
+	    line: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ParamData(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.value = source["value"];
+	        this.default = source["default"];
+	        this.min = source["min"];
+	        this.max = source["max"];
+	        this.step = source["step"];
+	        this.doc = source["doc"];
+	        this.line = source["line"];
+	    }
+	}
 	export class EvalResult {
 	    meshes: MeshData[];
 	    errors: EvalErrorData[];
 	    warnings: EvalErrorData[];
+	    params: ParamData[];
+	    variants: string[];
+	    variant?: string;
+	    console: ConsoleData[];
+	    fingerprint?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new EvalResult(source);
@@ -50,6 +97,11 @@ export namespace main {
 	        this.meshes = this.convertValues(source["meshes"], MeshData);
 	        this.errors = this.convertValues(source["errors"], EvalErrorData);
 	        this.warnings = this.convertValues(source["warnings"], EvalErrorData);
+	        this.params = this.convertValues(source["params"], ParamData);
+	        this.variants = source["variants"];
+	        this.variant = source["variant"];
+	        this.console = this.convertValues(source["console"], ConsoleData);
+	        this.fingerprint = source["fingerprint"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -0,0 +1,229 @@
+package cutlist
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chazu/lignin/pkg/graph"
+)
+
+// Allocation is a stock-grouped cut list: the cut list's boards assigned
+// to declared stock. It is advisory; pieces that do not fit are listed as
+// unallocated with a warning.
+type Allocation struct {
+	Units       string       `json:"units"`
+	Variant     string       `json:"variant,omitempty"`
+	Fingerprint string       `json:"fingerprint,omitempty"`
+	Boards      []StockBoard `json:"boards"`
+	Unallocated []Item       `json:"unallocated,omitempty"`
+	Warnings    []string     `json:"warnings,omitempty"`
+}
+
+// StockBoard is one board of a declared stock and the pieces cut from it.
+type StockBoard struct {
+	Stock     string  `json:"stock"` // stock name
+	Index     int     `json:"index"` // 1-based board number within the stock
+	Species   string  `json:"species,omitempty"`
+	Length    float64 `json:"length"`
+	Width     float64 `json:"width"`
+	Thickness float64 `json:"thickness"`
+	Cuts      []Cut   `json:"cuts"`
+}
+
+// Cut is one piece laid out on a stock board. The board is ripped into
+// strips along its length, and pieces are crosscut from the strips with
+// their grain along the board's. Pieces are laid out at their rough
+// dimensions and milled to their finished ones.
+type Cut struct {
+	Parts          []string `json:"parts"` // names of the interchangeable parts this piece is for
+	Length         float64  `json:"length"`
+	Width          float64  `json:"width"`
+	Thickness      float64  `json:"thickness"`
+	RoughLength    float64  `json:"rough_length"`
+	RoughWidth     float64  `json:"rough_width"`
+	RoughThickness float64  `json:"rough_thickness"`
+	At             float64  `json:"at"`    // offset along the board's length
+	Strip          float64  `json:"strip"` // offset of the piece's strip across the board's width
+}
+
+// board is a stock board being filled.
+type board struct {
+	stock  graph.Stock // in cut-list units
+	out    *StockBoard
+	strips []strip
+	used   float64 // width taken by strips, including kerfs between them
+}
+
+type strip struct {
+	offset, width, used float64
+}
+
+// place lays a piece on the board if it fits, first in an existing strip
+// wide enough for it and then in a new strip ripped from the remaining
+// width.
+func (b *board) place(it Item) bool {
+	kerf := b.stock.KerfOrDefault()
+	length, width, _ := it.Rough()
+	for i := range b.strips {
+		s := &b.strips[i]
+		if width > s.width {
+			continue
+		}
+		if at := s.used + kerf; at+length <= b.stock.Length {
+			b.cut(it, at, s.offset)
+			s.used = at + length
+			return true
+		}
+	}
+	offset := b.used
+	if len(b.strips) > 0 {
+		offset += kerf
+	}
+	if offset+width > b.stock.Width || length > b.stock.Length {
+		return false
+	}
+	b.strips = append(b.strips, strip{offset: offset, width: width, used: length})
+	b.used = offset + width
+	b.cut(it, 0, offset)
+	return true
+}
+
+func (b *board) cut(it Item, at, offset float64) {
+	l, w, t := it.Rough()
+	b.out.Cuts = append(b.out.Cuts, Cut{
+		Parts: it.Parts, Length: it.Length, Width: it.Width, Thickness: it.Thickness,
+		RoughLength: l, RoughWidth: w, RoughThickness: t,
+		At: round(at), Strip: round(offset),
+	})
+}
+
+// fits reports whether a piece could come from stock s at all: the species
+// matches if both name one, the stock is thick enough, and the piece's
+// rough blank fits a fresh board with its grain along the board's.
+//
+// Thickness is compared in nominal quarters when both sides have one: a
+// piece whose rough thickness is a quarter such as "8/4", given or rounded
+// up to by its milling allowance, fits stock sold as that quarter or a
+// thicker one, whatever thickness the stock is declared with. Nominal
+// stock is often declared at its surfaced thickness, 44 mm for 8/4, which
+// the piece's rough 50.8 mm would never fit. Otherwise the stock's declared
+// thickness must cover the piece's rough thickness.
+func fits(s graph.Stock, it Item) bool {
+	if s.Species != "" && it.Species != "" && graph.NormalizeSpecies(s.Species) != it.Species {
+		return false
+	}
+	l, w, t := it.Rough()
+	thick := t <= s.Thickness
+	if sq, ok := s.Quarter(); ok {
+		if iq, ok := graph.ParseQuarter(it.Nominal); ok {
+			thick = iq.Rough <= sq.Rough
+		}
+	}
+	return thick && w <= s.Width && l <= s.Length
+}
+
+// Allocate assigns the boards of a cut list to declared stock, largest
+// rough blanks first. Each piece goes to the thinnest stock it fits, which wastes
+// the least to milling, and within it to the first board with room,
+// opening a new board while the stock's quantity allows. Dowels are bought
+// rather than cut from boards and are not allocated.
+func Allocate(cl *CutList, stock []graph.Stock) *Allocation {
+	a := &Allocation{Units: cl.Units, Variant: cl.Variant, Fingerprint: cl.Fingerprint, Boards: []StockBoard{}}
+	scale := graph.UnitScale[cl.Units]
+
+	// Stock in cut-list units, thinnest first, then in declaration order.
+	scaled := make([]graph.Stock, len(stock))
+	for i, s := range stock {
+		s.Thickness, s.Width, s.Length = round(s.Thickness*scale), round(s.Width*scale), round(s.Length*scale)
+		kerf := s.KerfOrDefault() * scale
+		s.Kerf = &kerf
+		scaled[i] = s
+	}
+	sort.SliceStable(scaled, func(i, j int) bool { return scaled[i].Thickness < scaled[j].Thickness })
+
+	var items []Item
+	for _, it := range cl.Items {
+		if it.Kind == "board" {
+			items = append(items, it)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		li, wi, _ := items[i].Rough()
+		lj, wj, _ := items[j].Rough()
+		return li*wi > lj*wj
+	})
+
+	open := make(map[string][]*board)
+	for _, it := range items {
+		missing := 0
+		var exhausted []string
+		for n := 0; n < it.Quantity; n++ {
+			placed := false
+			for _, s := range scaled {
+				if !fits(s, it) {
+					continue
+				}
+				for _, b := range open[s.Name] {
+					if placed = b.place(it); placed {
+						break
+					}
+				}
+				if !placed && len(open[s.Name]) < s.Quantity {
+					b := &board{stock: s, out: &StockBoard{
+						Stock: s.Name, Index: len(open[s.Name]) + 1, Species: s.Species,
+						Length: s.Length, Width: s.Width, Thickness: s.Thickness,
+					}}
+					open[s.Name] = append(open[s.Name], b)
+					placed = b.place(it)
+				}
+				if placed {
+					break
+				}
+				exhausted = appendUnique(exhausted, s.Name)
+			}
+			if !placed {
+				missing++
+			}
+		}
+		if missing == 0 {
+			continue
+		}
+
+		un := it
+		un.Quantity = missing
+		a.Unallocated = append(a.Unallocated, un)
+		l, w, t := it.Rough()
+		desc := fmt.Sprintf("%d × %q (%s × %s × %s %s", missing, strings.Join(it.Parts, "/"),
+			formatDim(l), formatDim(w), formatDim(t), cl.Units)
+		if l != it.Length || w != it.Width || t != it.Thickness {
+			desc += " rough"
+		}
+		if it.Species != "" {
+			desc += ", " + it.Species
+		}
+		desc += ")"
+		if len(exhausted) > 0 {
+			a.Warnings = append(a.Warnings, fmt.Sprintf("not enough stock for %s: no room left in %s",
+				desc, quoteList(exhausted)))
+		} else {
+			a.Warnings = append(a.Warnings, fmt.Sprintf("no declared stock fits %s", desc))
+		}
+	}
+
+	// Boards in declaration order of their stock.
+	for _, s := range stock {
+		for _, b := range open[s.Name] {
+			a.Boards = append(a.Boards, *b.out)
+		}
+	}
+	return a
+}
+
+func quoteList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = fmt.Sprintf("%q", n)
+	}
+	return strings.Join(quoted, ", ")
+}
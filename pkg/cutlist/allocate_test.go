@@ -0,0 +1,131 @@
+package cutlist
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chazu/lignin/pkg/engine"
+	"github.com/chazu/lignin/pkg/graph"
+)
+
+func TestAllocateStripsAndKerf(t *testing.T) {
+	cl := &CutList{Units: "mm", Items: []Item{
+		{Kind: "board", Length: 400, Width: 90, Thickness: 19, Species: "walnut", Quantity: 3, Parts: []string{"rail"}},
+		{Kind: "board", Length: 600, Width: 300, Thickness: 19, Species: "walnut", Quantity: 2, Parts: []string{"shelf"}},
+		{Kind: "dowel", Length: 40, Width: 8, Thickness: 8, Quantity: 8, Parts: []string{"pin"}},
+	}}
+	kerf := 2.0
+	stock := []graph.Stock{
+		{Name: "walnut-8/4", Species: "Walnut", Thickness: 44, Width: 320, Length: 1300, Quantity: 1},
+		{Name: "walnut-4/4", Species: "walnut", Thickness: 22, Width: 100, Length: 1000, Quantity: 1, Kerf: &kerf},
+	}
+	a := Allocate(cl, stock)
+
+	shelf, rail := []string{"shelf"}, []string{"rail"}
+	want := []StockBoard{
+		{Stock: "walnut-8/4", Index: 1, Species: "Walnut", Length: 1300, Width: 320, Thickness: 44, Cuts: []Cut{
+			{Parts: shelf, Length: 600, Width: 300, Thickness: 19, RoughLength: 600, RoughWidth: 300, RoughThickness: 19, At: 0, Strip: 0},
+			{Parts: shelf, Length: 600, Width: 300, Thickness: 19, RoughLength: 600, RoughWidth: 300, RoughThickness: 19, At: 603, Strip: 0},
+		}},
+		{Stock: "walnut-4/4", Index: 1, Species: "walnut", Length: 1000, Width: 100, Thickness: 22, Cuts: []Cut{
+			{Parts: rail, Length: 400, Width: 90, Thickness: 19, RoughLength: 400, RoughWidth: 90, RoughThickness: 19, At: 0, Strip: 0},
+			{Parts: rail, Length: 400, Width: 90, Thickness: 19, RoughLength: 400, RoughWidth: 90, RoughThickness: 19, At: 402, Strip: 0},
+		}},
+	}
+	if !reflect.DeepEqual(a.Boards, want) {
+		t.Errorf("boards = %+v\nwant %+v", a.Boards, want)
+	}
+	if len(a.Unallocated) != 1 || a.Unallocated[0].Quantity != 1 || a.Unallocated[0].Parts[0] != "rail" {
+		t.Errorf("unallocated = %+v, want one rail", a.Unallocated)
+	}
+	wantWarning := `not enough stock for 1 × "rail" (400 × 90 × 19 mm, walnut): no room left in "walnut-4/4", "walnut-8/4"`
+	if len(a.Warnings) != 1 || a.Warnings[0] != wantWarning {
+		t.Errorf("warnings = %q, want %q", a.Warnings, wantWarning)
+	}
+}
+
+func TestAllocateNoFittingStock(t *testing.T) {
+	cl := &CutList{Units: "mm", Items: []Item{
+		{Kind: "board", Length: 500, Width: 100, Thickness: 19, Species: "cherry", Quantity: 1, Parts: []string{"door"}},
+		{Kind: "board", Length: 500, Width: 100, Thickness: 50, Quantity: 2, Parts: []string{"leg"}},
+	}}
+	a := Allocate(cl, []graph.Stock{{Name: "walnut-8/4", Species: "walnut", Thickness: 44, Width: 200, Length: 2400, Quantity: 3}})
+	if len(a.Boards) != 0 {
+		t.Errorf("expected no boards, got %+v", a.Boards)
+	}
+	want := []string{
+		`no declared stock fits 1 × "door" (500 × 100 × 19 mm, cherry)`,
+		`no declared stock fits 2 × "leg" (500 × 100 × 50 mm)`,
+	}
+	if !reflect.DeepEqual(a.Warnings, want) {
+		t.Errorf("warnings = %q, want %q", a.Warnings, want)
+	}
+}
+
+func TestAllocateDeclaredStock(t *testing.T) {
+	source := `
+(stock "walnut-8/4" :species "walnut" :thickness 44 :width 320 :length 1300 :qty 2)
+(defpart "shelf" (board :length 600 :width 300 :thickness 19
+                        :material (material :species "walnut")))
+(assembly "bay"
+  (place (part "shelf") :at (vec3 0 0 0))
+  (place (part "shelf") :at (vec3 0 200 0))
+  (place (part "shelf") :at (vec3 0 400 0)))
+`
+	g := evaluate(t, source, engine.EvalOptions{})
+	cl, err := Build(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := Allocate(cl, g.Stock)
+	if len(a.Boards) != 2 || len(a.Boards[0].Cuts) != 2 || len(a.Boards[1].Cuts) != 1 || len(a.Warnings) != 0 {
+		t.Fatalf("expected three shelves on two boards, got %+v (warnings %q)", a.Boards, a.Warnings)
+	}
+
+	var md bytes.Buffer
+	if err := a.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"## walnut-8/4 #2\n", "| shelf | 600 | 300 | 19 | 603 | 0 |\n", "- Design: `lignin-fp/1 "} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("Markdown lacks %q:\n%s", want, md.String())
+		}
+	}
+}
+
+func TestAllocateNominalStock(t *testing.T) {
+	source := `
+(stock "walnut-8/4" :species "walnut" :thickness 44 :width 200 :length 2400 :qty 3)
+(def walnut (material :species "walnut"))
+(defpart "leg" (board :length 700 :width 50 :thickness "8/4" :grain :x :material walnut))
+(defpart "slab" (board :length 700 :width 60 :thickness 48 :grain :x :material walnut))
+(defpart "post" (board :length 700 :width 70 :thickness "12/4" :grain :x :material walnut))
+(assembly "table"
+  (place (part "leg") :at (vec3 0 0 0))
+  (place (part "leg") :at (vec3 0 100 0))
+  (place (part "slab") :at (vec3 0 200 0))
+  (place (part "post") :at (vec3 0 300 0)))
+`
+	g := evaluate(t, source, engine.EvalOptions{})
+	cl, err := Build(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := Allocate(cl, g.Stock)
+
+	// The 8/4 legs come from the 8/4 stock although their rough 50.8 mm
+	// exceeds its declared 44 mm. The slab, specified by thickness alone,
+	// is thicker than the stock, and 12/4 is thicker than 8/4.
+	if len(a.Boards) != 1 || len(a.Boards[0].Cuts) != 2 || !reflect.DeepEqual(a.Boards[0].Cuts[0].Parts, []string{"leg"}) {
+		t.Errorf("boards = %+v, want both legs on one walnut-8/4 board", a.Boards)
+	}
+	var unallocated []string
+	for _, it := range a.Unallocated {
+		unallocated = append(unallocated, it.Parts...)
+	}
+	if want := []string{"post", "slab"}; !reflect.DeepEqual(unallocated, want) {
+		t.Errorf("unallocated = %q, want %q", unallocated, want)
+	}
+}
@@ -0,0 +1,238 @@
+// Package cutlist derives an abstract cut list from an evaluated design:
+// the finished parts to cut and the rough blanks they are milled from,
+// grouped into identical pieces with quantities, independent of how they
+// are laid out on stock.
+package cutlist
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/chazu/lignin/pkg/graph"
+)
+
+// CutList is the set of finished parts a design needs.
+type CutList struct {
+	Units       string `json:"units"`                 // unit of all dimensions
+	Variant     string `json:"variant,omitempty"`     // design variant, empty for the base design
+	Fingerprint string `json:"fingerprint,omitempty"` // see graph.Fingerprint
+	Items       []Item `json:"items"`
+}
+
+// Item is a group of identical parts: same kind, final and rough
+// dimensions, grain orientation, species and grade. Board dimensions are
+// oriented by the grain, so two boards of the same size with grain along
+// different edges are different items.
+//
+// The rough dimensions are those of the blank a part is cut to from stock
+// before milling: the finished dimensions plus the part's milling
+// allowance, or the nominal size it was specified by.
+type Item struct {
+	Kind           string   `json:"kind"`      // "board" or "dowel"
+	Length         float64  `json:"length"`    // along the grain
+	Width          float64  `json:"width"`     // the larger dimension across the grain; a dowel's diameter
+	Thickness      float64  `json:"thickness"` // the smaller dimension across the grain; a dowel's diameter
+	RoughLength    float64  `json:"rough_length,omitempty"`
+	RoughWidth     float64  `json:"rough_width,omitempty"`
+	RoughThickness float64  `json:"rough_thickness,omitempty"`
+	Nominal        string   `json:"nominal,omitempty"` // nominal size of the rough stock, e.g. "8/4" or "1x6"
+	Species        string   `json:"species,omitempty"`
+	Grade          string   `json:"grade,omitempty"`
+	Quantity       int      `json:"quantity"`
+	Parts          []string `json:"parts"`                // part names, in order of first placement
+	Assemblies     []string `json:"assemblies,omitempty"` // named assemblies the parts are placed in, sorted
+}
+
+// Build walks the placed instances of g and groups them into a cut list in
+// the design's units. Every placement of a part counts once toward its
+// item's quantity.
+func Build(g *graph.DesignGraph) (*CutList, error) {
+	units := g.Defaults.Units
+	if units == "" {
+		units = "mm"
+	}
+	scale, ok := graph.UnitScale[units]
+	if !ok {
+		return nil, fmt.Errorf("cutlist: unsupported units %q", units)
+	}
+
+	cl := &CutList{Units: units, Variant: g.Variant, Items: []Item{}}
+	if g.Fingerprint != nil {
+		cl.Fingerprint = g.Fingerprint.String()
+	}
+
+	type key struct {
+		kind             string
+		l, w, t          float64
+		rl, rw, rt       float64
+		nominal, species string
+		grade            string
+	}
+	index := make(map[key]int)
+	for _, p := range g.Placements() {
+		item, ok := cutItem(g, p.Part)
+		if !ok {
+			continue
+		}
+		item.Length = round(item.Length * scale)
+		item.Width = round(item.Width * scale)
+		item.Thickness = round(item.Thickness * scale)
+		item.RoughLength = round(item.RoughLength * scale)
+		item.RoughWidth = round(item.RoughWidth * scale)
+		item.RoughThickness = round(item.RoughThickness * scale)
+
+		k := key{item.Kind, item.Length, item.Width, item.Thickness,
+			item.RoughLength, item.RoughWidth, item.RoughThickness, item.Nominal, item.Species, item.Grade}
+		i, seen := index[k]
+		if !seen {
+			i = len(cl.Items)
+			index[k] = i
+			cl.Items = append(cl.Items, item)
+		}
+		it := &cl.Items[i]
+		it.Quantity++
+		it.Parts = appendUnique(it.Parts, partName(p.Part))
+		for _, n := range p.Path {
+			if n.Kind == graph.NodeGroup && n.Name != "" {
+				it.Assemblies = appendUnique(it.Assemblies, n.Name)
+			}
+		}
+	}
+
+	for i := range cl.Items {
+		sort.Strings(cl.Items[i].Assemblies)
+	}
+	// Grouped by species, grade and kind, so that each material reads as
+	// one block; within a group the thickest stock first, as it is usually
+	// milled first, then the widest and longest. Ties keep placement order.
+	sort.SliceStable(cl.Items, func(i, j int) bool {
+		a, b := cl.Items[i], cl.Items[j]
+		switch {
+		case a.Species != b.Species:
+			return a.Species < b.Species
+		case a.Grade != b.Grade:
+			return a.Grade < b.Grade
+		case a.Kind != b.Kind:
+			return a.Kind < b.Kind
+		case a.Thickness != b.Thickness:
+			return a.Thickness > b.Thickness
+		case a.Width != b.Width:
+			return a.Width > b.Width
+		default:
+			return a.Length > b.Length
+		}
+	})
+	return cl, nil
+}
+
+// cutItem describes a single part in millimetres, or returns false for
+// primitives that are not cut from stock.
+func cutItem(g *graph.DesignGraph, n *graph.Node) (Item, bool) {
+	switch d := n.Data.(type) {
+	case graph.BoardData:
+		dims := [3]float64{d.Dimensions.X, d.Dimensions.Y, d.Dimensions.Z}
+		var across []float64
+		for i, v := range dims {
+			if graph.Axis(i) != d.Grain {
+				across = append(across, v)
+			}
+		}
+		it := Item{
+			Kind:      "board",
+			Length:    dims[d.Grain],
+			Width:     math.Max(across[0], across[1]),
+			Thickness: math.Min(across[0], across[1]),
+			Species:   species(g, d.Material),
+			Grade:     grade(g, d.Material),
+		}
+		roughen(&it, d.Nominal, d.Allowance(g.Defaults))
+		return it, true
+	case graph.DowelData:
+		return Item{
+			Kind:           "dowel",
+			Length:         d.Length,
+			Width:          d.Diameter,
+			Thickness:      d.Diameter,
+			RoughLength:    d.Length,
+			RoughWidth:     d.Diameter,
+			RoughThickness: d.Diameter,
+			Species:        species(g, d.Material),
+			Grade:          grade(g, d.Material),
+		}, true
+	}
+	return Item{}, false
+}
+
+// roughen sets the rough dimensions of a board item, in millimetres, from
+// its finished ones, the nominal size the board was specified by and its
+// milling allowance. S4S lumber is bought milled to width and thickness and
+// only needs its length allowance; a quarter thickness such as "8/4" is
+// the rough thickness. Otherwise the allowance is added and, if it asks
+// for it, the thickness rounded up to the next quarter.
+func roughen(it *Item, nominal string, a graph.MillingAllowance) {
+	it.RoughLength = it.Length + a.Length
+	it.RoughWidth = it.Width + a.Width
+	it.RoughThickness = it.Thickness + a.Thickness
+	if _, _, ok := graph.ParseS4S(nominal); ok {
+		it.RoughWidth, it.RoughThickness, it.Nominal = it.Width, it.Thickness, nominal
+		return
+	}
+	q, ok := graph.ParseQuarter(nominal)
+	if !ok && a.Nominal {
+		q, ok = graph.RoundToQuarter(it.RoughThickness)
+	}
+	if ok {
+		it.RoughThickness, it.Nominal = q.Rough, q.Name
+	}
+}
+
+// Rough returns the item's rough dimensions, or its finished ones for an
+// item without rough dimensions.
+func (it Item) Rough() (length, width, thickness float64) {
+	if it.RoughLength == 0 {
+		return it.Length, it.Width, it.Thickness
+	}
+	return it.RoughLength, it.RoughWidth, it.RoughThickness
+}
+
+// species returns the normalized species of a part, falling back to the
+// design's default material.
+func species(g *graph.DesignGraph, m graph.MaterialSpec) string {
+	if m.Species != "" {
+		return graph.NormalizeSpecies(m.Species)
+	}
+	return graph.NormalizeSpecies(g.Defaults.Material.Species)
+}
+
+// grade returns the lumber grade of a part, falling back to the design's
+// default material.
+func grade(g *graph.DesignGraph, m graph.MaterialSpec) string {
+	if m.Grade != "" {
+		return strings.TrimSpace(m.Grade)
+	}
+	return strings.TrimSpace(g.Defaults.Material.Grade)
+}
+
+func partName(n *graph.Node) string {
+	if n.Name != "" {
+		return n.Name
+	}
+	return n.ID.Short()
+}
+
+// round drops floating-point noise below a hundredth of a unit, so that
+// parts computed from expressions group with their literal twins.
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, have := range list {
+		if have == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,235 @@
+package cutlist
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chazu/lignin/pkg/engine"
+	"github.com/chazu/lignin/pkg/graph"
+)
+
+func evaluate(t *testing.T, source string, opts engine.EvalOptions) *graph.DesignGraph {
+	t.Helper()
+	res, err := engine.NewEngine().EvaluateWithOptions(source, opts)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("eval errors: %v", res.Errors)
+	}
+	return res.Graph
+}
+
+func TestBuildBoxExample(t *testing.T) {
+	source, err := os.ReadFile("../../examples/box.lignin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl, err := Build(evaluate(t, string(source), engine.EvalOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Item{
+		{Kind: "board", Length: 362, Width: 262, Thickness: 19, RoughLength: 362, RoughWidth: 262, RoughThickness: 19, Species: "white-oak", Quantity: 1, Parts: []string{"bottom"}, Assemblies: []string{"box"}},
+		{Kind: "board", Length: 400, Width: 200, Thickness: 19, RoughLength: 400, RoughWidth: 200, RoughThickness: 19, Species: "white-oak", Quantity: 2, Parts: []string{"front", "back"}, Assemblies: []string{"box"}},
+		{Kind: "board", Length: 262, Width: 200, Thickness: 19, RoughLength: 262, RoughWidth: 200, RoughThickness: 19, Species: "white-oak", Quantity: 2, Parts: []string{"left", "right"}, Assemblies: []string{"box"}},
+	}
+	if !reflect.DeepEqual(cl.Items, want) {
+		t.Errorf("items = %+v\nwant %+v", cl.Items, want)
+	}
+	if cl.Units != "mm" || !strings.HasPrefix(cl.Fingerprint, "lignin-fp/1 ") {
+		t.Errorf("units = %q, fingerprint = %q", cl.Units, cl.Fingerprint)
+	}
+}
+
+const shelfSource = `
+(def depth (param "depth" 250 :min 200 :max 300))
+(variant "deep" :depth 300)
+(defpart "shelf" (board :length 600 :width depth :thickness 19 :grain :x
+                        :material (material :species "Walnut" :grade "FAS")))
+(defpart "upright" (board :length 19 :width 400 :thickness depth :grain :y))
+(assembly "bay"
+  (place (part "upright") :at (vec3 0 0 0))
+  (place (part "shelf") :at (vec3 19 0 0))
+  (place (part "shelf") :at (vec3 19 200 0))
+  (place (part "upright") :at (vec3 619 0 0)))
+`
+
+func TestBuildCountsPlacements(t *testing.T) {
+	cl, err := Build(evaluate(t, shelfSource, engine.EvalOptions{Variant: "deep"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cl.Variant != "deep" {
+		t.Errorf("variant = %q, want deep", cl.Variant)
+	}
+	want := []Item{
+		{Kind: "board", Length: 400, Width: 300, Thickness: 19, RoughLength: 400, RoughWidth: 300, RoughThickness: 19, Quantity: 2, Parts: []string{"upright"}, Assemblies: []string{"bay"}},
+		{Kind: "board", Length: 600, Width: 300, Thickness: 19, RoughLength: 600, RoughWidth: 300, RoughThickness: 19, Species: "walnut", Grade: "FAS", Quantity: 2, Parts: []string{"shelf"}, Assemblies: []string{"bay"}},
+	}
+	if !reflect.DeepEqual(cl.Items, want) {
+		t.Errorf("items = %+v\nwant %+v", cl.Items, want)
+	}
+}
+
+func TestBuildCountsNestedAssemblies(t *testing.T) {
+	cl, err := Build(evaluate(t, chestSource, engine.EvalOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The drawer is placed three times in the chest, and nowhere else.
+	got := make(map[string]int)
+	for _, it := range cl.Items {
+		got[strings.Join(it.Parts, ",")] += it.Quantity
+		if want := []string{"chest", "drawer"}; !reflect.DeepEqual(it.Assemblies, want) {
+			t.Errorf("%v assemblies = %q, want %q", it.Parts, it.Assemblies, want)
+		}
+	}
+	if want := map[string]int{"front": 3, "side": 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("quantities = %v, want %v", got, want)
+	}
+}
+
+func TestBuildConvertsUnits(t *testing.T) {
+	g := evaluate(t, shelfSource, engine.EvalOptions{})
+	g.Defaults.Units = "in"
+	cl, err := Build(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if it := cl.Items[1]; it.Length != 23.62 || it.Width != 9.84 || it.Thickness != 0.75 {
+		t.Errorf("shelf = %v x %v x %v in, want 23.62 x 9.84 x 0.75", it.Length, it.Width, it.Thickness)
+	}
+
+	g.Defaults.Units = "furlong"
+	if _, err := Build(g); err == nil {
+		t.Error("expected an error for unsupported units")
+	}
+}
+
+func TestFormats(t *testing.T) {
+	cl, err := Build(evaluate(t, shelfSource, engine.EvalOptions{Variant: "deep"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var js bytes.Buffer
+	if err := cl.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	var decoded CutList
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&decoded, cl) {
+		t.Errorf("JSON round trip = %+v, want %+v", decoded, cl)
+	}
+
+	var cs bytes.Buffer
+	if err := cl.WriteCSV(&cs); err != nil {
+		t.Fatal(err)
+	}
+	csvText := cs.String()
+	r := csv.NewReader(strings.NewReader(csvText))
+	r.Comment = '#'
+	rows, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRows := [][]string{
+		{"quantity", "kind", "length_mm", "width_mm", "thickness_mm",
+			"rough_length_mm", "rough_width_mm", "rough_thickness_mm", "nominal", "species", "grade", "parts", "assemblies"},
+		{"2", "board", "400", "300", "19", "400", "300", "19", "", "", "", "upright", "bay"},
+		{"2", "board", "600", "300", "19", "600", "300", "19", "", "walnut", "FAS", "shelf", "bay"},
+	}
+	if !reflect.DeepEqual(rows, wantRows) {
+		t.Errorf("CSV rows = %q, want %q", rows, wantRows)
+	}
+
+	var md bytes.Buffer
+	if err := cl.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(md.String(), "| 2 | shelf | 600 | 300 | 19 | walnut (FAS) | bay |\n") ||
+		!strings.Contains(md.String(), "- Variant: `deep`\n") {
+		t.Errorf("unexpected Markdown:\n%s", md.String())
+	}
+
+	// Every format carries a fingerprint that verify can find.
+	for name, out := range map[string]string{"json": js.String(), "csv": csvText, "markdown": md.String()} {
+		if _, ok := graph.FindFingerprint(out); !ok {
+			t.Errorf("%s output carries no fingerprint", name)
+		}
+	}
+}
+
+const roughSource = `
+(milling :length 50 :width 6 :thickness 3 :nominal true)
+(defpart "top" (board :length 600 :width 300 :thickness 19 :grain :x))
+(defpart "leg" (board :length 700 :width 50 :thickness "8/4" :grain :x))
+(defpart "rail" (board :length 500 :size "1x4" :grain :x))
+(defpart "panel" (board :length 400 :width 200 :thickness 12 :grain :x
+                        :milling (list :length 10)))
+(stock "oak-4/4" :thickness "4/4" :width 320 :length 2400)
+(assembly "table"
+  (place (part "top") :at (vec3 0 0 0))
+  (place (part "leg") :at (vec3 0 0 100))
+  (place (part "rail") :at (vec3 0 0 200))
+  (place (part "panel") :at (vec3 0 0 300)))
+`
+
+func TestBuildRoughDimensions(t *testing.T) {
+	g := evaluate(t, roughSource, engine.EvalOptions{})
+	cl, err := Build(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The design's allowance rounds up to a quarter thickness, a board
+	// given in quarters is that thick rough, S4S lumber is only cut to
+	// length, and a part's own allowance replaces the design's.
+	want := []Item{
+		{Kind: "board", Length: 700, Width: 50, Thickness: 44.5, RoughLength: 750, RoughWidth: 56, RoughThickness: 50.8, Nominal: "8/4",
+			Quantity: 1, Parts: []string{"leg"}, Assemblies: []string{"table"}},
+		{Kind: "board", Length: 500, Width: 88.9, Thickness: 19.05, RoughLength: 550, RoughWidth: 88.9, RoughThickness: 19.05, Nominal: "1x4",
+			Quantity: 1, Parts: []string{"rail"}, Assemblies: []string{"table"}},
+		{Kind: "board", Length: 600, Width: 300, Thickness: 19, RoughLength: 650, RoughWidth: 306, RoughThickness: 25.4, Nominal: "4/4",
+			Quantity: 1, Parts: []string{"top"}, Assemblies: []string{"table"}},
+		{Kind: "board", Length: 400, Width: 200, Thickness: 12, RoughLength: 410, RoughWidth: 200, RoughThickness: 12,
+			Quantity: 1, Parts: []string{"panel"}, Assemblies: []string{"table"}},
+	}
+	if !reflect.DeepEqual(cl.Items, want) {
+		t.Errorf("items = %+v\nwant %+v", cl.Items, want)
+	}
+
+	var md bytes.Buffer
+	if err := cl.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(md.String(), "| 1 | top | 600 | 300 | 19 | 650 × 306 × 25.4 (4/4) |  | table |\n") {
+		t.Errorf("unexpected Markdown:\n%s", md.String())
+	}
+
+	// Stock is allocated by rough size: the 8/4 leg blank does not fit 4/4
+	// stock, and the top's blank is laid out at its rough length.
+	a := Allocate(cl, g.Stock)
+	wantWarnings := []string{`no declared stock fits 1 × "leg" (750 × 56 × 50.8 mm rough)`}
+	if !reflect.DeepEqual(a.Warnings, wantWarnings) {
+		t.Errorf("warnings = %q, want %q", a.Warnings, wantWarnings)
+	}
+	if len(a.Boards) != 1 || len(a.Boards[0].Cuts) != 3 {
+		t.Fatalf("boards = %+v, want one board with three cuts", a.Boards)
+	}
+	if top := a.Boards[0].Cuts[0]; top.Parts[0] != "top" || top.RoughLength != 650 || top.Length != 600 {
+		t.Errorf("first cut = %+v, want the top's 650 mm blank", top)
+	}
+	if panel := a.Boards[0].Cuts[1]; panel.Parts[0] != "panel" || panel.At != 653 {
+		t.Errorf("second cut = %+v, want the panel after the top's blank and a kerf", panel)
+	}
+}
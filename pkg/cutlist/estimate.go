@@ -0,0 +1,317 @@
+package cutlist
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/chazu/lignin/pkg/graph"
+)
+
+// DefaultWaste is the fraction added to lumber quantities for defects,
+// offcuts and milling when a price table does not set one.
+const DefaultWaste = 0.2
+
+// boardFoot is the volume of a board foot (144 in³) in mm³.
+const boardFoot = 144 * 25.4 * 25.4 * 25.4
+
+// Pricing units for materials.
+const (
+	UnitBoardFoot   = "bf" // hardwood lumber, by volume
+	UnitSquareMetre = "m2" // sheet goods, by face area
+	UnitMetre       = "m"  // dowel rod and mouldings, by length
+	UnitEach        = "each"
+)
+
+// PriceTable holds a shop's material and fastener prices.
+type PriceTable struct {
+	Currency  string          `json:"currency,omitempty"` // e.g. "USD"; only used for display
+	Waste     *float64        `json:"waste,omitempty"`    // fraction added to material quantities; DefaultWaste if unset
+	Materials []MaterialPrice `json:"materials,omitempty"`
+	Fasteners []FastenerPrice `json:"fasteners,omitempty"`
+}
+
+// MaterialPrice prices a species and grade of stock. Grade, kind and
+// thickness narrow the entry; left empty they match anything, and the most
+// specific matching entry wins.
+type MaterialPrice struct {
+	Species   string  `json:"species"`
+	Grade     string  `json:"grade,omitempty"`
+	Kind      string  `json:"kind,omitempty"`      // "board" or "dowel"
+	Thickness float64 `json:"thickness,omitempty"` // mm, for sheet goods sold by thickness
+	Unit      string  `json:"unit,omitempty"`      // "bf" (default), "m2" or "m"
+	Price     float64 `json:"price"`               // per unit
+}
+
+// FastenerPrice prices a fastener. Zero diameter or length match any size,
+// and the most specific matching entry wins.
+type FastenerPrice struct {
+	Kind     string  `json:"kind"` // as in graph.FastenerKind.String
+	Diameter float64 `json:"diameter,omitempty"`
+	Length   float64 `json:"length,omitempty"`
+	Price    float64 `json:"price"` // each
+}
+
+// WasteFactor returns the table's waste fraction.
+func (p *PriceTable) WasteFactor() float64 {
+	if p == nil || p.Waste == nil {
+		return DefaultWaste
+	}
+	return *p.Waste
+}
+
+// LoadPrices reads a price table from JSON, such as
+//
+//	{"currency": "USD", "waste": 0.25,
+//	 "materials": [{"species": "walnut", "grade": "FAS", "price": 14.5},
+//	               {"species": "baltic-birch", "unit": "m2", "thickness": 18, "price": 32}],
+//	 "fasteners": [{"kind": "screw", "diameter": 4, "length": 40, "price": 0.06}]}
+func LoadPrices(r io.Reader) (*PriceTable, error) {
+	var p PriceTable
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("prices: %w", err)
+	}
+	if p.Waste != nil && *p.Waste < 0 {
+		return nil, fmt.Errorf("prices: waste must be non-negative, got %g", *p.Waste)
+	}
+	for i := range p.Materials {
+		m := &p.Materials[i]
+		m.Species = graph.NormalizeSpecies(m.Species)
+		m.Grade = strings.TrimSpace(m.Grade)
+		if m.Unit == "" {
+			m.Unit = UnitBoardFoot
+		}
+		switch {
+		case m.Species == "":
+			return nil, fmt.Errorf("prices: material %d has no species", i+1)
+		case m.Unit != UnitBoardFoot && m.Unit != UnitSquareMetre && m.Unit != UnitMetre:
+			return nil, fmt.Errorf("prices: %s: unknown unit %q, expected bf, m2 or m", m.Species, m.Unit)
+		case m.Kind != "" && m.Kind != "board" && m.Kind != "dowel":
+			return nil, fmt.Errorf("prices: %s: unknown kind %q, expected board or dowel", m.Species, m.Kind)
+		case m.Price < 0:
+			return nil, fmt.Errorf("prices: %s: price must be non-negative", m.Species)
+		}
+	}
+	for _, f := range p.Fasteners {
+		if _, ok := graph.ParseFastenerKind(f.Kind); !ok {
+			return nil, fmt.Errorf("prices: unknown fastener kind %q, expected screw/nail/dowel-pin/bolt", f.Kind)
+		}
+		if f.Price < 0 {
+			return nil, fmt.Errorf("prices: %s: price must be non-negative", f.Kind)
+		}
+	}
+	return &p, nil
+}
+
+// LoadPricesFile is LoadPrices for a file.
+func LoadPricesFile(path string) (*PriceTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p, err := LoadPrices(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// material returns the most specific entry pricing an item, or -1.
+func (p *PriceTable) material(it Item) int {
+	best, score := -1, -1
+	for i, m := range p.Materials {
+		if m.Species != it.Species ||
+			(m.Grade != "" && !strings.EqualFold(m.Grade, it.Grade)) ||
+			(m.Kind != "" && m.Kind != it.Kind) ||
+			(m.Thickness != 0 && math.Abs(m.Thickness-it.Thickness) > 0.01) {
+			continue
+		}
+		s := 0
+		if m.Grade != "" {
+			s += 4
+		}
+		if m.Thickness != 0 {
+			s += 2
+		}
+		if m.Kind != "" {
+			s++
+		}
+		if s > score {
+			best, score = i, s
+		}
+	}
+	return best
+}
+
+// fastener returns the most specific entry pricing a fastener, or -1.
+func (p *PriceTable) fastener(fd graph.FastenerData) int {
+	best, score := -1, -1
+	for i, f := range p.Fasteners {
+		if f.Kind != fd.Kind.String() ||
+			(f.Diameter != 0 && math.Abs(f.Diameter-fd.Diameter) > 0.01) ||
+			(f.Length != 0 && math.Abs(f.Length-fd.Length) > 0.01) {
+			continue
+		}
+		s := 0
+		if f.Diameter != 0 {
+			s += 2
+		}
+		if f.Length != 0 {
+			s++
+		}
+		if s > score {
+			best, score = i, s
+		}
+	}
+	return best
+}
+
+// Estimate is a priced bill of materials: material quantities with waste
+// and the design's fasteners, costed from a price table.
+type Estimate struct {
+	Variant     string   `json:"variant,omitempty"`
+	Fingerprint string   `json:"fingerprint,omitempty"`
+	Currency    string   `json:"currency,omitempty"`
+	Waste       float64  `json:"waste"` // fraction added to material quantities
+	Lines       []Line   `json:"lines"`
+	Materials   float64  `json:"materials"` // cost of material lines
+	Hardware    float64  `json:"hardware"`  // cost of fastener lines
+	Total       float64  `json:"total"`
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// Line is one entry of the bill of materials. Unpriced lines carry their
+// quantities but no cost.
+type Line struct {
+	Category  string   `json:"category"` // "board", "dowel" or "fastener"
+	Item      string   `json:"item"`     // e.g. "walnut (FAS)" or "screw 4 × 40 mm"
+	Unit      string   `json:"unit"`     // "bf", "m2", "m" or "each"
+	Quantity  float64  `json:"quantity"` // net, from rough dimensions
+	Gross     float64  `json:"gross"`    // with waste; the quantity to buy
+	Priced    bool     `json:"priced"`
+	UnitPrice float64  `json:"unit_price,omitempty"`
+	Cost      float64  `json:"cost,omitempty"`
+	Parts     []string `json:"parts,omitempty"` // parts cut from the material
+}
+
+// BuildEstimate prices the cut list and fastener schedule of g. Each material line
+// gathers the cut-list items priced by one table entry; items without a
+// price are grouped by species and grade and measured in board feet, and
+// reported as warnings. prices may be nil, which prices nothing.
+func BuildEstimate(g *graph.DesignGraph, prices *PriceTable) (*Estimate, error) {
+	cl, err := Build(g)
+	if err != nil {
+		return nil, err
+	}
+	if prices == nil {
+		prices = &PriceTable{}
+	}
+	e := &Estimate{
+		Variant: cl.Variant, Fingerprint: cl.Fingerprint,
+		Currency: prices.Currency, Waste: prices.WasteFactor(), Lines: []Line{},
+	}
+
+	// Materials, in cut-list order of first appearance.
+	type matKey struct {
+		kind, species, grade string
+		entry                int
+	}
+	scale := graph.UnitScale[cl.Units]
+	index := make(map[matKey]int)
+	for _, it := range cl.Items {
+		entry := prices.material(it)
+		k := matKey{it.Kind, it.Species, it.Grade, entry}
+		i, seen := index[k]
+		if !seen {
+			l := Line{Category: it.Kind, Item: material(it.Species, it.Grade), Unit: UnitBoardFoot}
+			if l.Item == "" {
+				l.Item = "unspecified"
+			}
+			if entry >= 0 {
+				m := prices.Materials[entry]
+				l.Unit, l.Priced, l.UnitPrice = m.Unit, true, m.Price
+				if m.Thickness != 0 {
+					l.Item += fmt.Sprintf(", %s mm", formatDim(m.Thickness))
+				}
+			}
+			i = len(e.Lines)
+			index[k] = i
+			e.Lines = append(e.Lines, l)
+		}
+		l := &e.Lines[i]
+		l.Quantity += float64(it.Quantity) * measure(it, l.Unit, scale)
+		for _, p := range it.Parts {
+			l.Parts = appendUnique(l.Parts, p)
+		}
+	}
+	for i := range e.Lines {
+		l := &e.Lines[i]
+		l.Gross = round(l.Quantity * (1 + e.Waste))
+		l.Quantity = round(l.Quantity)
+		if !l.Priced {
+			e.Warnings = append(e.Warnings, fmt.Sprintf("no price for %s %ss", l.Item, l.Category))
+			continue
+		}
+		l.Cost = round(l.Gross * l.UnitPrice)
+		e.Materials += l.Cost
+	}
+
+	// Fasteners, from the hardware schedule, which counts a join once per
+	// placed instance of its assembly as the cut list counts boards. Sizes
+	// that differ only in head diameter are bought and priced alike.
+	sched, err := BuildSchedule(g)
+	if err != nil {
+		return nil, err
+	}
+	fastIndex := make(map[string]int)
+	for _, f := range sched.Fasteners {
+		item := f.Kind + " " + f.Size
+		i, seen := fastIndex[item]
+		if !seen {
+			l := Line{Category: "fastener", Item: item, Unit: UnitEach}
+			if entry := prices.fastener(f.data); entry >= 0 {
+				l.Priced, l.UnitPrice = true, prices.Fasteners[entry].Price
+			}
+			i = len(e.Lines)
+			fastIndex[item] = i
+			e.Lines = append(e.Lines, l)
+		}
+		e.Lines[i].Quantity += float64(f.Quantity)
+	}
+	fasteners := e.Lines[len(index):]
+	for i := range fasteners {
+		l := &fasteners[i]
+		l.Gross = l.Quantity
+		if !l.Priced {
+			e.Warnings = append(e.Warnings, fmt.Sprintf("no price for %s", l.Item))
+			continue
+		}
+		l.Cost = round(l.Quantity * l.UnitPrice)
+		e.Hardware += l.Cost
+	}
+
+	e.Materials = round(e.Materials)
+	e.Hardware = round(e.Hardware)
+	e.Total = round(e.Materials + e.Hardware)
+	return e, nil
+}
+
+// measure returns the quantity of one piece in a pricing unit, from its
+// rough dimensions in cut-list units.
+func measure(it Item, unit string, scale float64) float64 {
+	l, w, t := it.Rough()
+	l, w, t = l/scale, w/scale, t/scale
+	switch unit {
+	case UnitSquareMetre:
+		return l * w / 1e6
+	case UnitMetre:
+		return l / 1000
+	}
+	return l * w * t / boardFoot
+}
@@ -0,0 +1,170 @@
+package cutlist
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chazu/lignin/pkg/engine"
+	"github.com/chazu/lignin/pkg/graph"
+)
+
+func loadPrices(t *testing.T, js string) *PriceTable {
+	t.Helper()
+	p, err := LoadPrices(strings.NewReader(js))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestEstimateBoxExample(t *testing.T) {
+	source, err := os.ReadFile("../../examples/box.lignin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	prices := loadPrices(t, `{"currency": "USD", "waste": 0.25,
+		"materials": [{"species": "White Oak", "price": 10}],
+		"fasteners": [{"kind": "screw", "price": 0.05}]}`)
+	e, err := BuildEstimate(evaluate(t, string(source), engine.EvalOptions{}), prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 6,833,052 mm³ of finished oak is 2.90 bf, 3.62 bf with waste.
+	want := []Line{
+		{Category: "board", Item: "white-oak", Unit: "bf", Quantity: 2.9, Gross: 3.62, Priced: true, UnitPrice: 10, Cost: 36.2,
+			Parts: []string{"bottom", "front", "back", "left", "right"}},
+		{Category: "fastener", Item: "screw 4 × 50 mm", Unit: "each", Quantity: 4, Gross: 4, Priced: true, UnitPrice: 0.05, Cost: 0.2},
+	}
+	if !reflect.DeepEqual(e.Lines, want) {
+		t.Errorf("lines = %+v\nwant %+v", e.Lines, want)
+	}
+	if e.Materials != 36.2 || e.Hardware != 0.2 || e.Total != 36.4 {
+		t.Errorf("totals = %v + %v = %v, want 36.2 + 0.2 = 36.4", e.Materials, e.Hardware, e.Total)
+	}
+	if len(e.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", e.Warnings)
+	}
+}
+
+func TestEstimatePrefersSpecificPrices(t *testing.T) {
+	g := evaluate(t, shelfSource, engine.EvalOptions{})
+	prices := loadPrices(t, `{"waste": 0,
+		"materials": [{"species": "walnut", "price": 8},
+		              {"species": "walnut", "grade": "fas", "price": 14},
+		              {"species": "walnut", "grade": "FAS", "unit": "m2", "thickness": 12, "price": 90}]}`)
+	e, err := BuildEstimate(g, prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The 19 mm shelves take the FAS board-foot price: 2 × 600 × 250 × 19
+	// mm³ is 2.42 bf. The uprights have no species and no price.
+	shelves := e.Lines[1]
+	if shelves.Item != "walnut (FAS)" || shelves.Unit != "bf" || shelves.UnitPrice != 14 ||
+		shelves.Quantity != 2.42 || shelves.Cost != 33.88 {
+		t.Errorf("shelves = %+v", shelves)
+	}
+	if uprights := e.Lines[0]; uprights.Priced || uprights.Item != "unspecified" {
+		t.Errorf("uprights = %+v", uprights)
+	}
+	if want := []string{"no price for unspecified boards"}; !reflect.DeepEqual(e.Warnings, want) {
+		t.Errorf("warnings = %q, want %q", e.Warnings, want)
+	}
+
+	// Sheet goods are priced by face area: 2 × 0.6 × 0.25 m².
+	prices.Materials[2].Thickness = 19
+	e, err = BuildEstimate(g, prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shelves := e.Lines[1]; shelves.Item != "walnut (FAS), 19 mm" || shelves.Unit != "m2" ||
+		shelves.Quantity != 0.3 || shelves.Cost != 27 {
+		t.Errorf("sheet shelves = %+v", shelves)
+	}
+}
+
+func TestEstimateCountsAssemblyInstances(t *testing.T) {
+	g := evaluate(t, chestSource, engine.EvalOptions{})
+	e, err := BuildEstimate(g, loadPrices(t, `{"fasteners": [{"kind": "screw", "price": 0.1}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two screws are bought for each of the three drawers.
+	screws := e.Lines[len(e.Lines)-1]
+	if screws.Category != "fastener" || screws.Quantity != 6 || screws.Cost != 0.6 {
+		t.Errorf("screws = %+v, want 6 costing 0.6", screws)
+	}
+}
+
+func TestLoadPricesRejectsBadEntries(t *testing.T) {
+	for _, js := range []string{
+		`{"waste": -0.1}`,
+		`{"materials": [{"price": 3}]}`,
+		`{"materials": [{"species": "oak", "unit": "ft", "price": 3}]}`,
+		`{"materials": [{"species": "oak", "kind": "panel", "price": 3}]}`,
+		`{"materials": [{"species": "oak", "price": -3}]}`,
+		`{"fasteners": [{"kind": "rivet", "price": 1}]}`,
+		`{"material": []}`,
+	} {
+		if _, err := LoadPrices(strings.NewReader(js)); err == nil {
+			t.Errorf("LoadPrices(%s) succeeded, want an error", js)
+		}
+	}
+}
+
+func TestEstimateFormats(t *testing.T) {
+	g := evaluate(t, shelfSource, engine.EvalOptions{Variant: "deep"})
+	e, err := BuildEstimate(g, loadPrices(t, `{"currency": "EUR", "waste": 0.5,
+		"materials": [{"species": "walnut", "price": 12}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cs bytes.Buffer
+	if err := e.WriteCSV(&cs); err != nil {
+		t.Fatal(err)
+	}
+	csvText := cs.String()
+	r := csv.NewReader(strings.NewReader(csvText))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 × 600 × 300 × 19 mm³ of walnut is 2.9 bf, 4.35 bf with waste.
+	if want := []string{"board", "walnut (FAS)", "bf", "2.9", "4.35", "12.00", "52.20", "shelf"}; !reflect.DeepEqual(rows[2], want) {
+		t.Errorf("walnut row = %q, want %q", rows[2], want)
+	}
+	if last := rows[len(rows)-1]; last[0] != "total" || last[6] != "52.20" {
+		t.Errorf("total row = %q", last)
+	}
+
+	var md bytes.Buffer
+	if err := e.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"| walnut (FAS) boards | 2.9 | 4.35 | bf | 12.00 | 52.20 |\n",
+		"| unspecified boards | 1.93 | 2.9 | bf | — | — |\n",
+		"| **Total** | | | | | **52.20** |\n",
+		"- Currency: EUR\n",
+		"- no price for unspecified boards\n",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("Markdown lacks %q:\n%s", want, md.String())
+		}
+	}
+
+	for name, out := range map[string]string{"csv": csvText, "markdown": md.String()} {
+		if _, ok := graph.FindFingerprint(out); !ok {
+			t.Errorf("%s output carries no fingerprint", name)
+		}
+	}
+}
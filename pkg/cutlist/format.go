@@ -0,0 +1,439 @@
+package cutlist
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteJSON writes the cut list as indented JSON.
+func (c *CutList) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
+
+// WriteCSV writes one row per item under a header naming the units. The
+// variant and fingerprint precede the header as "#" comment lines, which
+// readers such as encoding/csv can be told to skip.
+func (c *CutList) WriteCSV(w io.Writer) error {
+	if c.Variant != "" {
+		if _, err := fmt.Fprintf(w, "# variant: %s\n", c.Variant); err != nil {
+			return err
+		}
+	}
+	if c.Fingerprint != "" {
+		if _, err := fmt.Fprintf(w, "# %s\n", c.Fingerprint); err != nil {
+			return err
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"quantity", "kind",
+		"length_" + c.Units, "width_" + c.Units, "thickness_" + c.Units,
+		"rough_length_" + c.Units, "rough_width_" + c.Units, "rough_thickness_" + c.Units, "nominal",
+		"species", "grade", "parts", "assemblies",
+	})
+	for _, it := range c.Items {
+		l, w, t := it.Rough()
+		cw.Write([]string{
+			strconv.Itoa(it.Quantity), it.Kind,
+			formatDim(it.Length), formatDim(it.Width), formatDim(it.Thickness),
+			formatDim(l), formatDim(w), formatDim(t), it.Nominal,
+			it.Species, it.Grade, strings.Join(it.Parts, "; "), strings.Join(it.Assemblies, "; "),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMarkdown writes the cut list as a Markdown table. A column of rough
+// dimensions follows the finished ones if any item has them.
+func (c *CutList) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# Cut list\n\n")
+	if c.Variant != "" {
+		fmt.Fprintf(&b, "- Variant: `%s`\n", c.Variant)
+	}
+	if c.Fingerprint != "" {
+		fmt.Fprintf(&b, "- Design: `%s`\n", c.Fingerprint)
+	}
+	fmt.Fprintf(&b, "- Units: %s\n\n", c.Units)
+
+	if len(c.Items) == 0 {
+		b.WriteString("No parts.\n")
+	} else {
+		showRough := hasRough(c.Items)
+		if showRough {
+			b.WriteString("| Qty | Parts | Length | Width | Thickness | Rough | Species | Assemblies |\n")
+			b.WriteString("|----:|-------|-------:|------:|----------:|-------|---------|------------|\n")
+		} else {
+			b.WriteString("| Qty | Parts | Length | Width | Thickness | Species | Assemblies |\n")
+			b.WriteString("|----:|-------|-------:|------:|----------:|---------|------------|\n")
+		}
+		for _, it := range c.Items {
+			parts := strings.Join(it.Parts, ", ")
+			if it.Kind != "board" {
+				parts += " (" + it.Kind + ")"
+			}
+			fmt.Fprintf(&b, "| %d | %s | %s | %s | %s | ",
+				it.Quantity, markdownCell(parts),
+				formatDim(it.Length), formatDim(it.Width), formatDim(it.Thickness))
+			if showRough {
+				fmt.Fprintf(&b, "%s | ", roughSize(it))
+			}
+			fmt.Fprintf(&b, "%s | %s |\n",
+				markdownCell(material(it.Species, it.Grade)), markdownCell(strings.Join(it.Assemblies, ", ")))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatDim(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// hasRough reports whether any item's rough dimensions differ from its
+// finished ones.
+func hasRough(items []Item) bool {
+	for _, it := range items {
+		if l, w, t := it.Rough(); l != it.Length || w != it.Width || t != it.Thickness || it.Nominal != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// roughSize formats an item's rough dimensions, with its nominal size if
+// it has one, as in "650 × 106 × 25.4 (4/4)".
+func roughSize(it Item) string {
+	l, w, t := it.Rough()
+	s := fmt.Sprintf("%s × %s × %s", formatDim(l), formatDim(w), formatDim(t))
+	if it.Nominal != "" {
+		s += " (" + it.Nominal + ")"
+	}
+	return s
+}
+
+// material names a species and grade for display, as in "walnut (FAS)".
+func material(species, grade string) string {
+	switch {
+	case grade == "":
+		return species
+	case species == "":
+		return "(" + grade + ")"
+	}
+	return species + " (" + grade + ")"
+}
+
+// markdownCell escapes pipes so that names cannot break the table.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// WriteJSON writes the allocation as indented JSON.
+func (a *Allocation) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
+
+// WriteCSV writes one row per cut, followed by one row per unallocated
+// item with empty stock columns. Warnings, the variant and the fingerprint
+// are "#" comment lines before the header.
+func (a *Allocation) WriteCSV(w io.Writer) error {
+	var header strings.Builder
+	if a.Variant != "" {
+		fmt.Fprintf(&header, "# variant: %s\n", a.Variant)
+	}
+	if a.Fingerprint != "" {
+		fmt.Fprintf(&header, "# %s\n", a.Fingerprint)
+	}
+	for _, msg := range a.Warnings {
+		fmt.Fprintf(&header, "# warning: %s\n", msg)
+	}
+	if _, err := io.WriteString(w, header.String()); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"stock", "board", "quantity", "parts",
+		"length_" + a.Units, "width_" + a.Units, "thickness_" + a.Units,
+		"rough_length_" + a.Units, "rough_width_" + a.Units, "rough_thickness_" + a.Units,
+		"at_" + a.Units, "strip_" + a.Units,
+	})
+	for _, b := range a.Boards {
+		for _, c := range b.Cuts {
+			cw.Write([]string{
+				b.Stock, strconv.Itoa(b.Index), "1", strings.Join(c.Parts, "; "),
+				formatDim(c.Length), formatDim(c.Width), formatDim(c.Thickness),
+				formatDim(c.RoughLength), formatDim(c.RoughWidth), formatDim(c.RoughThickness),
+				formatDim(c.At), formatDim(c.Strip),
+			})
+		}
+	}
+	for _, it := range a.Unallocated {
+		l, w, t := it.Rough()
+		cw.Write([]string{
+			"", "", strconv.Itoa(it.Quantity), strings.Join(it.Parts, "; "),
+			formatDim(it.Length), formatDim(it.Width), formatDim(it.Thickness),
+			formatDim(l), formatDim(w), formatDim(t), "", "",
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMarkdown writes one section per stock board, listing its cuts,
+// then the unallocated parts and warnings. Rough dimensions are shown if
+// any piece has them.
+func (a *Allocation) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# Stock cut list\n\n")
+	if a.Variant != "" {
+		fmt.Fprintf(&b, "- Variant: `%s`\n", a.Variant)
+	}
+	if a.Fingerprint != "" {
+		fmt.Fprintf(&b, "- Design: `%s`\n", a.Fingerprint)
+	}
+	fmt.Fprintf(&b, "- Units: %s\n", a.Units)
+
+	showRough := hasRough(a.Unallocated)
+	for _, sb := range a.Boards {
+		for _, c := range sb.Cuts {
+			showRough = showRough || c.RoughLength != c.Length || c.RoughWidth != c.Width || c.RoughThickness != c.Thickness
+		}
+	}
+
+	for _, sb := range a.Boards {
+		fmt.Fprintf(&b, "\n## %s #%d\n\n", markdownCell(sb.Stock), sb.Index)
+		fmt.Fprintf(&b, "%s × %s × %s", formatDim(sb.Length), formatDim(sb.Width), formatDim(sb.Thickness))
+		if sb.Species != "" {
+			fmt.Fprintf(&b, ", %s", sb.Species)
+		}
+		if showRough {
+			b.WriteString("\n\n| Parts | Length | Width | Thickness | Rough | At | Strip |\n")
+			b.WriteString("|-------|-------:|------:|----------:|-------|---:|------:|\n")
+		} else {
+			b.WriteString("\n\n| Parts | Length | Width | Thickness | At | Strip |\n")
+			b.WriteString("|-------|-------:|------:|----------:|---:|------:|\n")
+		}
+		for _, c := range sb.Cuts {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | ",
+				markdownCell(strings.Join(c.Parts, ", ")),
+				formatDim(c.Length), formatDim(c.Width), formatDim(c.Thickness))
+			if showRough {
+				fmt.Fprintf(&b, "%s × %s × %s | ", formatDim(c.RoughLength), formatDim(c.RoughWidth), formatDim(c.RoughThickness))
+			}
+			fmt.Fprintf(&b, "%s | %s |\n", formatDim(c.At), formatDim(c.Strip))
+		}
+	}
+
+	if len(a.Unallocated) > 0 {
+		b.WriteString("\n## Unallocated\n\n")
+		if showRough {
+			b.WriteString("| Qty | Parts | Length | Width | Thickness | Rough | Species |\n")
+			b.WriteString("|----:|-------|-------:|------:|----------:|-------|---------|\n")
+		} else {
+			b.WriteString("| Qty | Parts | Length | Width | Thickness | Species |\n")
+			b.WriteString("|----:|-------|-------:|------:|----------:|---------|\n")
+		}
+		for _, it := range a.Unallocated {
+			fmt.Fprintf(&b, "| %d | %s | %s | %s | %s | ",
+				it.Quantity, markdownCell(strings.Join(it.Parts, ", ")),
+				formatDim(it.Length), formatDim(it.Width), formatDim(it.Thickness))
+			if showRough {
+				fmt.Fprintf(&b, "%s | ", roughSize(it))
+			}
+			fmt.Fprintf(&b, "%s |\n", markdownCell(it.Species))
+		}
+	}
+	if len(a.Warnings) > 0 {
+		b.WriteString("\n## Warnings\n\n")
+		for _, msg := range a.Warnings {
+			fmt.Fprintf(&b, "- %s\n", msg)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the estimate as indented JSON.
+func (e *Estimate) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
+
+// WriteCSV writes one row per line of the bill of materials, followed by
+// subtotal and total rows. The currency, waste factor, warnings, variant
+// and fingerprint are "#" comment lines before the header.
+func (e *Estimate) WriteCSV(w io.Writer) error {
+	var header strings.Builder
+	if e.Variant != "" {
+		fmt.Fprintf(&header, "# variant: %s\n", e.Variant)
+	}
+	if e.Fingerprint != "" {
+		fmt.Fprintf(&header, "# %s\n", e.Fingerprint)
+	}
+	if e.Currency != "" {
+		fmt.Fprintf(&header, "# currency: %s\n", e.Currency)
+	}
+	fmt.Fprintf(&header, "# waste: %s\n", formatDim(e.Waste))
+	for _, msg := range e.Warnings {
+		fmt.Fprintf(&header, "# warning: %s\n", msg)
+	}
+	if _, err := io.WriteString(w, header.String()); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"category", "item", "unit", "quantity", "gross", "unit_price", "cost", "parts"})
+	for _, l := range e.Lines {
+		price, cost := "", ""
+		if l.Priced {
+			price, cost = money(l.UnitPrice), money(l.Cost)
+		}
+		cw.Write([]string{
+			l.Category, l.Item, l.Unit, formatDim(l.Quantity), formatDim(l.Gross),
+			price, cost, strings.Join(l.Parts, "; "),
+		})
+	}
+	cw.Write([]string{"materials", "", "", "", "", "", money(e.Materials), ""})
+	cw.Write([]string{"hardware", "", "", "", "", "", money(e.Hardware), ""})
+	cw.Write([]string{"total", "", "", "", "", "", money(e.Total), ""})
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMarkdown writes the bill of materials as a Markdown table with
+// totals, followed by any warnings.
+func (e *Estimate) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# Bill of materials\n\n")
+	if e.Variant != "" {
+		fmt.Fprintf(&b, "- Variant: `%s`\n", e.Variant)
+	}
+	if e.Fingerprint != "" {
+		fmt.Fprintf(&b, "- Design: `%s`\n", e.Fingerprint)
+	}
+	if e.Currency != "" {
+		fmt.Fprintf(&b, "- Currency: %s\n", e.Currency)
+	}
+	fmt.Fprintf(&b, "- Waste: %s%%\n\n", formatDim(round(e.Waste*100)))
+
+	b.WriteString("| Item | Qty | With waste | Unit | Unit price | Cost |\n")
+	b.WriteString("|------|----:|-----------:|------|-----------:|-----:|\n")
+	for _, l := range e.Lines {
+		item := l.Item
+		if l.Category != "fastener" {
+			item += " " + l.Category + "s"
+		}
+		price, cost := "—", "—"
+		if l.Priced {
+			price, cost = money(l.UnitPrice), money(l.Cost)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+			markdownCell(item), formatDim(l.Quantity), formatDim(l.Gross), l.Unit, price, cost)
+	}
+	fmt.Fprintf(&b, "| **Materials** | | | | | %s |\n", money(e.Materials))
+	fmt.Fprintf(&b, "| **Hardware** | | | | | %s |\n", money(e.Hardware))
+	fmt.Fprintf(&b, "| **Total** | | | | | **%s** |\n", money(e.Total))
+
+	if len(e.Warnings) > 0 {
+		b.WriteString("\n## Warnings\n\n")
+		for _, msg := range e.Warnings {
+			fmt.Fprintf(&b, "- %s\n", msg)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func money(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// WriteJSON writes the schedule as indented JSON.
+func (s *Schedule) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// WriteCSV writes one row per fastener size under a header naming the
+// units, with the variant and fingerprint as "#" comment lines before it.
+func (s *Schedule) WriteCSV(w io.Writer) error {
+	var header strings.Builder
+	if s.Variant != "" {
+		fmt.Fprintf(&header, "# variant: %s\n", s.Variant)
+	}
+	if s.Fingerprint != "" {
+		fmt.Fprintf(&header, "# %s\n", s.Fingerprint)
+	}
+	if _, err := io.WriteString(w, header.String()); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"quantity", "kind", "size",
+		"diameter_" + s.Units, "length_" + s.Units, "head_dia_" + s.Units,
+		"pilot_hole_" + s.Units, "clearance_hole_" + s.Units, "joints",
+	})
+	for _, f := range s.Fasteners {
+		cw.Write([]string{
+			strconv.Itoa(f.Quantity), f.Kind, f.Size,
+			formatDim(f.Diameter), formatDim(f.Length), optionalDim(f.HeadDia),
+			optionalDim(f.PilotHole), optionalDim(f.ClearanceHole), strings.Join(f.Joints, "; "),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMarkdown writes the schedule as a purchasing list followed by a
+// table of drilling sizes.
+func (s *Schedule) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# Hardware schedule\n\n")
+	if s.Variant != "" {
+		fmt.Fprintf(&b, "- Variant: `%s`\n", s.Variant)
+	}
+	if s.Fingerprint != "" {
+		fmt.Fprintf(&b, "- Design: `%s`\n", s.Fingerprint)
+	}
+	fmt.Fprintf(&b, "- Units: %s\n\n", s.Units)
+
+	if len(s.Fasteners) == 0 {
+		b.WriteString("No fasteners.\n")
+	} else {
+		for _, f := range s.Fasteners {
+			fmt.Fprintf(&b, "- %s\n", f.Description())
+		}
+		b.WriteString("\n| Qty | Fastener | Head | Pilot hole | Clearance hole | Joints |\n")
+		b.WriteString("|----:|----------|-----:|-----------:|---------------:|--------|\n")
+		for _, f := range s.Fasteners {
+			fmt.Fprintf(&b, "| %d | %s | %s | %s | %s | %s |\n",
+				f.Quantity, markdownCell(f.Size+" "+f.Kind),
+				optionalDim(f.HeadDia), optionalDim(f.PilotHole), optionalDim(f.ClearanceHole),
+				markdownCell(strings.Join(f.Joints, ", ")))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// optionalDim formats a dimension that is zero when it does not apply.
+func optionalDim(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return formatDim(v)
+}
@@ -0,0 +1,168 @@
+package cutlist
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/chazu/lignin/pkg/graph"
+)
+
+// Schedule is a purchasing list of the fasteners a design uses.
+type Schedule struct {
+	Units       string     `json:"units"` // unit of all dimensions
+	Variant     string     `json:"variant,omitempty"`
+	Fingerprint string     `json:"fingerprint,omitempty"`
+	Fasteners   []Fastener `json:"fasteners"`
+}
+
+// Fastener is a group of identical fasteners: same kind, diameter, length
+// and head diameter.
+type Fastener struct {
+	Kind          string   `json:"kind"` // as in graph.FastenerKind.String
+	Size          string   `json:"size"` // trade size, e.g. "4 × 50 mm" or `#8 × 1-1/4"`
+	Diameter      float64  `json:"diameter"`
+	Length        float64  `json:"length"`
+	HeadDia       float64  `json:"head_dia,omitempty"`
+	PilotHole     float64  `json:"pilot_hole,omitempty"`     // see graph.FastenerData.PilotHole
+	ClearanceHole float64  `json:"clearance_hole,omitempty"` // see graph.FastenerData.ClearanceHole
+	Quantity      int      `json:"quantity"`
+	Joints        []string `json:"joints"` // joints the fasteners are driven through, in canonical order
+
+	data graph.FastenerData // a representative node, in millimetres
+}
+
+// BuildSchedule gathers the fasteners of every join in g. A join inside an
+// assembly that is placed several times is counted once per instance, and
+// a fastener node shared by several joins once per join.
+func BuildSchedule(g *graph.DesignGraph) (*Schedule, error) {
+	units := g.Defaults.Units
+	if units == "" {
+		units = "mm"
+	}
+	scale, ok := graph.UnitScale[units]
+	if !ok {
+		return nil, fmt.Errorf("cutlist: unsupported units %q", units)
+	}
+	s := &Schedule{Units: units, Variant: g.Variant, Fasteners: []Fastener{}}
+	if g.Fingerprint != nil {
+		s.Fingerprint = g.Fingerprint.String()
+	}
+
+	instances := make(map[graph.NodeID]int)
+	g.Walk(graph.Visitor{Pre: func(n *graph.Node, _ []*graph.Node) error {
+		if n.Kind == graph.NodeJoin {
+			instances[n.ID]++
+		}
+		return nil
+	}})
+
+	type key struct {
+		kind                      graph.FastenerKind
+		diameter, length, headDia float64
+	}
+	index := make(map[key]int)
+	for _, n := range g.Joins() {
+		jd := n.Data.(graph.JoinData)
+		count := max(1, instances[n.ID]) // joins outside any assembly still exist once
+		for _, id := range jd.Fasteners {
+			f := g.Nodes[id]
+			if f == nil {
+				continue
+			}
+			fd, ok := f.Data.(graph.FastenerData)
+			if !ok {
+				continue
+			}
+			k := key{fd.Kind, round(fd.Diameter * scale), round(fd.Length * scale), round(fd.HeadDia * scale)}
+			i, seen := index[k]
+			if !seen {
+				i = len(s.Fasteners)
+				index[k] = i
+				s.Fasteners = append(s.Fasteners, Fastener{
+					Kind: fd.Kind.String(), Size: tradeSize(fd, units),
+					Diameter: k.diameter, Length: k.length, HeadDia: k.headDia,
+					PilotHole:     round(fd.PilotHole() * scale),
+					ClearanceHole: round(fd.ClearanceHole() * scale),
+					data:          fd,
+				})
+			}
+			s.Fasteners[i].Quantity += count
+			s.Fasteners[i].Joints = appendUnique(s.Fasteners[i].Joints, jointName(g, n, jd))
+		}
+	}
+
+	sort.SliceStable(s.Fasteners, func(i, j int) bool {
+		a, b := s.Fasteners[i], s.Fasteners[j]
+		switch {
+		case a.Kind != b.Kind:
+			return a.Kind < b.Kind
+		case a.Diameter != b.Diameter:
+			return a.Diameter < b.Diameter
+		case a.Length != b.Length:
+			return a.Length < b.Length
+		default:
+			return a.HeadDia < b.HeadDia
+		}
+	})
+	return s, nil
+}
+
+// Description reads like a purchasing line, as in "48 × #8 × 1-1/4" screws".
+func (f Fastener) Description() string {
+	return fmt.Sprintf("%d × %s %ss", f.Quantity, f.Size, f.Kind)
+}
+
+// jointName names a join by its kind and the parts it connects, such as
+// "butt front/left".
+func jointName(g *graph.DesignGraph, n *graph.Node, jd graph.JoinData) string {
+	if n.Name != "" {
+		return n.Name
+	}
+	name := func(id graph.NodeID) string {
+		if p := g.Nodes[id]; p != nil {
+			return partName(p)
+		}
+		return id.Short()
+	}
+	return fmt.Sprintf("%s %s/%s", jd.Kind, name(jd.PartA), name(jd.PartB))
+}
+
+// tradeSize formats a fastener's diameter and length the way it is sold:
+// in millimetres for metric designs, and for inch designs as fractions,
+// with screws by wire gauge.
+func tradeSize(fd graph.FastenerData, units string) string {
+	if units != "in" {
+		scale := graph.UnitScale[units]
+		return fmt.Sprintf("%s × %s %s", formatDim(round(fd.Diameter*scale)), formatDim(round(fd.Length*scale)), units)
+	}
+	d, l := fd.Diameter/25.4, fd.Length/25.4
+	if fd.Kind == graph.FastenerScrew {
+		// ASME B18.6.1: a #N screw's major diameter is 0.060 + 0.013N in.
+		if n := math.Round((d - 0.060) / 0.013); n >= 0 && n <= 24 {
+			return fmt.Sprintf("#%d × %s", int(n), inches(l))
+		}
+	}
+	return fmt.Sprintf("%s × %s", inches(d), inches(l))
+}
+
+// inches formats a length in inches as a mixed fraction to the nearest
+// 1/64", such as 1-1/4", or as a decimal if it is not close to one.
+func inches(v float64) string {
+	n := math.Round(v * 64)
+	if math.Abs(v*64-n) > 0.1 {
+		return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64) + `"`
+	}
+	whole, num, den := int(n)/64, int(n)%64, 64
+	for num > 0 && num%2 == 0 {
+		num, den = num/2, den/2
+	}
+	switch {
+	case num == 0:
+		return strconv.Itoa(whole) + `"`
+	case whole == 0:
+		return fmt.Sprintf(`%d/%d"`, num, den)
+	}
+	return fmt.Sprintf(`%d-%d/%d"`, whole, num, den)
+}
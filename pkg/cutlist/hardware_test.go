@@ -0,0 +1,126 @@
+package cutlist
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/chazu/lignin/pkg/engine"
+	"github.com/chazu/lignin/pkg/graph"
+)
+
+func TestScheduleBoxExample(t *testing.T) {
+	source, err := os.ReadFile("../../examples/box.lignin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := BuildSchedule(evaluate(t, string(source), engine.EvalOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Fasteners) != 1 {
+		t.Fatalf("fasteners = %+v, want one size", s.Fasteners)
+	}
+	f := s.Fasteners[0]
+	if f.Kind != "screw" || f.Size != "4 × 50 mm" || f.Quantity != 4 || f.PilotHole != 3 || f.ClearanceHole != 4.5 {
+		t.Errorf("fastener = %+v", f)
+	}
+	if want := []string{"butt front/left", "butt front/right"}; !reflect.DeepEqual(f.Joints, want) {
+		t.Errorf("joints = %q, want %q", f.Joints, want)
+	}
+}
+
+const chestSource = `
+(fastener-rule :kind :screw :min-thread-engagement 3)
+(defpart "front" (board :length 400 :width 150 :thickness 19 :grain :x))
+(defpart "side" (board :length 300 :width 150 :thickness 22 :grain :y))
+(def drawer (assembly "drawer"
+  (place (part "front") :at (vec3 0 0 0))
+  (place (part "side") :at (vec3 0 0 19))
+  (butt-joint :part-a (part "front") :face-a :back :part-b (part "side") :face-b :front
+    :fasteners (list (screw :diameter 4.2 :length 31.75 :position (vec3 50 0 0))
+                     (screw :diameter 4.2 :length 31.75 :position (vec3 250 0 0))))))
+(assembly "chest"
+  (place drawer :at (vec3 0 0 0))
+  (place drawer :at (vec3 0 0 200))
+  (place drawer :at (vec3 0 0 400)))
+`
+
+func TestScheduleCountsInstances(t *testing.T) {
+	g := evaluate(t, chestSource, engine.EvalOptions{})
+	if r := graph.ValidateAll(g); len(r.Errors) != 0 || len(r.Warnings) != 0 {
+		t.Fatalf("fixture does not validate: %v %v", r.Errors, r.Warnings)
+	}
+	s, err := BuildSchedule(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each of the three drawers carries its joint's two screws.
+	if len(s.Fasteners) != 1 || s.Fasteners[0].Quantity != 6 {
+		t.Errorf("fasteners = %+v, want 6 screws", s.Fasteners)
+	}
+}
+
+func TestScheduleInchSizes(t *testing.T) {
+	s, err := BuildSchedule(evaluate(t, "(units :in)\n"+chestSource, engine.EvalOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := s.Fasteners[0]
+	if f.Size != `#8 × 1-1/4"` || f.Length != 1.25 {
+		t.Errorf("size = %q, length = %v; want #8 × 1-1/4\", 1.25", f.Size, f.Length)
+	}
+	if want := `× #8 × 1-1/4" screws`; !strings.HasSuffix(f.Description(), want) {
+		t.Errorf("description = %q, want suffix %q", f.Description(), want)
+	}
+
+	for v, want := range map[float64]string{0.25: `1/4"`, 2: `2"`, 1.0 + 5.0/16: `1-5/16"`, 0.3: `0.3"`} {
+		if got := inches(v); got != want {
+			t.Errorf("inches(%v) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestScheduleFormats(t *testing.T) {
+	s, err := BuildSchedule(evaluate(t, chestSource, engine.EvalOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := s.Fasteners[0]
+
+	var cs bytes.Buffer
+	if err := s.WriteCSV(&cs); err != nil {
+		t.Fatal(err)
+	}
+	csvText := cs.String()
+	r := csv.NewReader(strings.NewReader(csvText))
+	r.Comment = '#'
+	rows, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{strconv.Itoa(f.Quantity), "screw", "4.2 × 31.75 mm",
+		"4.2", "31.75", "", "3", "5", "butt front/side"}; !reflect.DeepEqual(rows[1], want) {
+		t.Errorf("CSV row = %q, want %q", rows[1], want)
+	}
+
+	var md bytes.Buffer
+	if err := s.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(md.String(), "- "+f.Description()+"\n") ||
+		!strings.Contains(md.String(), " | 4.2 × 31.75 mm screw |  | 3 | 5 | butt front/side |\n") {
+		t.Errorf("unexpected Markdown:\n%s", md.String())
+	}
+
+	for name, out := range map[string]string{"csv": csvText, "markdown": md.String()} {
+		if _, ok := graph.FindFingerprint(out); !ok {
+			t.Errorf("%s output carries no fingerprint", name)
+		}
+	}
+}
@@ -2,8 +2,8 @@ package engine
 
 import (
 	"fmt"
+	"slices"
 	"strings"
-	"sync/atomic"
 
 	"github.com/chazu/lignin/pkg/graph"
 	zygo "github.com/glycerine/zygomys/zygo"
@@ -267,6 +267,15 @@ func toKeywordString(s zygo.Sexp) (string, error) {
 	return str.S, nil
 }
 
+// toBool extracts a boolean from a Sexp.
+func toBool(s zygo.Sexp) (bool, error) {
+	b, ok := s.(*zygo.SexpBool)
+	if !ok {
+		return false, fmt.Errorf("expected true or false, got %T (%s)", s, s.SexpString(nil))
+	}
+	return b.Val, nil
+}
+
 // toAxis converts a keyword or string to a graph.Axis.
 func toAxis(s zygo.Sexp) (graph.Axis, error) {
 	name, err := toKeywordString(s)
@@ -321,6 +330,74 @@ func toMaterial(s zygo.Sexp) (graph.MaterialSpec, error) {
 	return graph.MaterialSpec{}, fmt.Errorf("expected material, got %T (%s)", s, s.SexpString(nil))
 }
 
+// toThickness extracts a thickness in mm from a number or a nominal
+// quarter thickness such as "8/4", which resolves to its S2S thickness if
+// surfaced is set and to its rough thickness otherwise. The quarter name
+// is returned alongside, empty for a number.
+func toThickness(s zygo.Sexp, surfaced bool) (float64, string, error) {
+	str, ok := s.(*zygo.SexpStr)
+	if !ok {
+		f, err := toFloat64(s)
+		return f, "", err
+	}
+	q, ok := graph.ParseQuarter(str.S)
+	if !ok {
+		return 0, "", fmt.Errorf("unknown nominal thickness %q, expected a number or one of 4/4, 5/4, 6/4, 8/4, 10/4, 12/4, 16/4", str.S)
+	}
+	if surfaced {
+		return q.Surfaced, q.Name, nil
+	}
+	return q.Rough, q.Name, nil
+}
+
+// toS4S extracts the actual thickness and width in mm of an S4S lumber
+// size such as "1x6".
+func toS4S(s zygo.Sexp) (thickness, width float64, size string, err error) {
+	size, err = toString(s)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	thickness, width, ok := graph.ParseS4S(size)
+	if !ok {
+		return 0, 0, "", fmt.Errorf("unknown S4S size %q, expected e.g. \"1x6\" or \"2x4\"", size)
+	}
+	return thickness, width, size, nil
+}
+
+// applyMilling sets the fields of m given as :length, :width, :thickness
+// and :nominal in pa.
+func applyMilling(form string, pa kwArgs, m *graph.MillingAllowance) error {
+	for _, f := range []struct {
+		kw  string
+		dst *float64
+	}{
+		{"length", &m.Length},
+		{"width", &m.Width},
+		{"thickness", &m.Thickness},
+	} {
+		v, ok := pa.kw[f.kw]
+		if !ok {
+			continue
+		}
+		x, err := toFloat64(v)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", form, f.kw, err)
+		}
+		if x < 0 {
+			return fmt.Errorf("%s: %s must not be negative", form, f.kw)
+		}
+		*f.dst = x
+	}
+	if v, ok := pa.kw["nominal"]; ok {
+		b, err := toBool(v)
+		if err != nil {
+			return fmt.Errorf("%s: nominal: %w", form, err)
+		}
+		m.Nominal = b
+	}
+	return nil
+}
+
 // sexpListToSlice converts a SexpPair (Lisp list) or SexpArray to a Go slice.
 func sexpListToSlice(s zygo.Sexp) ([]zygo.Sexp, error) {
 	switch v := s.(type) {
@@ -336,16 +413,126 @@ func sexpListToSlice(s zygo.Sexp) ([]zygo.Sexp, error) {
 	return nil, fmt.Errorf("expected list or array, got %T", s)
 }
 
+// toAnnotations extracts the :tags and :meta keyword arguments shared by
+// defpart, assembly and place. :tags takes a list of strings or keywords,
+// or a single one; :meta takes a list of alternating keys and values,
+// e.g. (list :finish "oil" :coats 2).
+func toAnnotations(form string, pa kwArgs) ([]string, map[string]string, error) {
+	var tags []string
+	if v, ok := pa.kw["tags"]; ok {
+		items := []zygo.Sexp{v}
+		if _, isStr := v.(*zygo.SexpStr); !isStr {
+			var err error
+			if items, err = sexpListToSlice(v); err != nil {
+				return nil, nil, fmt.Errorf("%s: tags: %w", form, err)
+			}
+		}
+		for _, item := range items {
+			tag, err := toKeywordString(item)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: tags: %w", form, err)
+			}
+			if tag == "" {
+				return nil, nil, fmt.Errorf("%s: tags: empty tag", form)
+			}
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	var meta map[string]string
+	if v, ok := pa.kw["meta"]; ok {
+		items, err := sexpListToSlice(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: meta: %w", form, err)
+		}
+		if len(items)%2 != 0 {
+			return nil, nil, fmt.Errorf("%s: meta: expected key/value pairs, got %d items", form, len(items))
+		}
+		meta = make(map[string]string, len(items)/2)
+		for i := 0; i < len(items); i += 2 {
+			key, err := toKeywordString(items[i])
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: meta: key: %w", form, err)
+			}
+			meta[key] = sexpText(items[i+1])
+		}
+	}
+	return tags, meta, nil
+}
+
 // ---------------------------------------------------------------------------
 // Node ID generation
 // ---------------------------------------------------------------------------
 
-// nodeCounter provides unique suffixes for anonymous nodes.
-var nodeCounter uint64
+// Anonymous nodes (placements, joints and fasteners) get IDs from where
+// they sit in the design rather than from the order they were evaluated in,
+// so that editing one of them leaves the IDs of the others alone and
+// graph.Diff can match them across edits. A joint is named by the parts and
+// faces it connects, a fastener by its joint and its index there, and a
+// placement by its assembly and the part it places:
+//
+//	butt-joint/front/left/left/front
+//	butt-joint/front/left/left/front/screw/2
+//	box/place/front#2
+//
+// Placements and fasteners are evaluated before the assembly or joint that
+// contains them, so they are added under a provisional ID and renamed by
+// adopt once their parent is known.
+
+// uniquePath returns path, or path#n for its nth use in this evaluation.
+func (st *evalState) uniquePath(path string) string {
+	st.paths[path]++
+	if n := st.paths[path]; n > 1 {
+		path += fmt.Sprintf("#%d", n)
+	}
+	return path
+}
+
+// addProvisional adds an anonymous node under a provisional ID made from
+// path and returns a reference to it.
+func (st *evalState) addProvisional(n *graph.Node, path string) *sexpNodeRef {
+	n.ID = graph.NewNodeID(st.uniquePath(path))
+	st.g.AddNode(n)
+	ref := &sexpNodeRef{id: n.ID}
+	st.provisional[n.ID] = ref
+	return ref
+}
+
+// adopt settles the ID of a node taken into a parent and returns it. A
+// provisional node is renamed to path, or keeps its provisional ID if path
+// is empty; any other node keeps its ID. Only the first parent adopts a
+// node, so a node shared by several parents keeps the first one's name.
+func (st *evalState) adopt(id graph.NodeID, path string) graph.NodeID {
+	ref, ok := st.provisional[id]
+	if !ok {
+		return id
+	}
+	delete(st.provisional, id)
+	n := st.g.Get(id)
+	if path == "" || n == nil {
+		return id
+	}
+	delete(st.g.Nodes, id)
+	n.ID = graph.NewNodeID(st.uniquePath(path))
+	st.g.AddNode(n)
+	ref.id = n.ID
+	return n.ID
+}
 
-func nextNodeSuffix() string {
-	n := atomic.AddUint64(&nodeCounter, 1)
-	return fmt.Sprintf("_anon_%d", n)
+// pathName names a node in an ID path: its own name, or the name of the
+// part a placement places.
+func (st *evalState) pathName(id graph.NodeID) string {
+	for n := st.g.Get(id); n != nil; n = st.g.Get(n.Children[0]) {
+		if n.Name != "" {
+			return n.Name
+		}
+		if n.Kind != graph.NodeTransform || len(n.Children) == 0 {
+			break
+		}
+	}
+	return id.Short()
 }
 
 // ---------------------------------------------------------------------------
@@ -353,16 +540,18 @@ func nextNodeSuffix() string {
 // ---------------------------------------------------------------------------
 
 // registerBuiltins installs all Lignin DSL builtins into a zygomys environment.
-// The builtins operate on the provided DesignGraph, populating it during evaluation.
+// The builtins operate on the DesignGraph held by st, populating it during
+// evaluation.
 //
 // Source code must be preprocessed with preprocessSource() before evaluation so
 // that :keyword tokens are converted to recognizable string literals.
-func registerBuiltins(env *zygo.Zlisp, g *graph.DesignGraph) {
+func registerBuiltins(env *zygo.Zlisp, st *evalState) {
+	g := st.g
 
 	// -----------------------------------------------------------------------
 	// (material :species "white-oak" :thickness 19 :grade "FAS")
 	// -----------------------------------------------------------------------
-	env.AddFunction("material", func(env *zygo.Zlisp, name string, args []zygo.Sexp) (zygo.Sexp, error) {
+	st.addFunction(env, "material", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
 		pa := parseArgs(args)
 		spec := graph.MaterialSpec{}
 
@@ -374,7 +563,7 @@ func registerBuiltins(env *zygo.Zlisp, g *graph.DesignGraph) {
 			spec.Species = s
 		}
 		if v, ok := pa.kw["thickness"]; ok {
-			f, err := toFloat64(v)
+			f, _, err := toThickness(v, false)
 			if err != nil {
 				return zygo.SexpNull, fmt.Errorf("material: thickness: %w", err)
 			}
@@ -393,8 +582,11 @@ func registerBuiltins(env *zygo.Zlisp, g *graph.DesignGraph) {
 
 	// -----------------------------------------------------------------------
 	// (board :length 400 :width 200 :thickness 19 :grain :z :material oak)
+	// (board :length 400 :size "1x6" :grain :x)
+	// (board :length 400 :width 200 :thickness "4/4" :grain :x
+	//        :milling (list :length 50 :width 6 :nominal true))
 	// -----------------------------------------------------------------------
-	env.AddFunction("board", func(env *zygo.Zlisp, name string, args []zygo.Sexp) (zygo.Sexp, error) {
+	st.addFunction(env, "board", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
 		pa := parseArgs(args)
 		bd := graph.BoardData{PrimKind: graph.PrimBoard}
 
@@ -413,11 +605,35 @@ func registerBuiltins(env *zygo.Zlisp, g *graph.DesignGraph) {
 			bd.Dimensions.Y = f
 		}
 		if v, ok := pa.kw["thickness"]; ok {
-			f, err := toFloat64(v)
+			f, nominal, err := toThickness(v, true)
 			if err != nil {
 				return zygo.SexpNull, fmt.Errorf("board: thickness: %w", err)
 			}
-			bd.Dimensions.Z = f
+			bd.Dimensions.Z, bd.Nominal = f, nominal
+		}
+		if v, ok := pa.kw["size"]; ok {
+			if _, ok := pa.kw["width"]; ok {
+				return zygo.SexpNull, fmt.Errorf("board: :size sets the width; do not give :width too")
+			}
+			if _, ok := pa.kw["thickness"]; ok {
+				return zygo.SexpNull, fmt.Errorf("board: :size sets the thickness; do not give :thickness too")
+			}
+			t, w, size, err := toS4S(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("board: size: %w", err)
+			}
+			bd.Dimensions.Y, bd.Dimensions.Z, bd.Nominal = w, t, size
+		}
+		if v, ok := pa.kw["milling"]; ok {
+			items, err := sexpListToSlice(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("board: milling: %w", err)
+			}
+			var m graph.MillingAllowance
+			if err := applyMilling("board: milling", parseArgs(items), &m); err != nil {
+				return zygo.SexpNull, err
+			}
+			bd.Milling = &m
 		}
 		if v, ok := pa.kw["grain"]; ok {
 			a, err := toAxis(v)
@@ -438,42 +654,23 @@ func registerBuiltins(env *zygo.Zlisp, g *graph.DesignGraph) {
 	})
 
 	// -----------------------------------------------------------------------
-	// (defpart "name" (board ...))
+	// (defpart "name" (board ...) :tags (list "visible-face") :meta (list ...))
+	// (redefpart "name" (board ...))
+	//
+	// defpart refuses to define a name twice; redefpart is the sanctioned
+	// way to replace an earlier definition.
 	// -----------------------------------------------------------------------
-	env.AddFunction("defpart", func(env *zygo.Zlisp, name string, args []zygo.Sexp) (zygo.Sexp, error) {
-		if len(args) < 2 {
-			return zygo.SexpNull, fmt.Errorf("defpart requires a name and a body expression")
-		}
-
-		partName, err := toString(args[0])
-		if err != nil {
-			return zygo.SexpNull, fmt.Errorf("defpart: name: %w", err)
-		}
-
-		var nodeData graph.NodeData
-		switch body := args[1].(type) {
-		case *sexpBoard:
-			nodeData = body.data
-		default:
-			return zygo.SexpNull, fmt.Errorf("defpart: expected board expression, got %T", args[1])
-		}
-
-		id := graph.NewNodeID(partName)
-		node := &graph.Node{
-			ID:   id,
-			Kind: graph.NodePrimitive,
-			Name: partName,
-			Data: nodeData,
-		}
-		g.AddNode(node)
-
-		return &sexpNodeRef{id: id, name: partName}, nil
+	st.addFunction(env, "defpart", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		return definePart(g, "defpart", src, args, false)
+	})
+	st.addFunction(env, "redefpart", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		return definePart(g, "redefpart", src, args, true)
 	})
 
 	// -----------------------------------------------------------------------
 	// (part "name")
 	// -----------------------------------------------------------------------
-	env.AddFunction("part", func(env *zygo.Zlisp, name string, args []zygo.Sexp) (zygo.Sexp, error) {
+	st.addFunction(env, "part", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
 		if len(args) < 1 {
 			return zygo.SexpNull, fmt.Errorf("part requires a name argument")
 		}
@@ -494,7 +691,7 @@ func registerBuiltins(env *zygo.Zlisp, g *graph.DesignGraph) {
 	// -----------------------------------------------------------------------
 	// (vec3 1 2 3)
 	// -----------------------------------------------------------------------
-	env.AddFunction("vec3", func(env *zygo.Zlisp, name string, args []zygo.Sexp) (zygo.Sexp, error) {
+	st.addFunction(env, "vec3", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
 		if len(args) != 3 {
 			return zygo.SexpNull, fmt.Errorf("vec3 requires exactly 3 arguments, got %d", len(args))
 		}
@@ -518,7 +715,7 @@ func registerBuiltins(env *zygo.Zlisp, g *graph.DesignGraph) {
 	// -----------------------------------------------------------------------
 	// (place (part "front") :at (vec3 0 0 19))
 	// -----------------------------------------------------------------------
-	env.AddFunction("place", func(env *zygo.Zlisp, name string, args []zygo.Sexp) (zygo.Sexp, error) {
+	st.addFunction(env, "place", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
 		pa := parseArgs(args)
 
 		if len(pa.positional) < 1 {
@@ -545,24 +742,23 @@ func registerBuiltins(env *zygo.Zlisp, g *graph.DesignGraph) {
 			}
 			td.Rotation = &vec
 		}
-
-		// Generate a deterministic ID from the child node name.
-		childNode := g.Get(childID)
-		idPath := "place/" + nextNodeSuffix()
-		if childNode != nil && childNode.Name != "" {
-			idPath = "place/" + childNode.Name
+		tags, meta, err := toAnnotations("place", pa)
+		if err != nil {
+			return zygo.SexpNull, err
 		}
-		id := graph.NewNodeID(idPath)
 
+		// The placement is named by the part it places; the assembly that
+		// takes it renames it under its own name.
+		childID = st.adopt(childID, "")
 		node := &graph.Node{
-			ID:       id,
 			Kind:     graph.NodeTransform,
+			Source:   src,
 			Children: []graph.NodeID{childID},
 			Data:     td,
+			Tags:     tags,
+			Meta:     meta,
 		}
-		g.AddNode(node)
-
-		return &sexpNodeRef{id: id}, nil
+		return st.addProvisional(node, "place/"+st.pathName(childID)), nil
 	})
 
 	// -----------------------------------------------------------------------
@@ -573,7 +769,7 @@ func registerBuiltins(env *zygo.Zlisp, g *graph.DesignGraph) {
 	// hyphens in identifiers. The preprocessor converts butt-joint to
 	// butt_joint in the source.
 	// -----------------------------------------------------------------------
-	env.AddFunction("butt_joint", func(env *zygo.Zlisp, name string, args []zygo.Sexp) (zygo.Sexp, error) {
+	st.addFunction(env, "butt_joint", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
 		pa := parseArgs(args)
 		jd := graph.JoinData{
 			Kind:   graph.JoinButt,
@@ -613,7 +809,14 @@ func registerBuiltins(env *zygo.Zlisp, g *graph.DesignGraph) {
 			if err != nil {
 				return zygo.SexpNull, fmt.Errorf("butt-joint: clearance: %w", err)
 			}
-			jd.Clearance = c
+			jd.Clearance = &c
+		}
+		if v, ok := pa.kw["glue-up"]; ok {
+			b, err := toBool(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("butt-joint: glue-up: %w", err)
+			}
+			jd.Params = graph.ButtJoinParams{GlueUp: b}
 		}
 		if v, ok := pa.kw["fasteners"]; ok {
 			items, err := sexpListToSlice(v)
@@ -629,13 +832,25 @@ func registerBuiltins(env *zygo.Zlisp, g *graph.DesignGraph) {
 			}
 		}
 
-		idPath := "butt-joint/" + nextNodeSuffix()
-		id := graph.NewNodeID(idPath)
+		jd.PartA, jd.PartB = st.adopt(jd.PartA, ""), st.adopt(jd.PartB, "")
+		path := st.uniquePath(fmt.Sprintf("butt-joint/%s/%s/%s/%s",
+			st.pathName(jd.PartA), jd.FaceA, st.pathName(jd.PartB), jd.FaceB))
+		for i, fid := range jd.Fasteners {
+			kind := "fastener"
+			if f := g.Get(fid); f != nil {
+				if fd, ok := f.Data.(graph.FastenerData); ok {
+					kind = fd.Kind.String()
+				}
+			}
+			jd.Fasteners[i] = st.adopt(fid, fmt.Sprintf("%s/%s/%d", path, kind, i+1))
+		}
+		id := graph.NewNodeID(path)
 
 		node := &graph.Node{
-			ID:   id,
-			Kind: graph.NodeJoin,
-			Data: jd,
+			ID:     id,
+			Kind:   graph.NodeJoin,
+			Source: src,
+			Data:   jd,
 		}
 		g.AddNode(node)
 
@@ -643,9 +858,10 @@ func registerBuiltins(env *zygo.Zlisp, g *graph.DesignGraph) {
 	})
 
 	// -----------------------------------------------------------------------
-	// (screw :diameter 4 :length 50 :position (vec3 0 50 0) :head-dia 8)
+	// (screw :diameter 4 :length 50 :position (vec3 0 50 0) :head-dia 8
+	//        :pilot-hole 3 :clearance-hole 4.5)
 	// -----------------------------------------------------------------------
-	env.AddFunction("screw", func(env *zygo.Zlisp, name string, args []zygo.Sexp) (zygo.Sexp, error) {
+	st.addFunction(env, "screw", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
 		pa := parseArgs(args)
 		fd := graph.FastenerData{Kind: graph.FastenerScrew}
 
@@ -677,50 +893,279 @@ func registerBuiltins(env *zygo.Zlisp, g *graph.DesignGraph) {
 			}
 			fd.HeadDia = f
 		}
+		if v, ok := pa.kw["pilot-hole"]; ok {
+			f, err := toFloat64(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("screw: pilot-hole: %w", err)
+			}
+			fd.PilotHoleDia = f
+		}
+		if v, ok := pa.kw["clearance-hole"]; ok {
+			f, err := toFloat64(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("screw: clearance-hole: %w", err)
+			}
+			fd.ClearanceHoleDia = f
+		}
 
-		idPath := "screw/" + nextNodeSuffix()
-		id := graph.NewNodeID(idPath)
-
+		// The joint that takes the screw renames it under its own ID.
 		node := &graph.Node{
-			ID:   id,
-			Kind: graph.NodeFastener,
-			Data: fd,
+			Kind:   graph.NodeFastener,
+			Source: src,
+			Data:   fd,
 		}
-		g.AddNode(node)
+		return st.addProvisional(node, "screw"), nil
+	})
 
-		return &sexpNodeRef{id: id}, nil
+	// -----------------------------------------------------------------------
+	// (fastener-rule :kind :screw :min-embedment 12 :min-thread-engagement 5)
+	// -----------------------------------------------------------------------
+	st.addFunction(env, "fastener_rule", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		pa := parseArgs(args)
+		v, ok := pa.kw["kind"]
+		if !ok {
+			return zygo.SexpNull, fmt.Errorf("fastener-rule requires :kind")
+		}
+		name, err := toKeywordString(v)
+		if err != nil {
+			return zygo.SexpNull, fmt.Errorf("fastener-rule: kind: %w", err)
+		}
+		kind, ok := graph.ParseFastenerKind(name)
+		if !ok {
+			return zygo.SexpNull, fmt.Errorf("fastener-rule: unknown fastener kind %q, expected screw/nail/dowel-pin/bolt", name)
+		}
+
+		// Unset fields keep the rule currently in force for the kind.
+		rule, _ := g.Defaults.FastenerRule(kind)
+		if v, ok := pa.kw["min-embedment"]; ok {
+			f, err := toFloat64(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("fastener-rule: min-embedment: %w", err)
+			}
+			rule.MinEmbedment = f
+		}
+		if v, ok := pa.kw["min-thread-engagement"]; ok {
+			f, err := toFloat64(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("fastener-rule: min-thread-engagement: %w", err)
+			}
+			rule.MinThreadEngagement = f
+		}
+
+		if g.Defaults.FastenerRules == nil {
+			g.Defaults.FastenerRules = make(map[string]graph.FastenerRule)
+		}
+		g.Defaults.FastenerRules[kind.String()] = rule
+		return zygo.SexpNull, nil
 	})
 
 	// -----------------------------------------------------------------------
-	// (assembly "name" (place ...) (place ...) (butt-joint ...) ...)
+	// (stock "walnut-8/4" :species "walnut" :thickness "8/4" :width 200
+	//        :length 2400 :qty 3 :kerf 3)
+	// (stock "pine-1x6" :size "1x6" :length 2438)
 	// -----------------------------------------------------------------------
-	env.AddFunction("assembly", func(env *zygo.Zlisp, name string, args []zygo.Sexp) (zygo.Sexp, error) {
-		if len(args) < 1 {
+	st.addFunction(env, "stock", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		pa := parseArgs(args)
+		if len(pa.positional) != 1 {
+			return zygo.SexpNull, fmt.Errorf("stock requires a name argument")
+		}
+		name, err := toString(pa.positional[0])
+		if err != nil {
+			return zygo.SexpNull, fmt.Errorf("stock: name: %w", err)
+		}
+		for _, prev := range g.Stock {
+			if prev.Name == name {
+				return zygo.SexpNull, fmt.Errorf("stock: %q redeclared; first declared at line %d, col %d",
+					name, prev.Source.Line, prev.Source.Col)
+			}
+		}
+
+		s := graph.Stock{Name: name, Quantity: 1, Source: src}
+		if v, ok := pa.kw["species"]; ok {
+			sp, err := toString(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("stock: species: %w", err)
+			}
+			s.Species = sp
+		}
+		if v, ok := pa.kw["size"]; ok {
+			if _, ok := pa.kw["width"]; ok {
+				return zygo.SexpNull, fmt.Errorf("stock: :size sets the width; do not give :width too")
+			}
+			if _, ok := pa.kw["thickness"]; ok {
+				return zygo.SexpNull, fmt.Errorf("stock: :size sets the thickness; do not give :thickness too")
+			}
+			t, w, size, err := toS4S(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("stock: size: %w", err)
+			}
+			s.Thickness, s.Width, s.Nominal = t, w, size
+		}
+		if v, ok := pa.kw["thickness"]; ok {
+			t, nominal, err := toThickness(v, false)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("stock: thickness: %w", err)
+			}
+			s.Thickness, s.Nominal = t, nominal
+		}
+		for _, f := range []struct {
+			kw  string
+			dst *float64
+		}{
+			{"width", &s.Width},
+			{"length", &s.Length},
+		} {
+			v, ok := pa.kw[f.kw]
+			if !ok {
+				continue
+			}
+			x, err := toFloat64(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("stock: %s: %w", f.kw, err)
+			}
+			*f.dst = x
+		}
+		if v, ok := pa.kw["kerf"]; ok {
+			k, err := toFloat64(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("stock: kerf: %w", err)
+			}
+			if k < 0 {
+				return zygo.SexpNull, fmt.Errorf("stock: kerf must not be negative")
+			}
+			s.Kerf = &k
+		}
+		if v, ok := pa.kw["qty"]; ok {
+			q, ok := v.(*zygo.SexpInt)
+			if !ok || q.Val < 1 {
+				return zygo.SexpNull, fmt.Errorf("stock: qty must be a positive integer, got %s", v.SexpString(nil))
+			}
+			s.Quantity = int(q.Val)
+		}
+		if s.Thickness <= 0 || s.Width <= 0 || s.Length <= 0 {
+			return zygo.SexpNull, fmt.Errorf("stock: %q needs positive :thickness, :width and :length", name)
+		}
+
+		g.Stock = append(g.Stock, s)
+		return zygo.SexpNull, nil
+	})
+
+	// -----------------------------------------------------------------------
+	// (wood-movement :min-humidity 30 :max-humidity 70 :threshold 1.5)
+	// -----------------------------------------------------------------------
+	st.addFunction(env, "wood_movement", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		pa := parseArgs(args)
+		settings := g.Defaults.MovementSettings()
+		for _, f := range []struct {
+			kw  string
+			dst *float64
+		}{
+			{"min-humidity", &settings.MinHumidity},
+			{"max-humidity", &settings.MaxHumidity},
+			{"threshold", &settings.Threshold},
+		} {
+			v, ok := pa.kw[f.kw]
+			if !ok {
+				continue
+			}
+			x, err := toFloat64(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("wood-movement: %s: %w", f.kw, err)
+			}
+			*f.dst = x
+		}
+		if settings.MinHumidity < 0 || settings.MaxHumidity > 100 || settings.MinHumidity > settings.MaxHumidity {
+			return zygo.SexpNull, fmt.Errorf("wood-movement: humidity range %g-%g%% must lie within 0-100%%",
+				settings.MinHumidity, settings.MaxHumidity)
+		}
+		g.Defaults.Movement = &settings
+		return zygo.SexpNull, nil
+	})
+
+	// -----------------------------------------------------------------------
+	// (milling :length 50 :width 6 :thickness 3 :nominal true)
+	// -----------------------------------------------------------------------
+	st.addFunction(env, "milling", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		m := g.Defaults.MillingAllowance()
+		if err := applyMilling("milling", parseArgs(args), &m); err != nil {
+			return zygo.SexpNull, err
+		}
+		g.Defaults.Milling = &m
+		return zygo.SexpNull, nil
+	})
+
+	// -----------------------------------------------------------------------
+	// (units :in)
+	//
+	// Source dimensions are always millimetres; units sets the units cut
+	// lists, schedules and estimates are reported in.
+	// -----------------------------------------------------------------------
+	st.addFunction(env, "units", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		if len(args) != 1 {
+			return zygo.SexpNull, fmt.Errorf("units requires one unit keyword, such as :in")
+		}
+		u, err := toKeywordString(args[0])
+		if err != nil {
+			return zygo.SexpNull, fmt.Errorf("units: %w", err)
+		}
+		if _, ok := graph.UnitScale[u]; !ok {
+			return zygo.SexpNull, fmt.Errorf("units: unknown unit %q, expected mm, cm, m or in", u)
+		}
+		g.Defaults.Units = u
+		return zygo.SexpNull, nil
+	})
+
+	// -----------------------------------------------------------------------
+	// (assembly "name" (place ...) (place ...) (butt-joint ...) ...
+	//           :tags (list "drawer-box") :meta (list :finish "oil"))
+	// -----------------------------------------------------------------------
+	st.addFunction(env, "assembly", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		pa := parseArgs(args)
+		if len(pa.positional) < 1 {
 			return zygo.SexpNull, fmt.Errorf("assembly requires a name argument")
 		}
 
-		asmName, err := toString(args[0])
+		asmName, err := toString(pa.positional[0])
 		if err != nil {
 			return zygo.SexpNull, fmt.Errorf("assembly: name: %w", err)
 		}
 
 		var children []graph.NodeID
-		for i := 1; i < len(args); i++ {
-			ref, ok := args[i].(*sexpNodeRef)
+		for i := 1; i < len(pa.positional); i++ {
+			ref, ok := pa.positional[i].(*sexpNodeRef)
 			if !ok {
 				return zygo.SexpNull, fmt.Errorf("assembly: child %d: expected node reference, got %T (%s)",
-					i, args[i], args[i].SexpString(nil))
+					i, pa.positional[i], pa.positional[i].SexpString(nil))
 			}
 			children = append(children, ref.id)
 		}
+		tags, meta, err := toAnnotations("assembly", pa)
+		if err != nil {
+			return zygo.SexpNull, err
+		}
+
+		if err := checkRedefinition(g, "assembly", asmName, src); err != nil {
+			return zygo.SexpNull, err
+		}
+		for i, c := range children {
+			path := ""
+			if n := g.Get(c); n != nil && n.Kind == graph.NodeTransform {
+				path = asmName + "/place/" + st.pathName(c)
+			}
+			children[i] = st.adopt(c, path)
+			unrootNested(g, children[i])
+		}
 
 		id := graph.NewNodeID(asmName)
 		node := &graph.Node{
 			ID:       id,
 			Kind:     graph.NodeGroup,
 			Name:     asmName,
+			Source:   src,
 			Children: children,
 			Data:     graph.GroupData{},
+			Tags:     tags,
+			Meta:     meta,
 		}
 		g.AddNode(node)
 		g.AddRoot(id)
@@ -728,3 +1173,86 @@ func registerBuiltins(env *zygo.Zlisp, g *graph.DesignGraph) {
 		return &sexpNodeRef{id: id, name: asmName}, nil
 	})
 }
+
+// unrootNested removes an assembly adopted by another, directly or through
+// a placement, from the graph's roots: it is reached through its parent
+// from now on, and walking it as a root too would count it once more.
+func unrootNested(g *graph.DesignGraph, id graph.NodeID) {
+	n := g.Get(id)
+	if n == nil {
+		return
+	}
+	switch n.Kind {
+	case graph.NodeGroup:
+		g.RemoveRoot(id)
+	case graph.NodeTransform:
+		for _, c := range n.Children {
+			unrootNested(g, c)
+		}
+	}
+}
+
+// definePart implements defpart and redefpart. When redefine is false, a
+// second definition of the same name is an error that names both sites.
+func definePart(g *graph.DesignGraph, form string, src graph.SourceRef, args []zygo.Sexp, redefine bool) (zygo.Sexp, error) {
+	pa := parseArgs(args)
+	if len(pa.positional) < 2 {
+		return zygo.SexpNull, fmt.Errorf("%s requires a name and a body expression", form)
+	}
+
+	partName, err := toString(pa.positional[0])
+	if err != nil {
+		return zygo.SexpNull, fmt.Errorf("%s: name: %w", form, err)
+	}
+
+	var nodeData graph.NodeData
+	switch body := pa.positional[1].(type) {
+	case *sexpBoard:
+		nodeData = body.data
+	default:
+		return zygo.SexpNull, fmt.Errorf("%s: expected board expression, got %T", form, pa.positional[1])
+	}
+	tags, meta, err := toAnnotations(form, pa)
+	if err != nil {
+		return zygo.SexpNull, err
+	}
+
+	if !redefine {
+		if err := checkRedefinition(g, form, partName, src); err != nil {
+			return zygo.SexpNull, err
+		}
+	} else if prev := g.Lookup(partName); prev != nil && prev.Kind != graph.NodePrimitive {
+		return zygo.SexpNull, fmt.Errorf("redefpart: %q is defined as a %s at %s, not a part",
+			partName, prev.Kind, prev.Source)
+	}
+
+	id := graph.NewNodeID(partName)
+	node := &graph.Node{
+		ID:     id,
+		Kind:   graph.NodePrimitive,
+		Name:   partName,
+		Source: src,
+		Data:   nodeData,
+		Tags:   tags,
+		Meta:   meta,
+	}
+	g.AddNode(node)
+
+	return &sexpNodeRef{id: id, name: partName}, nil
+}
+
+// checkRedefinition returns an error if name is already defined in g.
+// graph.AddNode silently overwrites, so definitions are checked here, where
+// both the original and the new definition site are still known.
+func checkRedefinition(g *graph.DesignGraph, form, name string, src graph.SourceRef) error {
+	prev := g.Lookup(name)
+	if prev == nil {
+		return nil
+	}
+	hint := ""
+	if prev.Kind == graph.NodePrimitive {
+		hint = "; use redefpart to override it intentionally"
+	}
+	return fmt.Errorf("%s: %q redefined at %s; first defined at %s%s",
+		form, name, src, prev.Source, hint)
+}
@@ -1,6 +1,8 @@
 package engine
 
 import (
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/chazu/lignin/pkg/graph"
@@ -245,6 +247,134 @@ func TestAssemblyWithPlacement(t *testing.T) {
 	}
 }
 
+func TestPlacingPartTwiceKeepsBothInstances(t *testing.T) {
+	eng := NewEngine()
+
+	source := `
+(defpart "side" (board :length 19 :width 720 :thickness 300))
+(assembly "carcase"
+  (place (part "side") :at (vec3 0 0 0))
+  (place (part "side") :at (vec3 10 0 0)))
+`
+	g, evalErrs, err := eng.Evaluate(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) > 0 {
+		t.Fatalf("eval errors: %v", evalErrs)
+	}
+
+	carcase := g.Lookup("carcase")
+	if len(carcase.Children) != 2 || carcase.Children[0] == carcase.Children[1] {
+		t.Fatalf("expected two distinct placements, got %v", carcase.Children)
+	}
+	if g.Get(graph.NewNodeID("carcase/place/side")) == nil || g.Get(graph.NewNodeID("carcase/place/side#2")) == nil {
+		t.Error("expected placements carcase/place/side and carcase/place/side#2")
+	}
+	if g.Get(graph.NewNodeID("place/side")) != nil {
+		t.Error("provisional placement ID place/side left in the graph")
+	}
+	if len(g.Placements()) != 2 {
+		t.Errorf("expected 2 placed instances, got %d", len(g.Placements()))
+	}
+
+	result := graph.ValidateAll(g)
+	if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, "overlap by 9 mm") {
+		t.Errorf("expected the overlapping sides to be reported, got %v", result.Errors)
+	}
+}
+
+func TestNestedAssemblyInstances(t *testing.T) {
+	eng := NewEngine()
+
+	source := `
+(defpart "front" (board :length 400 :width 150 :thickness 19))
+(defpart "back" (board :length 400 :width 150 :thickness 19))
+(def drawer (assembly "drawer"
+  (place (part "front") :at (vec3 0 0 0))
+  (place (part "back") :at (vec3 0 0 300))))
+(assembly "chest"
+  (place drawer :at (vec3 0 0 0))
+  (place drawer :at (vec3 0 200 0))
+  (place drawer :at (vec3 0 400 0)))
+`
+	g, evalErrs, err := eng.Evaluate(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) > 0 {
+		t.Fatalf("eval errors: %v", evalErrs)
+	}
+
+	// The drawer is reached through the chest only, not as a root of its
+	// own at the origin.
+	if want := []graph.NodeID{graph.NewNodeID("chest")}; !slices.Equal(g.Roots, want) {
+		t.Errorf("roots = %v, want only the chest", g.Roots)
+	}
+	counts := make(map[string]int)
+	for _, p := range g.Placements() {
+		counts[p.Part.Name]++
+	}
+	if counts["front"] != 3 || counts["back"] != 3 {
+		t.Errorf("instances = %v, want 3 fronts and 3 backs", counts)
+	}
+	if result := graph.ValidateAll(g); len(result.Errors) != 0 {
+		t.Errorf("unexpected errors: %v", result.Errors)
+	}
+}
+
+func TestAnonymousNodeIDsAreStructural(t *testing.T) {
+	const source = `
+(defpart "front" (board :length 400 :width 200 :thickness 19))
+(defpart "left" (board :length 19 :width 200 :thickness 262))
+(defpart "right" (board :length 19 :width 200 :thickness 262))
+(assembly "box"
+  (place (part "front") :at (vec3 0 0 0))
+  (place (part "left") :at (vec3 0 0 19))
+  (place (part "right") :at (vec3 381 0 19))
+  (butt-joint :part-a (part "front") :face-a :back :part-b (part "left") :face-b :front
+    :fasteners (list (screw :diameter 4 :length 50 :position (vec3 0 50 0))
+                     (screw :diameter 4 :length 50 :position (vec3 0 150 0))EXTRA))
+  (butt-joint :part-a (part "front") :face-a :back :part-b (part "right") :face-b :front
+    :fasteners (list (screw :diameter 4 :length 50 :position (vec3 0 50 0))
+                     (screw :diameter 4 :length 50 :position (vec3 0 150 0)))))
+`
+	eval := func(src string) *graph.DesignGraph {
+		t.Helper()
+		g, evalErrs, err := NewEngine().Evaluate(src)
+		if err != nil || len(evalErrs) > 0 {
+			t.Fatalf("evaluate: %v %v", err, evalErrs)
+		}
+		return g
+	}
+	old := eval(strings.Replace(source, "EXTRA", "", 1))
+	for _, path := range []string{
+		"box/place/front",
+		"butt-joint/front/back/left/front",
+		"butt-joint/front/back/left/front/screw/2",
+		"butt-joint/front/back/right/front/screw/1",
+	} {
+		if old.Get(graph.NewNodeID(path)) == nil {
+			t.Errorf("no node %s", path)
+		}
+	}
+
+	// Adding a screw to one joint adds that screw and changes that joint;
+	// the other joint and its screws keep their IDs.
+	d := graph.Diff(old, eval(strings.Replace(source, "EXTRA",
+		"\n(screw :diameter 4 :length 50 :position (vec3 0 100 0))", 1)))
+	if len(d.Added) != 1 || len(d.Removed) != 0 || len(d.Modified) != 1 {
+		t.Fatalf("diff: %d added, %d removed, %d modified, want 1, 0, 1",
+			len(d.Added), len(d.Removed), len(d.Modified))
+	}
+	if d.Added[0].ID != graph.NewNodeID("butt-joint/front/back/left/front/screw/3") {
+		t.Errorf("added %s, want the third screw of the front/left joint", d.Added[0].ID.Short())
+	}
+	if d.Modified[0].New.ID != graph.NewNodeID("butt-joint/front/back/left/front") {
+		t.Errorf("modified %s, want the front/left joint", d.Modified[0].New.ID.Short())
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Butt joint test
 // ---------------------------------------------------------------------------
@@ -623,7 +753,7 @@ func TestScrewWithHeadDia(t *testing.T) {
     :part-a (part "a") :face-a :top
     :part-b (part "b") :face-b :bottom
     :fasteners (list
-      (screw :diameter 5 :length 40 :position (vec3 50 50 0) :head-dia 10))))
+      (screw :diameter 5 :length 40 :position (vec3 50 50 0) :head-dia 10 :pilot-hole 3.2))))
 `
 	g, evalErrs, err := eng.Evaluate(source)
 	if err != nil {
@@ -643,6 +773,9 @@ func TestScrewWithHeadDia(t *testing.T) {
 			if fd.Diameter != 5 {
 				t.Errorf("expected diameter=5, got %f", fd.Diameter)
 			}
+			if fd.PilotHole() != 3.2 || fd.ClearanceHole() != 5.5 {
+				t.Errorf("expected holes 3.2/5.5, got %g/%g", fd.PilotHole(), fd.ClearanceHole())
+			}
 			return
 		}
 	}
@@ -687,3 +820,475 @@ func TestArithmeticStillWorks(t *testing.T) {
 		t.Fatal("expected non-nil graph")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// Source locations and redefinition
+// ---------------------------------------------------------------------------
+
+func TestAnnotateSource(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			name:   "located form",
+			input:  `(defpart "a" (board :length 1))`,
+			expect: `(defpart "__src_1_1" "a" (board "__src_1_14" :length 1))`,
+		},
+		{
+			name:   "kebab-case form",
+			input:  "\n  (butt-joint :part-a a)",
+			expect: "\n  (butt-joint \"__src_2_3\" :part-a a)",
+		},
+		{
+			name:   "other forms untouched",
+			input:  `(def x (+ 1 2))`,
+			expect: `(def x (+ 1 2))`,
+		},
+		{
+			name:   "strings and comments untouched",
+			input:  "\"(defpart\" ; (board\n(vec3 1 2 3)",
+			expect: "\"(defpart\" ; (board\n(vec3 \"__src_2_1\" 1 2 3)",
+		},
+		{
+			name:   "quoted lists are data",
+			input:  `(def f '(part "x")) (quote (vec3 1 2 3)) %(place) (part "y")`,
+			expect: `(def f '(part "x")) (quote (vec3 1 2 3)) %(place) (part "__src_1_51" "y")`,
+		},
+		{
+			name:   "rune literals",
+			input:  `(def c '(') (vec3 1 2 3)`,
+			expect: `(def c '(') (vec3 "__src_1_13" 1 2 3)`,
+		},
+		{
+			name:   "user functions shadow located forms",
+			input:  "(vec3 1 2 3)\n(defn vec3 [x] x)\n(vec3 1)",
+			expect: "(vec3 \"__src_1_1\" 1 2 3)\n(defn vec3 [x] x)\n(vec3 1)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := annotateSource(tt.input)
+			if got != tt.expect {
+				t.Errorf("annotateSource(%q) = %q, want %q", tt.input, got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestQuotedAndShadowedForms(t *testing.T) {
+	eng := NewEngine()
+
+	g, evalErrs, err := eng.Evaluate(`
+(defpart "x" (board :length 100 :width 50 :thickness 19))
+(def form %(part "x"))
+(assert (== (len form) 2))
+(defn screw [d] (* d 2))
+(assert (== (screw 4) 8))
+`)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) > 0 {
+		t.Fatalf("eval errors: %v", evalErrs)
+	}
+	if len(g.Nodes) != 1 {
+		t.Errorf("got %d nodes, want only the part", len(g.Nodes))
+	}
+}
+
+func TestNodeSourceRef(t *testing.T) {
+	eng := NewEngine()
+
+	source := `(def oak (material :species "oak"))
+(defpart "shelf"
+  (board :length 600 :width 300 :thickness 19 :material oak))
+(assembly "case"
+  (place (part "shelf") :at (vec3 0 0 0)))
+`
+	g, evalErrs, err := eng.Evaluate(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) > 0 {
+		t.Fatalf("eval errors: %v", evalErrs)
+	}
+
+	shelf := g.Lookup("shelf")
+	if shelf.Source.Line != 2 || shelf.Source.Col != 1 {
+		t.Errorf("shelf source = %s, want line 2, col 1", shelf.Source)
+	}
+	asm := g.Lookup("case")
+	if asm.Source.Line != 4 {
+		t.Errorf("case source = %s, want line 4", asm.Source)
+	}
+	place := g.Get(asm.Children[0])
+	if place.Source.Line != 5 || place.Source.Col != 3 {
+		t.Errorf("place source = %s, want line 5, col 3", place.Source)
+	}
+}
+
+func TestDuplicateDefpartError(t *testing.T) {
+	eng := NewEngine()
+
+	source := `(defpart "shelf" (board :length 600 :width 300 :thickness 19))
+
+(defpart "shelf" (board :length 500 :width 300 :thickness 19))
+`
+	g, evalErrs, err := eng.Evaluate(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if g != nil {
+		t.Fatal("expected nil graph on redefinition")
+	}
+	if len(evalErrs) != 1 {
+		t.Fatalf("expected 1 eval error, got %v", evalErrs)
+	}
+
+	e := evalErrs[0]
+	if e.Line != 3 {
+		t.Errorf("error line = %d, want 3 (the redefinition)", e.Line)
+	}
+	for _, want := range []string{`"shelf"`, "redefined at line 3, col 1", "first defined at line 1, col 1", "redefpart"} {
+		if !strings.Contains(e.Message, want) {
+			t.Errorf("error %q should contain %q", e.Message, want)
+		}
+	}
+}
+
+func TestDuplicateAssemblyError(t *testing.T) {
+	eng := NewEngine()
+
+	source := `(defpart "a" (board :length 100 :width 100 :thickness 19))
+(assembly "box" (place (part "a") :at (vec3 0 0 0)))
+(assembly "box")
+`
+	_, evalErrs, err := eng.Evaluate(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) != 1 {
+		t.Fatalf("expected 1 eval error, got %v", evalErrs)
+	}
+	msg := evalErrs[0].Message
+	if !strings.Contains(msg, "redefined at line 3") || !strings.Contains(msg, "first defined at line 2") {
+		t.Errorf("error should name both definition sites, got %q", msg)
+	}
+}
+
+func TestDefpartNameClashesWithAssembly(t *testing.T) {
+	eng := NewEngine()
+
+	source := `(assembly "box")
+(defpart "box" (board :length 100 :width 100 :thickness 19))
+`
+	_, evalErrs, err := eng.Evaluate(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) != 1 || !strings.Contains(evalErrs[0].Message, "first defined at line 1") {
+		t.Fatalf("expected redefinition error, got %v", evalErrs)
+	}
+}
+
+func TestRedefpartOverrides(t *testing.T) {
+	eng := NewEngine()
+
+	source := `(defpart "shelf" (board :length 600 :width 300 :thickness 19))
+(assembly "case" (place (part "shelf") :at (vec3 0 0 0)))
+(redefpart "shelf" (board :length 550 :width 300 :thickness 19))
+`
+	g, evalErrs, err := eng.Evaluate(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) > 0 {
+		t.Fatalf("eval errors: %v", evalErrs)
+	}
+
+	shelf := g.Lookup("shelf")
+	if bd := shelf.Data.(graph.BoardData); bd.Dimensions.X != 550 {
+		t.Errorf("expected redefined length 550, got %f", bd.Dimensions.X)
+	}
+	if shelf.Source.Line != 3 {
+		t.Errorf("shelf source = %s, want the redefinition on line 3", shelf.Source)
+	}
+	if g.NodeCount() != 3 {
+		t.Errorf("expected 3 nodes, got %d", g.NodeCount())
+	}
+}
+
+func TestRedefpartRejectsAssembly(t *testing.T) {
+	eng := NewEngine()
+
+	source := `(assembly "box")
+(redefpart "box" (board :length 100 :width 100 :thickness 19))
+`
+	_, evalErrs, err := eng.Evaluate(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) != 1 || !strings.Contains(evalErrs[0].Message, "not a part") {
+		t.Fatalf("expected redefpart error, got %v", evalErrs)
+	}
+}
+
+func TestBuiltinErrorHasLineInfo(t *testing.T) {
+	eng := NewEngine()
+
+	source := "(def x 1)\n\n(part \"missing\")\n"
+	_, evalErrs, err := eng.Evaluate(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) != 1 {
+		t.Fatalf("expected 1 eval error, got %v", evalErrs)
+	}
+	if evalErrs[0].Line != 3 || evalErrs[0].Col != 1 {
+		t.Errorf("error position = %d:%d, want 3:1", evalErrs[0].Line, evalErrs[0].Col)
+	}
+}
+
+func TestTagsAndMeta(t *testing.T) {
+	eng := NewEngine()
+	source := `(defpart "front" (board :length 500 :width 150 :thickness 19)
+  :tags (list "visible-face" :paint-grade "visible-face")
+  :meta (list :finish "oil" :coats 2))
+(defpart "bottom" (board :length 480 :width 400 :thickness 6) :tags "secondary-wood")
+(assembly "drawer"
+  (place (part "front") :at (vec3 0 0 0) :tags (list "show"))
+  (place (part "bottom") :at (vec3 10 0 10))
+  :tags (list "drawer-box")
+  :meta (list "maker" "shop"))
+`
+	g, evalErrs, err := eng.Evaluate(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) > 0 {
+		t.Fatalf("eval errors: %v", evalErrs)
+	}
+
+	front := g.MustLookup("front")
+	if strings.Join(front.Tags, ",") != "visible-face,paint-grade" {
+		t.Errorf("front tags = %v", front.Tags)
+	}
+	if front.Meta["finish"] != "oil" || front.Meta["coats"] != "2" {
+		t.Errorf("front meta = %v", front.Meta)
+	}
+	if tags := g.MustLookup("bottom").Tags; len(tags) != 1 || tags[0] != "secondary-wood" {
+		t.Errorf("bottom tags = %v", tags)
+	}
+
+	drawer := g.MustLookup("drawer")
+	if len(drawer.Children) != 2 {
+		t.Fatalf("expected 2 children, got %d", len(drawer.Children))
+	}
+	if !drawer.HasTag("drawer-box") || drawer.Meta["maker"] != "shop" {
+		t.Errorf("drawer tags = %v, meta = %v", drawer.Tags, drawer.Meta)
+	}
+	if place := g.Get(drawer.Children[0]); !place.HasTag("show") {
+		t.Errorf("place tags = %v", place.Tags)
+	}
+
+	parts, err := g.Query(graph.Query{Kinds: []graph.NodeKind{graph.NodePrimitive}, Tags: []string{"drawer-box"}, InheritTags: true})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(parts) != 2 {
+		t.Errorf("expected both drawer parts, got %d", len(parts))
+	}
+}
+
+func TestMetaRequiresPairs(t *testing.T) {
+	eng := NewEngine()
+	_, evalErrs, err := eng.Evaluate(`(defpart "a" (board :length 1 :width 1 :thickness 1) :meta (list :finish))`)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) == 0 || !strings.Contains(evalErrs[0].Message, "expected key/value pairs") {
+		t.Errorf("expected a key/value error, got %v", evalErrs)
+	}
+}
+
+func TestFastenerRuleOverridesDefault(t *testing.T) {
+	eng := NewEngine()
+
+	source := `
+(fastener-rule :kind :screw :min-embedment 20)
+(fastener-rule :kind :bolt :min-thread-engagement 1.5)
+`
+	g, evalErrs, err := eng.Evaluate(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) > 0 {
+		t.Fatalf("eval errors: %v", evalErrs)
+	}
+
+	screw, _ := g.Defaults.FastenerRule(graph.FastenerScrew)
+	want := graph.FastenerRule{MinEmbedment: 20, MinThreadEngagement: graph.DefaultFastenerRules[graph.FastenerScrew].MinThreadEngagement}
+	if screw != want {
+		t.Errorf("screw rule = %+v, want %+v", screw, want)
+	}
+	bolt, ok := g.Defaults.FastenerRule(graph.FastenerBolt)
+	if !ok || bolt != (graph.FastenerRule{MinThreadEngagement: 1.5}) {
+		t.Errorf("bolt rule = %+v (%v), want thread engagement 1.5", bolt, ok)
+	}
+
+	_, evalErrs, err = eng.Evaluate(`(fastener-rule :kind :rivet :min-embedment 5)`)
+	if err == nil && len(evalErrs) == 0 {
+		t.Error("expected an error for an unknown fastener kind")
+	}
+}
+
+func TestWoodMovementSettingsAndGlueUp(t *testing.T) {
+	eng := NewEngine()
+
+	source := `
+(wood-movement :max-humidity 60 :threshold 2)
+(defpart "a" (board :length 100 :width 100 :thickness 19))
+(defpart "b" (board :length 100 :width 100 :thickness 19))
+(butt-joint :part-a (part "a") :face-a :right :part-b (part "b") :face-b :left :glue-up true :clearance 0)
+`
+	g, evalErrs, err := eng.Evaluate(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) > 0 {
+		t.Fatalf("eval errors: %v", evalErrs)
+	}
+
+	want := graph.MovementSettings{MinHumidity: graph.DefaultMovement.MinHumidity, MaxHumidity: 60, Threshold: 2}
+	if got := g.Defaults.MovementSettings(); got != want {
+		t.Errorf("movement settings = %+v, want %+v", got, want)
+	}
+	joins := g.Joins()
+	if len(joins) != 1 || !joins[0].Data.(graph.JoinData).Params.(graph.ButtJoinParams).GlueUp {
+		t.Error("expected a glued butt joint")
+	}
+	if c := joins[0].Data.(graph.JoinData).Clearance; c == nil || *c != 0 {
+		t.Errorf("clearance = %v, want an explicit zero", c)
+	}
+
+	_, evalErrs, err = eng.Evaluate(`(wood-movement :min-humidity 80 :max-humidity 40)`)
+	if err == nil && len(evalErrs) == 0 {
+		t.Error("expected an error for an inverted humidity range")
+	}
+}
+
+func TestStockDeclarations(t *testing.T) {
+	eng := NewEngine()
+
+	g, evalErrs, err := eng.Evaluate(`
+(stock "walnut-8/4" :species "walnut" :thickness 44 :width 200 :length 2400 :qty 3)
+(stock "ply" :thickness 18 :width 1220 :length 2440 :kerf 2.5)
+(stock "mdf" :thickness 18 :width 600 :length 1200 :kerf 0)
+`)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) > 0 {
+		t.Fatalf("eval errors: %v", evalErrs)
+	}
+	if len(g.Stock) != 3 {
+		t.Fatalf("expected 3 stock declarations, got %d", len(g.Stock))
+	}
+	walnut, ply, mdf := g.Stock[0], g.Stock[1], g.Stock[2]
+	if walnut.Name != "walnut-8/4" || walnut.Species != "walnut" || walnut.Quantity != 3 ||
+		walnut.Thickness != 44 || walnut.Width != 200 || walnut.Length != 2400 || walnut.Source.Line != 2 {
+		t.Errorf("unexpected walnut stock %+v", walnut)
+	}
+	if ply.Quantity != 1 || ply.KerfOrDefault() != 2.5 {
+		t.Errorf("unexpected ply stock %+v", ply)
+	}
+	if walnut.KerfOrDefault() != graph.DefaultKerf || mdf.KerfOrDefault() != 0 {
+		t.Errorf("kerfs = %v, %v; want the default and an explicit zero",
+			walnut.KerfOrDefault(), mdf.KerfOrDefault())
+	}
+
+	for _, src := range []string{
+		`(stock "a" :thickness 19 :width 100 :length 1000) (stock "a" :thickness 19 :width 100 :length 1000)`,
+		`(stock "a" :thickness 19 :width 100 :length 1000 :qty 0)`,
+		`(stock "a" :thickness 19 :width 100)`,
+		`(stock "a" :thickness 19 :width 100 :length 1000 :kerf -1)`,
+		`(stock "a" :size "1x6" :width 200 :length 1000)`,
+		`(stock "a" :size "1x6" :thickness 25 :length 1000)`,
+	} {
+		if _, evalErrs, err := eng.Evaluate(src); err == nil && len(evalErrs) == 0 {
+			t.Errorf("expected an error for %s", src)
+		}
+	}
+}
+
+func TestUnits(t *testing.T) {
+	eng := NewEngine()
+
+	g, evalErrs, err := eng.Evaluate(`(units :in)`)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) > 0 {
+		t.Fatalf("eval errors: %v", evalErrs)
+	}
+	if g.Defaults.Units != "in" {
+		t.Errorf("units = %q, want in", g.Defaults.Units)
+	}
+
+	for _, src := range []string{`(units :ft)`, `(units)`, `(units :in :mm)`, `(units 25.4)`} {
+		if _, evalErrs, err := eng.Evaluate(src); err == nil && len(evalErrs) == 0 {
+			t.Errorf("expected an error for %s", src)
+		}
+	}
+}
+
+func TestMillingAndNominalSizes(t *testing.T) {
+	eng := NewEngine()
+
+	g, evalErrs, err := eng.Evaluate(`
+(milling :length 50 :width 6)
+(milling :thickness 3 :nominal true)
+(defpart "leg" (board :length 700 :width 50 :thickness "8/4" :grain :x))
+(defpart "rail" (board :length 600 :size "1x4" :grain :x :milling (list :length 25)))
+(stock "oak-8/4" :thickness "8/4" :width 200 :length 2400)
+(stock "pine" :size "2x6" :length 2438)
+`)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) > 0 {
+		t.Fatalf("eval errors: %v", evalErrs)
+	}
+
+	if want := (graph.MillingAllowance{Length: 50, Width: 6, Thickness: 3, Nominal: true}); g.Defaults.MillingAllowance() != want {
+		t.Errorf("milling = %+v, want %+v", g.Defaults.MillingAllowance(), want)
+	}
+	leg := g.MustLookup("leg").Data.(graph.BoardData)
+	if leg.Dimensions.Z != 44.5 || leg.Nominal != "8/4" || leg.Milling != nil {
+		t.Errorf("leg = %+v, want 44.5 mm thick, nominal 8/4", leg)
+	}
+	rail := g.MustLookup("rail").Data.(graph.BoardData)
+	if rail.Dimensions.Y != 88.9 || rail.Dimensions.Z != 19.05 || rail.Nominal != "1x4" ||
+		rail.Allowance(g.Defaults) != (graph.MillingAllowance{Length: 25}) {
+		t.Errorf("rail = %+v, want 88.9 × 19.05 mm with its own allowance", rail)
+	}
+	if oak, pine := g.Stock[0], g.Stock[1]; oak.Thickness != 50.8 || oak.Nominal != "8/4" ||
+		pine.Thickness != 38.1 || pine.Width != 139.7 || pine.Nominal != "2x6" {
+		t.Errorf("stock = %+v, %+v", oak, pine)
+	}
+
+	for _, src := range []string{
+		`(defpart "a" (board :length 100 :width 50 :thickness "7/4"))`,
+		`(defpart "a" (board :length 100 :width 50 :size "1x4"))`,
+		`(defpart "a" (board :length 100 :size "4x1"))`,
+		`(milling :length -5)`,
+		`(stock "a" :thickness "3/4" :width 100 :length 1000)`,
+	} {
+		if _, evalErrs, err := eng.Evaluate(src); err == nil && len(evalErrs) == 0 {
+			t.Errorf("expected an error for %s", src)
+		}
+	}
+}
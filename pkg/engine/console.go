@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chazu/lignin/pkg/graph"
+	zygo "github.com/glycerine/zygomys/zygo"
+)
+
+// MaxConsoleBytes bounds the output captured from a single evaluation.
+// Output beyond the limit is dropped and a truncation notice is appended.
+const MaxConsoleBytes = 64 * 1024
+
+// ConsoleEntry is the output of one print call in user code.
+// Text is exactly what was printed, including any trailing newline.
+type ConsoleEntry struct {
+	Line int
+	Col  int
+	Text string
+}
+
+// consoleLog collects print output for one evaluation.
+type consoleLog struct {
+	entries   []ConsoleEntry
+	size      int
+	truncated bool
+}
+
+// write records text printed by the form at src, respecting MaxConsoleBytes.
+func (c *consoleLog) write(src graph.SourceRef, text string) {
+	if c.truncated || text == "" {
+		return
+	}
+	if c.size+len(text) > MaxConsoleBytes {
+		text = strings.ToValidUTF8(text[:MaxConsoleBytes-c.size], "")
+		c.truncated = true
+	}
+	c.size += len(text)
+	if text != "" {
+		c.entries = append(c.entries, ConsoleEntry{Line: src.Line, Col: src.Col, Text: text})
+	}
+	if c.truncated {
+		c.entries = append(c.entries, ConsoleEntry{
+			Text: fmt.Sprintf("[console output truncated at %d bytes]\n", MaxConsoleBytes),
+		})
+	}
+}
+
+// printFunctions returns replacements for the zygomys print builtins that
+// write to the evaluation's console log instead of the process stdout.
+// They keep zygomys semantics: print and println show only their first
+// argument, printf formats like sprintf.
+func (st *evalState) printFunctions() map[string]zygo.ZlispUserFunction {
+	printFn := st.wrap(func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		if len(args) < 1 {
+			return zygo.SexpNull, zygo.WrongNargs
+		}
+		st.console.write(src, sexpText(args[0]))
+		return zygo.SexpNull, nil
+	})
+	printlnFn := st.wrap(func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		if len(args) < 1 {
+			return zygo.SexpNull, zygo.WrongNargs
+		}
+		st.console.write(src, sexpText(args[0])+"\n")
+		return zygo.SexpNull, nil
+	})
+	printfFn := st.wrap(func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		formatted, err := zygo.PrintFunction(env, "sprintf", args)
+		if err != nil {
+			return zygo.SexpNull, err
+		}
+		st.console.write(src, sexpText(formatted))
+		return zygo.SexpNull, nil
+	})
+	return map[string]zygo.ZlispUserFunction{
+		"print":   printFn,
+		"println": printlnFn,
+		"printf":  printfFn,
+	}
+}
+
+// sexpText renders a value the way the zygomys print builtins do: strings
+// verbatim, everything else in its Lisp representation.
+func sexpText(s zygo.Sexp) string {
+	if str, ok := s.(*zygo.SexpStr); ok {
+		return str.S
+	}
+	return s.SexpString(nil)
+}
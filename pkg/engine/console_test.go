@@ -0,0 +1,95 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConsoleCapturesPrints(t *testing.T) {
+	eng := NewEngine()
+
+	source := `(def w 600)
+(println "width:")
+  (printf "%d mm\n" w)
+(print w)
+`
+	res, err := eng.EvaluateWithOptions(source, EvalOptions{})
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("eval errors: %v", res.Errors)
+	}
+
+	want := []ConsoleEntry{
+		{Line: 2, Col: 1, Text: "width:\n"},
+		{Line: 3, Col: 3, Text: "600 mm\n"},
+		{Line: 4, Col: 1, Text: "600"},
+	}
+	if len(res.Console) != len(want) {
+		t.Fatalf("expected %d console entries, got %v", len(want), res.Console)
+	}
+	for i, w := range want {
+		if res.Console[i] != w {
+			t.Errorf("entry %d = %+v, want %+v", i, res.Console[i], w)
+		}
+	}
+}
+
+func TestConsoleKeptOnError(t *testing.T) {
+	eng := NewEngine()
+
+	source := "(println \"before\")\n(part \"missing\")\n"
+	res, err := eng.EvaluateWithOptions(source, EvalOptions{})
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(res.Errors) == 0 {
+		t.Fatal("expected an eval error")
+	}
+	if len(res.Console) != 1 || res.Console[0].Text != "before\n" {
+		t.Errorf("expected console output before the error, got %v", res.Console)
+	}
+}
+
+func TestConsoleBounded(t *testing.T) {
+	eng := NewEngine()
+
+	line := strings.Repeat("x", 1000)
+	source := `(for [(def i 0) (< i 100) (def i (+ i 1))] (println "` + line + `"))`
+	res, err := eng.EvaluateWithOptions(source, EvalOptions{})
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("eval errors: %v", res.Errors)
+	}
+
+	total := 0
+	for _, e := range res.Console[:len(res.Console)-1] {
+		total += len(e.Text)
+	}
+	if total != MaxConsoleBytes {
+		t.Errorf("expected %d captured bytes, got %d", MaxConsoleBytes, total)
+	}
+	last := res.Console[len(res.Console)-1]
+	if !strings.Contains(last.Text, "truncated") {
+		t.Errorf("expected truncation notice, got %q", last.Text)
+	}
+}
+
+func TestConsoleSprintfUnaffected(t *testing.T) {
+	eng := NewEngine()
+
+	source := `(println (sprintf "%d-%d" 1 2))`
+	res, err := eng.EvaluateWithOptions(source, EvalOptions{})
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("eval errors: %v", res.Errors)
+	}
+	if len(res.Console) != 1 || res.Console[0].Text != "1-2\n" {
+		t.Errorf("unexpected console: %v", res.Console)
+	}
+}
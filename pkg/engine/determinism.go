@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/chazu/lignin/pkg/graph"
+	zygo "github.com/glycerine/zygomys/zygo"
+)
+
+// nondeterministicBuiltins lists zygomys builtins whose results depend on
+// the clock, global random state, the platform or Go map iteration order.
+// They are removed from every sandbox, whichever function set zygomys
+// considers safe, so the same source always evaluates to the same graph.
+// Seeded randomness is available through (random-seed n) and (random).
+var nondeterministicBuiltins = []string{
+	"now", "millis", "timeit", "astm", "sleep", // clock
+	"random", // unseeded global generator
+	"GOOS",   // host platform
+	"symnum", // symbol numbers follow builtin map order
+}
+
+// divergence describes where two evaluations of the same source differ.
+type divergence struct {
+	NodeID graph.NodeID // zero if only roots, defaults or stock differ
+	Label  string       // kind and name of the node, e.g. `primitive "side"`
+	Source graph.SourceRef
+	Reason string
+}
+
+func (d divergence) String() string {
+	if d.NodeID.IsZero() {
+		return d.Reason
+	}
+	return d.Label + " " + d.Reason
+}
+
+// firstDivergence compares two graphs evaluated from the same source and
+// returns the first node, in canonical order, that differs between them.
+// It returns false if the graphs have the same canonical hash.
+func firstDivergence(a, b *graph.DesignGraph) (divergence, bool) {
+	if a.CanonicalHash() == b.CanonicalHash() {
+		return divergence{}, false
+	}
+	at := func(n *graph.Node, reason string) (divergence, bool) {
+		return divergence{NodeID: n.ID, Label: n.Label(), Source: n.Source, Reason: reason}, true
+	}
+	for _, n := range a.SortedNodes() {
+		other, ok := b.Nodes[n.ID]
+		if !ok {
+			return at(n, "is missing from the second evaluation")
+		}
+		if n.ComputeContentHash() != other.ComputeContentHash() {
+			return at(n, "differs between evaluations")
+		}
+	}
+	for _, n := range b.SortedNodes() {
+		if _, ok := a.Nodes[n.ID]; !ok {
+			return at(n, "is missing from the first evaluation")
+		}
+	}
+	return divergence{Reason: "graph roots, defaults or stock differ between evaluations"}, true
+}
+
+// checkDeterminism compares a successful evaluation with a second one of
+// the same source and returns a warning locating the first divergence.
+func checkDeterminism(first, second evalResult) []EvalWarning {
+	switch {
+	case second.err != nil:
+		return []EvalWarning{{Message: "design is not deterministic: second evaluation failed: " + second.err.Error()}}
+	case second.graph == nil:
+		msg := "design is not deterministic: second evaluation failed"
+		if len(second.errors) > 0 {
+			msg += ": " + second.errors[0].Error()
+		}
+		return []EvalWarning{{Message: msg}}
+	}
+	d, diverged := firstDivergence(first.graph, second.graph)
+	if !diverged {
+		return nil
+	}
+	return []EvalWarning{{
+		Line:    d.Source.Line,
+		Col:     d.Source.Col,
+		Message: "design is not deterministic: " + d.String(),
+		NodeID:  d.NodeID,
+	}}
+}
+
+// registerRandomBuiltins installs seeded random numbers. (random) fails
+// until (random-seed n) has been called, so every random value in a design
+// is reproducible from its source.
+func registerRandomBuiltins(env *zygo.Zlisp, st *evalState) {
+
+	// -----------------------------------------------------------------------
+	// (random-seed 42)
+	// -----------------------------------------------------------------------
+	st.addFunction(env, "random_seed", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		if len(args) != 1 {
+			return zygo.SexpNull, fmt.Errorf("random-seed requires one integer seed")
+		}
+		seed, ok := args[0].(*zygo.SexpInt)
+		if !ok {
+			return zygo.SexpNull, fmt.Errorf("random-seed: seed must be an integer, got %s", args[0].SexpString(nil))
+		}
+		st.rng = rand.New(rand.NewSource(seed.Val))
+		return zygo.SexpNull, nil
+	})
+
+	// -----------------------------------------------------------------------
+	// (random)  ; float in [0, 1)
+	// -----------------------------------------------------------------------
+	st.addFunction(env, "random", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		if st.rng == nil {
+			return zygo.SexpNull, fmt.Errorf("random: call (random-seed n) first; unseeded random numbers are not reproducible")
+		}
+		if len(args) != 0 {
+			return zygo.SexpNull, fmt.Errorf("random takes no arguments")
+		}
+		return &zygo.SexpFloat{Val: st.rng.Float64()}, nil
+	})
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chazu/lignin/pkg/graph"
+)
+
+func TestNondeterministicBuiltinsRemoved(t *testing.T) {
+	eng := NewEngine()
+	for _, name := range []string{"now", "millis", "random", "GOOS", "symnum"} {
+		src := "(def x (" + name + "))"
+		if name == "GOOS" {
+			src = "(def x GOOS)"
+		}
+		_, evalErrs, err := eng.Evaluate(src)
+		if err != nil {
+			t.Fatalf("%s: fatal error: %v", name, err)
+		}
+		if len(evalErrs) == 0 {
+			t.Errorf("%s should not be available in the sandbox", name)
+		}
+	}
+}
+
+func TestSeededRandom(t *testing.T) {
+	src := `(random-seed 7)
+(def w (+ 300 (* 100 (random))))
+(defpart "side" (board :length w :width 300 :thickness 19))`
+
+	eng := NewEngine()
+	lengths := make([]float64, 2)
+	for i := range lengths {
+		g, evalErrs, err := eng.Evaluate(src)
+		if err != nil || len(evalErrs) > 0 {
+			t.Fatalf("evaluation failed: %v %v", err, evalErrs)
+		}
+		lengths[i] = g.MustLookup("side").Data.(graph.BoardData).Dimensions.X
+	}
+	if lengths[0] != lengths[1] {
+		t.Errorf("seeded random is not reproducible: %v", lengths)
+	}
+	if lengths[0] < 300 || lengths[0] >= 400 {
+		t.Errorf("length %v out of range", lengths[0])
+	}
+}
+
+func TestUnseededRandomFails(t *testing.T) {
+	_, evalErrs, err := NewEngine().Evaluate(`(def x (random))`)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) == 0 || !strings.Contains(evalErrs[0].Message, "random-seed") {
+		t.Errorf("expected an error asking for a seed, got %v", evalErrs)
+	}
+}
+
+func TestCheckDeterminismClean(t *testing.T) {
+	res, err := NewEngine().EvaluateWithOptions(cabinetSource, EvalOptions{Variant: "tall", CheckDeterminism: true})
+	if err != nil || len(res.Errors) > 0 {
+		t.Fatalf("evaluation failed: %v %v", err, res.Errors)
+	}
+	for _, w := range res.Warnings {
+		if strings.Contains(w.Message, "not deterministic") {
+			t.Errorf("unexpected warning: %s", w.Message)
+		}
+	}
+}
+
+func TestFirstDivergence(t *testing.T) {
+	eval := func(src string) *graph.DesignGraph {
+		g, evalErrs, err := NewEngine().Evaluate(src)
+		if err != nil || len(evalErrs) > 0 {
+			t.Fatalf("evaluation failed: %v %v", err, evalErrs)
+		}
+		return g
+	}
+	base := "(defpart \"top\" (board :length 600 :width 300 :thickness 19))\n"
+	a := eval(base + `(defpart "side" (board :length 720 :width 300 :thickness 19))`)
+	b := eval(base + `(defpart "side" (board :length 721 :width 300 :thickness 19))`)
+
+	if _, diverged := firstDivergence(a, eval(base+`(defpart "side" (board :length 720 :width 300 :thickness 19))`)); diverged {
+		t.Error("identical evaluations should not diverge")
+	}
+
+	ws := checkDeterminism(evalResult{graph: a}, evalResult{graph: b})
+	if len(ws) != 1 {
+		t.Fatalf("expected one warning, got %v", ws)
+	}
+	w := ws[0]
+	if w.NodeID != graph.NewNodeID("side") || w.Line != 2 {
+		t.Errorf("warning should locate the side part on line 2, got %+v", w)
+	}
+	if !strings.Contains(w.Message, `primitive "side" differs between evaluations`) {
+		t.Errorf("unexpected message %q", w.Message)
+	}
+
+	c := eval(base)
+	if d, _ := firstDivergence(a, c); !strings.Contains(d.String(), "missing from the second evaluation") {
+		t.Errorf("unexpected divergence %q", d)
+	}
+}
@@ -11,9 +11,13 @@ import (
 	"sync"
 
 	"github.com/chazu/lignin/pkg/graph"
-	zygo "github.com/glycerine/zygomys/zygo"
 )
 
+// Version is the Lignin release, recorded in the fingerprint of every
+// evaluated graph. Release builds set it with
+// -ldflags "-X github.com/chazu/lignin/pkg/engine.Version=...".
+var Version = "0.1.0-dev"
+
 // EvalError represents a non-fatal error encountered during evaluation,
 // such as a parse error or a runtime error in user code.
 type EvalError struct {
@@ -42,6 +46,33 @@ type EvalResult struct {
 	Graph    *graph.DesignGraph
 	Errors   []EvalError
 	Warnings []EvalWarning
+	Params   []Param   // declared design parameters, in declaration order
+	Variants []Variant // declared design variants, in declaration order
+
+	// Console holds everything user code printed, in order. It is bounded
+	// by MaxConsoleBytes and is kept even when evaluation fails.
+	Console []ConsoleEntry
+}
+
+// EvalOptions configures a single evaluation.
+type EvalOptions struct {
+	// Overrides replaces the default value of declared (param ...) forms,
+	// keyed by parameter name.
+	Overrides map[string]float64
+
+	// Variant selects a declared (variant ...) by name. Its values are
+	// applied as overrides, beneath any explicit Overrides.
+	Variant string
+
+	// CheckDeterminism evaluates the source a second time in an independent
+	// sandbox and compares the canonical graph hashes. A mismatch is
+	// reported as a warning locating the first diverging node. Both
+	// evaluations share EvalTimeout.
+	CheckDeterminism bool
+
+	// Species is the species table attached to the evaluated graph for
+	// material checks. Nil selects the built-in table.
+	Species graph.SpeciesTable
 }
 
 // Engine wraps the zygomys interpreter for Lignin evaluation.
@@ -65,6 +96,18 @@ func NewEngine() *Engine {
 //   - On parse/eval failure: returns nil graph + eval errors + nil error
 //   - On fatal failure (timeout, panic): returns nil + nil + error
 func (e *Engine) Evaluate(source string) (*graph.DesignGraph, []EvalError, error) {
+	res, err := e.EvaluateWithOptions(source, EvalOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.Graph, res.Errors, nil
+}
+
+// EvaluateWithOptions is like Evaluate but applies opts and returns the full
+// EvalResult, including warnings and the declared parameter table.
+// The result's Graph is nil when Errors is non-empty. A non-nil error is
+// returned only for fatal failures (timeout, panic).
+func (e *Engine) EvaluateWithOptions(source string, opts EvalOptions) (*EvalResult, error) {
 	e.mu.Lock()
 	e.generation++
 	gen := e.generation
@@ -79,50 +122,92 @@ func (e *Engine) Evaluate(source string) (*graph.DesignGraph, []EvalError, error
 			}
 		}()
 
-		g, evalErrs, err := e.evaluate(source)
-		ch <- evalResult{graph: g, errors: evalErrs, err: err}
+		run := e.evaluate
+		if opts.Variant != "" {
+			run = e.evaluateVariant
+		}
+		res := run(source, opts)
+		if opts.CheckDeterminism && res.graph != nil {
+			res.warnings = append(res.warnings, checkDeterminism(res, run(source, opts))...)
+		}
+		ch <- res
 	}()
 
-	return waitWithTimeout(ch, gen, &e.mu, &e.generation)
+	res, err := awaitResult(ch, gen, &e.mu, &e.generation)
+	if err != nil {
+		return nil, err
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+	if res.graph != nil {
+		fp := graph.NewFingerprint(res.graph, source, Version, appliedOverrides(opts.Overrides, res.params))
+		res.graph.Fingerprint = &fp
+		res.graph.Species = opts.Species
+	}
+	return &EvalResult{
+		Graph:    res.graph,
+		Errors:   res.errors,
+		Warnings: res.warnings,
+		Params:   res.params,
+		Variants: res.variants,
+		Console:  res.console,
+	}, nil
 }
 
 // evaluate performs the actual zygomys evaluation in a fresh sandbox.
-func (e *Engine) evaluate(source string) (*graph.DesignGraph, []EvalError, error) {
+func (e *Engine) evaluate(source string, opts EvalOptions) evalResult {
+	// Create the design graph that builtins will populate.
+	g := graph.New()
+	st := newEvalState(g, opts)
+
 	// Empty source is a valid program that produces an empty graph.
 	if strings.TrimSpace(source) == "" {
-		return graph.New(), nil, nil
+		return evalResult{graph: g, warnings: st.unusedOverrides()}
 	}
 
-	// Create the design graph that builtins will populate.
-	g := graph.New()
-
-	// Preprocess the source to transform :keyword tokens into string literals
-	// and convert kebab-case identifiers to underscore form for zygomys.
-	source = preprocessSource(source)
+	// Record the source location of each DSL form, then preprocess the source
+	// to transform :keyword tokens into string literals and convert
+	// kebab-case identifiers to underscore form for zygomys.
+	source = preprocessSource(annotateSource(source))
 
 	// Create a fresh sandboxed zygomys environment.
-	// Sandbox mode prevents user code from accessing the filesystem or syscalls.
-	env := zygo.NewZlispSandbox()
+	env := st.newSandbox()
 	defer env.Stop()
 
 	// Register DSL builtins (board, joint, assembly, etc.) that populate the graph.
-	registerBuiltins(env, g)
+	registerBuiltins(env, st)
+	registerParamBuiltins(env, st)
+	registerVariantBuiltins(env, st)
+	registerQueryBuiltins(env, st)
+	registerRandomBuiltins(env, st)
 
 	// Load and compile the source string into bytecode.
 	err := env.LoadString(source)
 	if err != nil {
-		evalErrs := parseZygomysError(err)
-		return nil, evalErrs, nil
+		return evalResult{errors: parseZygomysError(err)}
 	}
 
-	// Execute the compiled bytecode.
+	// Execute the compiled bytecode. Parameters declared before a failure
+	// are still reported so a UI can keep showing their controls.
 	_, err = env.Run()
 	if err != nil {
-		evalErrs := parseZygomysError(err)
-		return nil, evalErrs, nil
+		return evalResult{
+			errors:   st.locate(parseZygomysError(err)),
+			warnings: st.unusedOverrides(),
+			params:   st.params,
+			variants: st.variants,
+			console:  st.console.entries,
+		}
 	}
 
-	return g, nil, nil
+	return evalResult{
+		graph:    g,
+		warnings: st.unusedOverrides(),
+		params:   st.params,
+		variants: st.variants,
+		console:  st.console.entries,
+	}
 }
 
 // linePattern matches zygomys error messages that include "Error on line N: ..."
@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/chazu/lignin/pkg/graph"
+	zygo "github.com/glycerine/zygomys/zygo"
+)
+
+// Param is an exposed design parameter declared with (param ...).
+// Its Value is the override supplied through EvalOptions, or the default.
+type Param struct {
+	Name    string
+	Default float64
+	Value   float64
+	Min     *float64 // nil = unbounded
+	Max     *float64 // nil = unbounded
+	Step    float64  // 0 = continuous; values lie on a grid from Min, or from Default if unbounded below
+	Doc     string
+	Line    int
+	Col     int
+}
+
+// inRange reports whether v lies within the parameter's bounds.
+func (p Param) inRange(v float64) bool {
+	if p.Min != nil && v < *p.Min {
+		return false
+	}
+	if p.Max != nil && v > *p.Max {
+		return false
+	}
+	return true
+}
+
+// base returns the value the parameter's step grid starts from.
+func (p Param) base() float64 {
+	if p.Min != nil {
+		return *p.Min
+	}
+	return p.Default
+}
+
+// onStep reports whether v lies on the parameter's step grid.
+func (p Param) onStep(v float64) bool {
+	if p.Step == 0 {
+		return true
+	}
+	n := (v - p.base()) / p.Step
+	return math.Abs(n-math.Round(n)) < 1e-9*math.Max(1, math.Abs(n))
+}
+
+// rangeString formats the parameter's bounds for error messages.
+func (p Param) rangeString() string {
+	lo, hi := "-inf", "+inf"
+	if p.Min != nil {
+		lo = fmt.Sprintf("%g", *p.Min)
+	}
+	if p.Max != nil {
+		hi = fmt.Sprintf("%g", *p.Max)
+	}
+	return fmt.Sprintf("[%s, %s]", lo, hi)
+}
+
+// declareParam records a parameter declaration and returns its effective
+// value. Declaring the same name twice is an error.
+func (st *evalState) declareParam(p Param) (float64, error) {
+	for _, prev := range st.params {
+		if prev.Name == p.Name {
+			return 0, fmt.Errorf("param: %q redeclared at line %d, col %d; first declared at line %d, col %d",
+				p.Name, p.Line, p.Col, prev.Line, prev.Col)
+		}
+	}
+	if p.Min != nil && p.Max != nil && *p.Min > *p.Max {
+		return 0, fmt.Errorf("param %q: min %g is greater than max %g", p.Name, *p.Min, *p.Max)
+	}
+	if !p.inRange(p.Default) {
+		return 0, fmt.Errorf("param %q: default %g is outside %s", p.Name, p.Default, p.rangeString())
+	}
+	if !p.onStep(p.Default) {
+		return 0, fmt.Errorf("param %q: default %g is not on the step of %g from %g", p.Name, p.Default, p.Step, p.base())
+	}
+
+	p.Value = p.Default
+	if v, ok := st.overrides[p.Name]; ok {
+		if !p.inRange(v) {
+			return 0, fmt.Errorf("param %q: override %g is outside %s", p.Name, v, p.rangeString())
+		}
+		if !p.onStep(v) {
+			return 0, fmt.Errorf("param %q: override %g is not on the step of %g from %g", p.Name, v, p.Step, p.base())
+		}
+		p.Value = v
+	}
+
+	st.params = append(st.params, p)
+	return p.Value, nil
+}
+
+// unusedOverrides returns a warning for every override that does not match
+// a declared parameter, sorted by name.
+func (st *evalState) unusedOverrides() []EvalWarning {
+	declared := make(map[string]bool, len(st.params))
+	for _, p := range st.params {
+		declared[p.Name] = true
+	}
+	var names []string
+	for name := range st.overrides {
+		if !declared[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	warnings := make([]EvalWarning, 0, len(names))
+	for _, name := range names {
+		warnings = append(warnings, EvalWarning{
+			Message: fmt.Sprintf("override for undeclared parameter %q ignored", name),
+		})
+	}
+	return warnings
+}
+
+// appliedOverrides returns the overrides that set a declared parameter.
+// Overrides of undeclared names are ignored by evaluation and so left out.
+func appliedOverrides(overrides map[string]float64, params []Param) map[string]float64 {
+	applied := make(map[string]float64)
+	for _, p := range params {
+		if v, ok := overrides[p.Name]; ok {
+			applied[p.Name] = v
+		}
+	}
+	return applied
+}
+
+// registerParamBuiltins installs the (param ...) builtin.
+func registerParamBuiltins(env *zygo.Zlisp, st *evalState) {
+
+	// -----------------------------------------------------------------------
+	// (param "width" 900 :min 600 :max 1200 :step 10 :doc "Overall width")
+	// -----------------------------------------------------------------------
+	st.addFunction(env, "param", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		pa := parseArgs(args)
+		if len(pa.positional) < 2 {
+			return zygo.SexpNull, fmt.Errorf("param requires a name and a default value")
+		}
+
+		paramName, err := toString(pa.positional[0])
+		if err != nil {
+			return zygo.SexpNull, fmt.Errorf("param: name: %w", err)
+		}
+		def, err := toFloat64(pa.positional[1])
+		if err != nil {
+			return zygo.SexpNull, fmt.Errorf("param %q: default: %w", paramName, err)
+		}
+
+		p := Param{Name: paramName, Default: def, Line: src.Line, Col: src.Col}
+		if v, ok := pa.kw["min"]; ok {
+			f, err := toFloat64(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("param %q: min: %w", paramName, err)
+			}
+			p.Min = &f
+		}
+		if v, ok := pa.kw["max"]; ok {
+			f, err := toFloat64(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("param %q: max: %w", paramName, err)
+			}
+			p.Max = &f
+		}
+		if v, ok := pa.kw["step"]; ok {
+			f, err := toFloat64(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("param %q: step: %w", paramName, err)
+			}
+			if f < 0 {
+				return zygo.SexpNull, fmt.Errorf("param %q: step must not be negative", paramName)
+			}
+			p.Step = f
+		}
+		if v, ok := pa.kw["doc"]; ok {
+			s, err := toString(v)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("param %q: doc: %w", paramName, err)
+			}
+			p.Doc = s
+		}
+
+		value, err := st.declareParam(p)
+		if err != nil {
+			return zygo.SexpNull, err
+		}
+
+		// Integer defaults stay integers so parameters can drive counts.
+		if _, isInt := pa.positional[1].(*zygo.SexpInt); isInt && value == math.Trunc(value) {
+			return &zygo.SexpInt{Val: int64(value)}, nil
+		}
+		return &zygo.SexpFloat{Val: value}, nil
+	})
+}
@@ -0,0 +1,179 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chazu/lignin/pkg/graph"
+)
+
+const bookcaseSource = `(def width (param "width" 900 :min 600 :max 1200 :step 10 :doc "Overall width"))
+(def shelves (param "shelves" 4))
+
+(defpart "shelf"
+  (board :length width :width 250 :thickness 19 :grain :x))
+`
+
+func TestParamDefaults(t *testing.T) {
+	eng := NewEngine()
+
+	res, err := eng.EvaluateWithOptions(bookcaseSource, EvalOptions{})
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("eval errors: %v", res.Errors)
+	}
+
+	if len(res.Params) != 2 {
+		t.Fatalf("expected 2 params, got %d", len(res.Params))
+	}
+	w := res.Params[0]
+	if w.Name != "width" || w.Default != 900 || w.Value != 900 {
+		t.Errorf("unexpected width param: %+v", w)
+	}
+	if w.Min == nil || *w.Min != 600 || w.Max == nil || *w.Max != 1200 {
+		t.Errorf("expected width range [600, 1200], got %+v", w)
+	}
+	if w.Step != 10 || w.Doc != "Overall width" {
+		t.Errorf("unexpected step/doc: %+v", w)
+	}
+	if w.Line != 1 {
+		t.Errorf("expected width declared on line 1, got %d", w.Line)
+	}
+	if s := res.Params[1]; s.Name != "shelves" || s.Min != nil || s.Max != nil {
+		t.Errorf("unexpected shelves param: %+v", s)
+	}
+
+	bd := res.Graph.Lookup("shelf").Data.(graph.BoardData)
+	if bd.Dimensions.X != 900 {
+		t.Errorf("expected shelf length 900, got %f", bd.Dimensions.X)
+	}
+}
+
+func TestParamOverride(t *testing.T) {
+	eng := NewEngine()
+
+	res, err := eng.EvaluateWithOptions(bookcaseSource, EvalOptions{
+		Overrides: map[string]float64{"width": 1000},
+	})
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("eval errors: %v", res.Errors)
+	}
+	if res.Params[0].Value != 1000 || res.Params[0].Default != 900 {
+		t.Errorf("expected overridden value 1000 with default 900, got %+v", res.Params[0])
+	}
+	bd := res.Graph.Lookup("shelf").Data.(graph.BoardData)
+	if bd.Dimensions.X != 1000 {
+		t.Errorf("expected shelf length 1000, got %f", bd.Dimensions.X)
+	}
+}
+
+func TestParamOverrideOutOfRange(t *testing.T) {
+	eng := NewEngine()
+
+	res, err := eng.EvaluateWithOptions(bookcaseSource, EvalOptions{
+		Overrides: map[string]float64{"width": 1300},
+	})
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if res.Graph != nil {
+		t.Error("expected nil graph for out-of-range override")
+	}
+	if len(res.Errors) != 1 || !strings.Contains(res.Errors[0].Message, "outside [600, 1200]") {
+		t.Fatalf("expected range error, got %v", res.Errors)
+	}
+	if res.Errors[0].Line != 1 {
+		t.Errorf("expected error on line 1, got %d", res.Errors[0].Line)
+	}
+}
+
+func TestParamOverrideOffStep(t *testing.T) {
+	eng := NewEngine()
+
+	res, err := eng.EvaluateWithOptions(bookcaseSource, EvalOptions{
+		Overrides: map[string]float64{"width": 905},
+	})
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if res.Graph != nil {
+		t.Error("expected nil graph for an override off the step")
+	}
+	if len(res.Errors) != 1 || !strings.Contains(res.Errors[0].Message, "905 is not on the step of 10 from 600") {
+		t.Fatalf("expected step error, got %v", res.Errors)
+	}
+
+	res, err = eng.EvaluateWithOptions(bookcaseSource, EvalOptions{
+		Overrides: map[string]float64{"width": 910},
+	})
+	if err != nil || len(res.Errors) != 0 {
+		t.Fatalf("override on the step rejected: %v %v", err, res.Errors)
+	}
+}
+
+func TestParamUnknownOverrideWarns(t *testing.T) {
+	eng := NewEngine()
+
+	res, err := eng.EvaluateWithOptions(bookcaseSource, EvalOptions{
+		Overrides: map[string]float64{"depth": 300},
+	})
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("eval errors: %v", res.Errors)
+	}
+	if len(res.Warnings) != 1 || !strings.Contains(res.Warnings[0].Message, `"depth"`) {
+		t.Fatalf("expected warning for undeclared override, got %v", res.Warnings)
+	}
+}
+
+func TestParamIntegerStaysInteger(t *testing.T) {
+	eng := NewEngine()
+
+	source := `(def n (param "count" 3))
+(defpart "a" (board :length (* n 100) :width (mod n 2) :thickness 19))
+`
+	res, err := eng.EvaluateWithOptions(source, EvalOptions{})
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("eval errors: %v", res.Errors)
+	}
+	bd := res.Graph.Lookup("a").Data.(graph.BoardData)
+	if bd.Dimensions.X != 300 || bd.Dimensions.Y != 1 {
+		t.Errorf("expected 300x1 from integer param, got %v", bd.Dimensions)
+	}
+}
+
+func TestParamErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantMsg string
+	}{
+		{"default out of range", `(param "w" 50 :min 100)`, "default 50 is outside [100, +inf]"},
+		{"min above max", `(param "w" 50 :min 100 :max 10)`, "min 100 is greater than max 10"},
+		{"default off step", `(param "w" 105 :min 100 :step 10)`, "default 105 is not on the step of 10 from 100"},
+		{"redeclared", "(param \"w\" 1)\n(param \"w\" 2)", "first declared at line 1"},
+		{"missing default", `(param "w")`, "requires a name and a default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := NewEngine().EvaluateWithOptions(tt.source, EvalOptions{})
+			if err != nil {
+				t.Fatalf("fatal error: %v", err)
+			}
+			if len(res.Errors) != 1 || !strings.Contains(res.Errors[0].Message, tt.wantMsg) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantMsg, res.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/chazu/lignin/pkg/graph"
+	zygo "github.com/glycerine/zygomys/zygo"
+)
+
+// ---------------------------------------------------------------------------
+// Geometry queries
+// ---------------------------------------------------------------------------
+
+// queryNode resolves the node reference passed to a query builtin. The graph
+// is still being built, so only nodes defined earlier in the source exist.
+func queryNode(g *graph.DesignGraph, form string, s zygo.Sexp) (*graph.Node, error) {
+	id, err := toNodeRef(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", form, err)
+	}
+	n := g.Get(id)
+	if n == nil {
+		return nil, fmt.Errorf("%s: node %s is not in the design", form, id.Short())
+	}
+	return n, nil
+}
+
+// nodeBounds returns the bounding box of n, or an error naming form if n
+// has no geometry.
+func nodeBounds(g *graph.DesignGraph, form string, n *graph.Node) (graph.Box, error) {
+	b, ok := g.Bounds(n.ID)
+	if !ok {
+		return graph.Box{}, fmt.Errorf("%s: %s has no geometry", form, n.Label())
+	}
+	return b, nil
+}
+
+// partDimension returns a named dimension of a primitive part: :length,
+// :width and :thickness of a board, :length and :diameter of a dowel.
+func partDimension(data graph.NodeData, name string) (float64, bool) {
+	switch d := data.(type) {
+	case graph.BoardData:
+		switch name {
+		case "length":
+			return d.Dimensions.X, true
+		case "width":
+			return d.Dimensions.Y, true
+		case "thickness":
+			return d.Dimensions.Z, true
+		}
+	case graph.DowelData:
+		switch name {
+		case "length":
+			return d.Length, true
+		case "diameter":
+			return d.Diameter, true
+		}
+	}
+	return 0, false
+}
+
+// boxAxis returns the extent of b along the axis named :x, :y or :z.
+func boxAxis(b graph.Box, name string) (float64, bool) {
+	size := b.Size()
+	switch name {
+	case "x":
+		return size.X, true
+	case "y":
+		return size.Y, true
+	case "z":
+		return size.Z, true
+	}
+	return 0, false
+}
+
+// placedBoard follows a chain of placements down to a single board and
+// returns the board together with the accumulated transform. Groups are
+// rejected because a face of a group is ambiguous.
+func placedBoard(g *graph.DesignGraph, form string, n *graph.Node) (graph.BoardData, graph.Transform, error) {
+	t := graph.Transform{}
+	start := n
+	for depth := 0; n != nil && depth <= len(g.Nodes); depth++ {
+		switch n.Kind {
+		case graph.NodePrimitive:
+			bd, ok := n.Data.(graph.BoardData)
+			if !ok {
+				return graph.BoardData{}, t, fmt.Errorf("%s: %s is not a board", form, n.Label())
+			}
+			return bd, t, nil
+		case graph.NodeTransform:
+			if td, ok := n.Data.(graph.TransformData); ok {
+				t = t.Compose(td)
+			}
+			if len(n.Children) != 1 {
+				return graph.BoardData{}, t, fmt.Errorf("%s: %s does not place a single part", form, n.Label())
+			}
+			n = g.Get(n.Children[0])
+		default:
+			return graph.BoardData{}, t, fmt.Errorf("%s: %s: expected a part or a placed part", form, n.Label())
+		}
+	}
+	return graph.BoardData{}, t, fmt.Errorf("%s: %s does not resolve to a part", form, start.Label())
+}
+
+// registerQueryBuiltins installs builtins that read back geometry from the
+// in-progress design graph, so that dimensions can be expressed relative to
+// other parts.
+func registerQueryBuiltins(env *zygo.Zlisp, st *evalState) {
+	g := st.g
+
+	// -----------------------------------------------------------------------
+	// (dim (part "front") :length)
+	//
+	// Parts answer :length, :width and :thickness (:diameter for dowels).
+	// Any node with geometry answers :x, :y and :z with the extent of its
+	// bounding box.
+	// -----------------------------------------------------------------------
+	st.addFunction(env, "dim", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		if len(args) != 2 {
+			return zygo.SexpNull, fmt.Errorf("dim requires a node reference and a dimension keyword")
+		}
+		n, err := queryNode(g, "dim", args[0])
+		if err != nil {
+			return zygo.SexpNull, err
+		}
+		name, err := toKeywordString(args[1])
+		if err != nil {
+			return zygo.SexpNull, fmt.Errorf("dim: %w", err)
+		}
+
+		if n.Kind == graph.NodePrimitive {
+			if v, ok := partDimension(n.Data, name); ok {
+				return &zygo.SexpFloat{Val: v}, nil
+			}
+		}
+		switch name {
+		case "x", "y", "z":
+			b, err := nodeBounds(g, "dim", n)
+			if err != nil {
+				return zygo.SexpNull, err
+			}
+			v, _ := boxAxis(b, name)
+			return &zygo.SexpFloat{Val: v}, nil
+		}
+		if n.Kind == graph.NodePrimitive {
+			return zygo.SexpNull, fmt.Errorf("dim: %s has no dimension %q", n.Label(), name)
+		}
+		return zygo.SexpNull, fmt.Errorf("dim: only :x, :y and :z apply to %s", n.Label())
+	})
+
+	// -----------------------------------------------------------------------
+	// (thickness-of (part "side"))
+	// -----------------------------------------------------------------------
+	st.addFunction(env, "thickness_of", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		if len(args) != 1 {
+			return zygo.SexpNull, fmt.Errorf("thickness-of requires a part reference")
+		}
+		n, err := queryNode(g, "thickness-of", args[0])
+		if err != nil {
+			return zygo.SexpNull, err
+		}
+		switch d := n.Data.(type) {
+		case graph.BoardData:
+			return &zygo.SexpFloat{Val: d.Dimensions.Z}, nil
+		case graph.DowelData:
+			return &zygo.SexpFloat{Val: d.Diameter}, nil
+		}
+		return zygo.SexpNull, fmt.Errorf("thickness-of: %s: expected a part", n.Label())
+	})
+
+	// -----------------------------------------------------------------------
+	// (bbox (part "box")) => (list (vec3 min...) (vec3 max...))
+	// -----------------------------------------------------------------------
+	st.addFunction(env, "bbox", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		if len(args) != 1 {
+			return zygo.SexpNull, fmt.Errorf("bbox requires a node reference")
+		}
+		n, err := queryNode(g, "bbox", args[0])
+		if err != nil {
+			return zygo.SexpNull, err
+		}
+		b, err := nodeBounds(g, "bbox", n)
+		if err != nil {
+			return zygo.SexpNull, err
+		}
+		return zygo.MakeList([]zygo.Sexp{&sexpVec3{vec: b.Min}, &sexpVec3{vec: b.Max}}), nil
+	})
+
+	// -----------------------------------------------------------------------
+	// (face-center (part "left") :top)
+	//
+	// For a part the point is in the part's own frame; for a placed part
+	// the placement's transform is applied.
+	// -----------------------------------------------------------------------
+	st.addFunction(env, "face_center", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		if len(args) != 2 {
+			return zygo.SexpNull, fmt.Errorf("face-center requires a part reference and a face keyword")
+		}
+		n, err := queryNode(g, "face-center", args[0])
+		if err != nil {
+			return zygo.SexpNull, err
+		}
+		face, err := toFaceID(args[1])
+		if err != nil {
+			return zygo.SexpNull, fmt.Errorf("face-center: %w", err)
+		}
+		bd, t, err := placedBoard(g, "face-center", n)
+		if err != nil {
+			return zygo.SexpNull, err
+		}
+		c, _ := graph.FaceCenter(bd.Dimensions, face)
+		return &sexpVec3{vec: t.Apply(c)}, nil
+	})
+
+	// -----------------------------------------------------------------------
+	// (vec3-x v) (vec3-y v) (vec3-z v)
+	// -----------------------------------------------------------------------
+	for _, axis := range []string{"x", "y", "z"} {
+		axis := axis
+		form := "vec3-" + axis
+		st.addFunction(env, "vec3_"+axis, func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+			if len(args) != 1 {
+				return zygo.SexpNull, fmt.Errorf("%s requires a vec3 argument", form)
+			}
+			v, err := toVec3(args[0])
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("%s: %w", form, err)
+			}
+			switch axis {
+			case "x":
+				return &zygo.SexpFloat{Val: v.X}, nil
+			case "y":
+				return &zygo.SexpFloat{Val: v.Y}, nil
+			}
+			return &zygo.SexpFloat{Val: v.Z}, nil
+		})
+	}
+}
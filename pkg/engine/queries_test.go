@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chazu/lignin/pkg/graph"
+)
+
+func TestDimDerivesFromOtherPart(t *testing.T) {
+	eng := NewEngine()
+	source := `(defpart "side" (board :length 720 :width 300 :thickness 19))
+(defpart "shelf" (board :length 560 :width (- (dim (part "side") :width) 20) :thickness (thickness-of (part "side"))))
+`
+	g, evalErrs, err := eng.Evaluate(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) > 0 {
+		t.Fatalf("eval errors: %v", evalErrs)
+	}
+	bd := g.MustLookup("shelf").Data.(graph.BoardData)
+	if bd.Dimensions.Y != 280 {
+		t.Errorf("shelf width = %g, want 280", bd.Dimensions.Y)
+	}
+	if bd.Dimensions.Z != 19 {
+		t.Errorf("shelf thickness = %g, want 19", bd.Dimensions.Z)
+	}
+}
+
+func TestDimOfAssemblyUsesBoundingBox(t *testing.T) {
+	eng := NewEngine()
+	source := `(defpart "left" (board :length 19 :width 720 :thickness 300))
+(defpart "right" (board :length 19 :width 720 :thickness 300))
+(assembly "carcass"
+  (place (part "left") :at (vec3 0 0 0))
+  (place (part "right") :at (vec3 581 0 0)))
+(defpart "shelf" (board :length (dim (part "carcass") :x) :width 19 :thickness 300))
+`
+	g, evalErrs, err := eng.Evaluate(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) > 0 {
+		t.Fatalf("eval errors: %v", evalErrs)
+	}
+	bd := g.MustLookup("shelf").Data.(graph.BoardData)
+	if bd.Dimensions.X != 600 {
+		t.Errorf("shelf length = %g, want 600", bd.Dimensions.X)
+	}
+}
+
+func TestBboxAndFaceCenter(t *testing.T) {
+	eng := NewEngine()
+	source := `(defpart "left" (board :length 19 :width 720 :thickness 300))
+(def box (bbox (place (part "left") :at (vec3 100 0 0))))
+(def top (face-center (part "left") :top))
+(def placed-top (face-center (place (part "left") :at (vec3 100 0 0)) :top))
+(defpart "probe" (board
+  :length (vec3-x (car (cdr box)))
+  :width (vec3-y top)
+  :thickness (vec3-x placed-top)))
+`
+	g, evalErrs, err := eng.Evaluate(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) > 0 {
+		t.Fatalf("eval errors: %v", evalErrs)
+	}
+	bd := g.MustLookup("probe").Data.(graph.BoardData)
+	if bd.Dimensions.X != 119 {
+		t.Errorf("bbox max x = %g, want 119", bd.Dimensions.X)
+	}
+	if bd.Dimensions.Y != 720 {
+		t.Errorf("top face center y = %g, want 720", bd.Dimensions.Y)
+	}
+	if bd.Dimensions.Z != 109.5 {
+		t.Errorf("placed top face center x = %g, want 109.5", bd.Dimensions.Z)
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "unknown dimension",
+			source: `(defpart "a" (board :length 10 :width 10 :thickness 10)) (dim (part "a") :diameter)`,
+			want:   `has no dimension "diameter"`,
+		},
+		{
+			name: "face of assembly",
+			source: `(defpart "a" (board :length 10 :width 10 :thickness 10))
+(assembly "g" (place (part "a")))
+(face-center (part "g") :top)`,
+			want: "expected a part or a placed part",
+		},
+		{
+			name:   "not a node",
+			source: `(thickness-of 12)`,
+			want:   "expected node reference",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, evalErrs, err := NewEngine().Evaluate(tt.source)
+			if err != nil {
+				t.Fatalf("fatal error: %v", err)
+			}
+			if len(evalErrs) == 0 {
+				t.Fatal("expected an eval error")
+			}
+			if !strings.Contains(evalErrs[0].Message, tt.want) {
+				t.Errorf("error %q does not contain %q", evalErrs[0].Message, tt.want)
+			}
+			if evalErrs[0].Line == 0 {
+				t.Error("expected the error to carry a line number")
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/chazu/lignin/pkg/graph"
+	zygo "github.com/glycerine/zygomys/zygo"
+)
+
+// ---------------------------------------------------------------------------
+// Source location tracking
+// ---------------------------------------------------------------------------
+
+// srcPrefix is the marker prepended to source locations by annotateSource.
+const srcPrefix = "__src_"
+
+// locatedForms lists the DSL builtins whose call sites are annotated with
+// their source location. Names use the underscore spelling that zygomys sees.
+var locatedForms = map[string]bool{
+	"material":      true,
+	"board":         true,
+	"defpart":       true,
+	"redefpart":     true,
+	"part":          true,
+	"vec3":          true,
+	"place":         true,
+	"butt_joint":    true,
+	"screw":         true,
+	"fastener_rule": true,
+	"wood_movement": true,
+	"milling":       true,
+	"stock":         true,
+	"units":         true,
+	"assembly":      true,
+	"param":         true,
+	"variant":       true,
+	"dim":           true,
+	"thickness_of":  true,
+	"bbox":          true,
+	"face_center":   true,
+	"vec3_x":        true,
+	"vec3_y":        true,
+	"vec3_z":        true,
+	"print":         true,
+	"println":       true,
+	"printf":        true,
+	"random_seed":   true,
+	"random":        true,
+}
+
+// annotateSource inserts the source location of every located DSL form as a
+// hidden first argument: (defpart "a" ...) on line 3, column 1 becomes
+// (defpart "__src_3_1" "a" ...). zygomys does not expose the position of the
+// form being executed to Go builtins, so this is how builtins learn where
+// they were called from.
+//
+// Quoted lists, %(part "x") in zygomys, '(part "x") as other Lisps write
+// it, or (quote ...), are data and are left alone,
+// as are calls to a located name after the user rebinds it with def, defn
+// or defmac, since the call then reaches the user's function.
+//
+// It runs on the original source, before preprocessSource, so positions
+// refer to what the user wrote. No newlines are inserted, which keeps the
+// line numbers in zygomys parse errors intact.
+func annotateSource(source string) string {
+	b := []byte(source)
+	result := make([]byte, 0, len(b)+len(b)/8)
+	line, col := 1, 1
+
+	// depth is the list nesting at i; quoted is the depth of the quoted
+	// list being copied, or -1. shadowed holds the located names the user
+	// has rebound so far.
+	depth, quoted := 0, -1
+	quote := false // the previous token was a quote prefix
+	shadowed := make(map[string]bool)
+
+	// advance copies b[i] to the result and tracks line/column.
+	i := 0
+	advance := func() {
+		if b[i] == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+		result = append(result, b[i])
+		i++
+	}
+
+	for i < len(b) {
+		if b[i] != '(' && b[i] != ' ' && b[i] != '\t' && b[i] != '\n' && b[i] != '\r' {
+			quote = false
+		}
+		switch {
+		case b[i] == '\'' && runeLiteralLen(b[i:]) > 0:
+			for n := runeLiteralLen(b[i:]); n > 0; n-- {
+				advance()
+			}
+		case b[i] == '\'' || b[i] == '%':
+			advance()
+			quote = true
+		case b[i] == '"':
+			advance()
+			for i < len(b) && b[i] != '"' {
+				if b[i] == '\\' && i+1 < len(b) {
+					advance()
+				}
+				advance()
+			}
+			if i < len(b) {
+				advance()
+			}
+		case b[i] == '`':
+			advance()
+			for i < len(b) && b[i] != '`' {
+				advance()
+			}
+			if i < len(b) {
+				advance()
+			}
+		case b[i] == ';' || (b[i] == '/' && i+1 < len(b) && b[i+1] == '/'):
+			for i < len(b) && b[i] != '\n' {
+				advance()
+			}
+		case b[i] == '(':
+			formLine, formCol := line, col
+			advance()
+			j := i
+			for j < len(b) && (isIdentChar(b[j]) || b[j] == '-') {
+				j++
+			}
+			head := strings.ReplaceAll(string(b[i:j]), "-", "_")
+			for i < j {
+				advance()
+			}
+			depth++
+			switch {
+			case quoted >= 0:
+			case quote || head == "quote":
+				quoted = depth
+			case head == "def" || head == "defn" || head == "defmac":
+				k := j
+				for k < len(b) && (b[k] == ' ' || b[k] == '\t' || b[k] == '\n' || b[k] == '\r') {
+					k++
+				}
+				n := k
+				for n < len(b) && (isIdentChar(b[n]) || b[n] == '-') {
+					n++
+				}
+				if name := strings.ReplaceAll(string(b[k:n]), "-", "_"); locatedForms[name] {
+					shadowed[name] = true
+				}
+			case locatedForms[head] && !shadowed[head]:
+				result = append(result, fmt.Sprintf(" \"%s%d_%d\"", srcPrefix, formLine, formCol)...)
+			}
+			quote = false
+		case b[i] == ')':
+			if depth == quoted {
+				quoted = -1
+			}
+			depth--
+			advance()
+		default:
+			advance()
+		}
+	}
+	return string(result)
+}
+
+// runeLiteralLen returns the length of the rune literal, such as '(' or
+// '\n', at the start of b, or 0 if there is none.
+func runeLiteralLen(b []byte) int {
+	switch {
+	case len(b) >= 4 && b[1] == '\\' && b[3] == '\'':
+		return 4
+	case len(b) >= 3 && b[1] != '\\' && b[2] == '\'':
+		return 3
+	}
+	return 0
+}
+
+// splitSourceRef removes the source marker inserted by annotateSource from
+// the front of args and returns it as a SourceRef. Calls that were not
+// annotated (e.g. via apply) yield a zero SourceRef and unchanged args.
+func splitSourceRef(args []zygo.Sexp) (graph.SourceRef, []zygo.Sexp) {
+	if len(args) == 0 {
+		return graph.SourceRef{}, args
+	}
+	str, ok := args[0].(*zygo.SexpStr)
+	if !ok || !strings.HasPrefix(str.S, srcPrefix) {
+		return graph.SourceRef{}, args
+	}
+	parts := strings.SplitN(str.S[len(srcPrefix):], "_", 2)
+	if len(parts) != 2 {
+		return graph.SourceRef{}, args[1:]
+	}
+	line, _ := strconv.Atoi(parts[0])
+	col, _ := strconv.Atoi(parts[1])
+	return graph.SourceRef{
+		Line:   line,
+		Col:    col,
+		FormID: fmt.Sprintf("%d:%d", line, col),
+	}, args[1:]
+}
+
+// evalState holds the per-evaluation bookkeeping shared by the builtins.
+// A fresh state is created for every evaluation.
+type evalState struct {
+	g *graph.DesignGraph
+
+	overrides map[string]float64 // parameter overrides from EvalOptions
+	params    []Param            // declared parameters, in declaration order
+	variants  []Variant          // declared variants, in declaration order
+
+	paths       map[string]int                // uses of each node ID path so far
+	provisional map[graph.NodeID]*sexpNodeRef // anonymous nodes not yet given a parent
+	console     consoleLog                    // output of print calls in user code
+	rng         *rand.Rand                    // set by (random-seed n)
+
+	// failedAt is the location of the form whose builtin last returned an
+	// error. zygomys reports runtime errors without line information, so
+	// evaluate uses this to locate them.
+	failedAt graph.SourceRef
+}
+
+func newEvalState(g *graph.DesignGraph, opts EvalOptions) *evalState {
+	return &evalState{
+		g: g, overrides: opts.Overrides,
+		paths: make(map[string]int), provisional: make(map[graph.NodeID]*sexpNodeRef),
+	}
+}
+
+// builtinFunc is the signature of a Lignin DSL builtin. src is the location
+// of the calling form, or the zero SourceRef if it is unknown.
+type builtinFunc func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error)
+
+// addFunction registers a DSL builtin with zygomys.
+func (st *evalState) addFunction(env *zygo.Zlisp, name string, fn builtinFunc) {
+	env.AddFunction(name, st.wrap(fn))
+}
+
+// wrap adapts fn to the zygomys function signature. The source marker is
+// stripped from the arguments before fn sees them, and the location of a
+// failing call is remembered for error reporting.
+func (st *evalState) wrap(fn builtinFunc) zygo.ZlispUserFunction {
+	return func(env *zygo.Zlisp, name string, args []zygo.Sexp) (zygo.Sexp, error) {
+		src, args := splitSourceRef(args)
+		res, err := fn(env, src, args)
+		if err != nil && src.Line > 0 {
+			st.failedAt = src
+		}
+		return res, err
+	}
+}
+
+// newSandbox creates a sandboxed zygomys environment for one evaluation.
+// Sandbox mode prevents user code from accessing the filesystem or
+// syscalls; the print builtins are replaced so that their output is
+// captured in the evaluation's console log.
+func (st *evalState) newSandbox() *zygo.Zlisp {
+	funcs := zygo.SandboxSafeFunctions()
+	for _, name := range nondeterministicBuiltins {
+		delete(funcs, name)
+	}
+	for name, fn := range st.printFunctions() {
+		funcs[name] = fn
+	}
+	return zygo.NewZlispWithFuncs(funcs)
+}
+
+// locate fills in the position of evalErrs from the failing builtin call
+// when zygomys did not report one.
+func (st *evalState) locate(evalErrs []EvalError) []EvalError {
+	if st.failedAt.Line == 0 {
+		return evalErrs
+	}
+	for i := range evalErrs {
+		if evalErrs[i].Line == 0 {
+			evalErrs[i].Line = st.failedAt.Line
+			evalErrs[i].Col = st.failedAt.Col
+		}
+	}
+	return evalErrs
+}
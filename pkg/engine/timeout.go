@@ -13,9 +13,13 @@ const EvalTimeout = 5 * time.Second
 
 // result is the internal type used to pass evaluation results through channels.
 type evalResult struct {
-	graph  *graph.DesignGraph
-	errors []EvalError
-	err    error
+	graph    *graph.DesignGraph
+	errors   []EvalError
+	warnings []EvalWarning
+	params   []Param
+	variants []Variant
+	console  []ConsoleEntry
+	err      error
 }
 
 // waitWithTimeout waits for a result from ch, but returns a timeout error
@@ -30,6 +34,20 @@ func waitWithTimeout(
 	mu *sync.Mutex,
 	currentGen *uint64,
 ) (*graph.DesignGraph, []EvalError, error) {
+	res, err := awaitResult(ch, gen, mu, currentGen)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.graph, res.errors, res.err
+}
+
+// awaitResult is like waitWithTimeout but returns the complete evalResult.
+func awaitResult(
+	ch <-chan evalResult,
+	gen uint64,
+	mu *sync.Mutex,
+	currentGen *uint64,
+) (evalResult, error) {
 	timer := time.NewTimer(EvalTimeout)
 	defer timer.Stop()
 
@@ -42,12 +60,12 @@ func waitWithTimeout(
 
 		if gen != current {
 			// A newer evaluation was started; discard this result.
-			return nil, nil, fmt.Errorf("evaluation superseded by newer request")
+			return evalResult{}, fmt.Errorf("evaluation superseded by newer request")
 		}
 
-		return res.graph, res.errors, res.err
+		return res, nil
 
 	case <-timer.C:
-		return nil, nil, fmt.Errorf("evaluation timed out after %s", EvalTimeout)
+		return evalResult{}, fmt.Errorf("evaluation timed out after %s", EvalTimeout)
 	}
 }
@@ -0,0 +1,116 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/chazu/lignin/pkg/graph"
+	zygo "github.com/glycerine/zygomys/zygo"
+)
+
+// Variant is a named set of parameter values declared with (variant ...),
+// e.g. (variant "tall" :width 600 :height 1800).
+type Variant struct {
+	Name   string
+	Values map[string]float64 // parameter name -> value
+	Line   int
+	Col    int
+}
+
+// ListVariants evaluates source with default parameter values and returns
+// the variants it declares, in declaration order. Evaluation errors are
+// returned as eval errors, fatal failures as error.
+func (e *Engine) ListVariants(source string) ([]Variant, []EvalError, error) {
+	res, err := e.EvaluateWithOptions(source, EvalOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.Variants, res.Errors, nil
+}
+
+// EvaluateVariant evaluates source with the parameter values of the named
+// variant. The resulting graph is labeled with the variant name.
+func (e *Engine) EvaluateVariant(source, name string) (*EvalResult, error) {
+	return e.EvaluateWithOptions(source, EvalOptions{Variant: name})
+}
+
+// evaluateVariant runs the two passes behind EvalOptions.Variant: the first
+// collects the declared variants with default parameter values, the second
+// evaluates with the selected variant's values. Explicit overrides in opts
+// take precedence over the variant's values.
+func (e *Engine) evaluateVariant(source string, opts EvalOptions) evalResult {
+	first := e.evaluate(source, EvalOptions{})
+	if len(first.errors) > 0 {
+		return first
+	}
+
+	var selected *Variant
+	for i := range first.variants {
+		if first.variants[i].Name == opts.Variant {
+			selected = &first.variants[i]
+			break
+		}
+	}
+	if selected == nil {
+		return evalResult{
+			errors:   []EvalError{{Message: fmt.Sprintf("unknown variant %q", opts.Variant)}},
+			params:   first.params,
+			variants: first.variants,
+		}
+	}
+
+	overrides := make(map[string]float64, len(selected.Values)+len(opts.Overrides))
+	for k, v := range selected.Values {
+		overrides[k] = v
+	}
+	for k, v := range opts.Overrides {
+		overrides[k] = v
+	}
+
+	res := e.evaluate(source, EvalOptions{Overrides: overrides})
+	if res.graph != nil {
+		res.graph.Variant = selected.Name
+	}
+	return res
+}
+
+// registerVariantBuiltins installs the (variant ...) builtin.
+func registerVariantBuiltins(env *zygo.Zlisp, st *evalState) {
+
+	// -----------------------------------------------------------------------
+	// (variant "tall" :width 600 :height 1800)
+	// -----------------------------------------------------------------------
+	st.addFunction(env, "variant", func(env *zygo.Zlisp, src graph.SourceRef, args []zygo.Sexp) (zygo.Sexp, error) {
+		pa := parseArgs(args)
+		if len(pa.positional) != 1 {
+			return zygo.SexpNull, fmt.Errorf("variant requires a name followed by :param value pairs")
+		}
+
+		variantName, err := toString(pa.positional[0])
+		if err != nil {
+			return zygo.SexpNull, fmt.Errorf("variant: name: %w", err)
+		}
+		for _, prev := range st.variants {
+			if prev.Name == variantName {
+				return zygo.SexpNull, fmt.Errorf("variant: %q redeclared at line %d, col %d; first declared at line %d, col %d",
+					variantName, src.Line, src.Col, prev.Line, prev.Col)
+			}
+		}
+
+		v := Variant{
+			Name:   variantName,
+			Values: make(map[string]float64, len(pa.kw)),
+			Line:   src.Line,
+			Col:    src.Col,
+		}
+		for k, val := range pa.kw {
+			f, err := toFloat64(val)
+			if err != nil {
+				return zygo.SexpNull, fmt.Errorf("variant %q: %s: %w", variantName, k, err)
+			}
+			v.Values[k] = f
+		}
+		st.variants = append(st.variants, v)
+
+		return zygo.SexpNull, nil
+	})
+}
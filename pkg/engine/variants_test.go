@@ -0,0 +1,156 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chazu/lignin/pkg/graph"
+)
+
+const cabinetSource = `(def width (param "width" 500 :min 300 :max 900))
+(def height (param "height" 900 :min 600 :max 2000))
+
+(variant "tall" :width 600 :height 1800)
+(variant "wide" :width 900)
+
+(defpart "side" (board :length 19 :width height :thickness 400 :grain :y))
+(defpart "top" (board :length width :width 19 :thickness 400 :grain :x))
+
+(assembly "cabinet"
+  (place (part "side") :at (vec3 0 0 0))
+  (place (part "top") :at (vec3 0 height 0))
+  (butt-joint
+    :part-a (part "top") :face-a :bottom
+    :part-b (part "side") :face-b :top
+    :fasteners (list (screw :diameter 4 :length 40 :position (vec3 0 0 100)))))
+`
+
+func TestListVariants(t *testing.T) {
+	eng := NewEngine()
+
+	variants, evalErrs, err := eng.ListVariants(cabinetSource)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) > 0 {
+		t.Fatalf("eval errors: %v", evalErrs)
+	}
+	if len(variants) != 2 {
+		t.Fatalf("expected 2 variants, got %d", len(variants))
+	}
+	if variants[0].Name != "tall" || variants[1].Name != "wide" {
+		t.Errorf("expected variants in declaration order, got %q, %q", variants[0].Name, variants[1].Name)
+	}
+	if variants[0].Values["width"] != 600 || variants[0].Values["height"] != 1800 {
+		t.Errorf("unexpected tall values: %v", variants[0].Values)
+	}
+	if variants[0].Line != 4 {
+		t.Errorf("expected tall declared on line 4, got %d", variants[0].Line)
+	}
+}
+
+func TestEvaluateVariant(t *testing.T) {
+	eng := NewEngine()
+
+	res, err := eng.EvaluateVariant(cabinetSource, "tall")
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("eval errors: %v", res.Errors)
+	}
+	if res.Graph.Variant != "tall" {
+		t.Errorf("expected graph labeled %q, got %q", "tall", res.Graph.Variant)
+	}
+	side := res.Graph.Lookup("side").Data.(graph.BoardData)
+	top := res.Graph.Lookup("top").Data.(graph.BoardData)
+	if side.Dimensions.Y != 1800 || top.Dimensions.X != 600 {
+		t.Errorf("expected tall dimensions, got side=%v top=%v", side.Dimensions, top.Dimensions)
+	}
+
+	// The base design is unlabeled and uses defaults.
+	base, err := eng.EvaluateWithOptions(cabinetSource, EvalOptions{})
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if base.Graph.Variant != "" {
+		t.Errorf("expected unlabeled base graph, got %q", base.Graph.Variant)
+	}
+}
+
+func TestEvaluateVariantExplicitOverrideWins(t *testing.T) {
+	eng := NewEngine()
+
+	res, err := eng.EvaluateWithOptions(cabinetSource, EvalOptions{
+		Variant:   "tall",
+		Overrides: map[string]float64{"width": 700},
+	})
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("eval errors: %v", res.Errors)
+	}
+	top := res.Graph.Lookup("top").Data.(graph.BoardData)
+	side := res.Graph.Lookup("side").Data.(graph.BoardData)
+	if top.Dimensions.X != 700 || side.Dimensions.Y != 1800 {
+		t.Errorf("expected width 700 and height 1800, got top=%v side=%v", top.Dimensions, side.Dimensions)
+	}
+}
+
+func TestEvaluateUnknownVariant(t *testing.T) {
+	eng := NewEngine()
+
+	res, err := eng.EvaluateVariant(cabinetSource, "short")
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if res.Graph != nil {
+		t.Error("expected nil graph for unknown variant")
+	}
+	if len(res.Errors) != 1 || !strings.Contains(res.Errors[0].Message, `unknown variant "short"`) {
+		t.Fatalf("expected unknown variant error, got %v", res.Errors)
+	}
+	if len(res.Variants) != 2 {
+		t.Errorf("expected declared variants to be reported, got %d", len(res.Variants))
+	}
+}
+
+func TestVariantRedeclared(t *testing.T) {
+	eng := NewEngine()
+
+	source := "(variant \"tall\" :height 1800)\n(variant \"tall\" :height 2000)\n"
+	_, evalErrs, err := eng.ListVariants(source)
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	if len(evalErrs) != 1 || !strings.Contains(evalErrs[0].Message, "first declared at line 1") {
+		t.Fatalf("expected redeclaration error, got %v", evalErrs)
+	}
+}
+
+func TestVariantGraphDeterministic(t *testing.T) {
+	eng := NewEngine()
+
+	first, err := eng.EvaluateVariant(cabinetSource, "wide")
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	// Evaluate something else in between to advance any shared state.
+	if _, err := eng.EvaluateVariant(cabinetSource, "tall"); err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+	second, err := eng.EvaluateVariant(cabinetSource, "wide")
+	if err != nil {
+		t.Fatalf("fatal error: %v", err)
+	}
+
+	if first.Graph.NodeCount() != second.Graph.NodeCount() {
+		t.Fatalf("node counts differ: %d vs %d", first.Graph.NodeCount(), second.Graph.NodeCount())
+	}
+	for id := range first.Graph.Nodes {
+		if second.Graph.Get(id) == nil {
+			t.Errorf("node %s missing from second evaluation", id.Short())
+		}
+	}
+}
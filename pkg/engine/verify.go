@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chazu/lignin/pkg/graph"
+)
+
+// Verification compares the fingerprint stamped into an output with the
+// fingerprint of the current source.
+type Verification struct {
+	Stamped graph.Fingerprint // found in the output
+	Current graph.Fingerprint // of the current source, evaluated now
+}
+
+// SourceChanged reports whether the source text differs from the one the
+// output was produced from.
+func (v Verification) SourceChanged() bool { return v.Stamped.Source != v.Current.Source }
+
+// DesignChanged reports whether the evaluated design differs. An edit that
+// only touches comments or layout changes the source but not the design.
+func (v Verification) DesignChanged() bool { return v.Stamped.Graph != v.Current.Graph }
+
+// VersionChanged reports whether the output was produced by another Lignin
+// version.
+func (v Verification) VersionChanged() bool { return v.Stamped.Version != v.Current.Version }
+
+// OK reports whether the output still matches the current design. Source
+// and version changes alone do not make an output stale.
+func (v Verification) OK() bool { return !v.DesignChanged() }
+
+// Summary describes the outcome in one line per finding.
+func (v Verification) Summary() string {
+	var lines []string
+	if v.DesignChanged() {
+		lines = append(lines, fmt.Sprintf("design changed: output is %s, current source is %s",
+			v.Stamped.Short(), v.Current.Short()))
+	} else {
+		lines = append(lines, "design matches ("+v.Current.Short()+")")
+	}
+	if v.SourceChanged() && !v.DesignChanged() {
+		lines = append(lines, "source edited without changing the design")
+	}
+	if v.VersionChanged() {
+		lines = append(lines, fmt.Sprintf("produced by Lignin %s, current is %s", v.Stamped.Version, v.Current.Version))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Verify checks whether an output stamped with a fingerprint still matches
+// source. The source is evaluated for the stamped variant and parameter
+// overrides, which replace any in opts. An error is returned if output
+// carries no fingerprint or source fails to evaluate.
+func (e *Engine) Verify(source, output string, opts EvalOptions) (Verification, error) {
+	stamped, ok := graph.FindFingerprint(output)
+	if !ok {
+		return Verification{}, fmt.Errorf("verify: output carries no Lignin fingerprint")
+	}
+	opts.Variant = stamped.Variant
+	opts.Overrides = stamped.Overrides()
+	res, err := e.EvaluateWithOptions(source, opts)
+	if err != nil {
+		return Verification{}, fmt.Errorf("verify: %w", err)
+	}
+	if len(res.Errors) > 0 {
+		return Verification{}, fmt.Errorf("verify: source does not evaluate: %s", res.Errors[0].Error())
+	}
+	return Verification{Stamped: stamped, Current: *res.Graph.Fingerprint}, nil
+}
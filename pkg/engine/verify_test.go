@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chazu/lignin/pkg/graph"
+)
+
+func TestEvaluateSetsFingerprint(t *testing.T) {
+	res, err := NewEngine().EvaluateWithOptions(cabinetSource, EvalOptions{Variant: "tall"})
+	if err != nil || len(res.Errors) > 0 {
+		t.Fatalf("evaluation failed: %v %v", err, res.Errors)
+	}
+	fp := res.Graph.Fingerprint
+	if fp == nil {
+		t.Fatal("expected a fingerprint")
+	}
+	if fp.Version != Version || fp.Variant != "tall" {
+		t.Errorf("fingerprint = %+v", fp)
+	}
+	if fp.Source != graph.SourceHash(cabinetSource) || fp.Graph != res.Graph.CanonicalHash() {
+		t.Error("fingerprint hashes do not match the source and graph")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	eng := NewEngine()
+	res, err := eng.EvaluateWithOptions(cabinetSource, EvalOptions{Variant: "wide"})
+	if err != nil || len(res.Errors) > 0 {
+		t.Fatalf("evaluation failed: %v %v", err, res.Errors)
+	}
+	output := graph.ExportDOT(res.Graph, graph.ExportOptions{})
+
+	v, err := eng.Verify(cabinetSource, output, EvalOptions{})
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !v.OK() || v.SourceChanged() {
+		t.Errorf("unchanged source should verify: %s", v.Summary())
+	}
+
+	v, err = eng.Verify("; reformatted\n"+cabinetSource, output, EvalOptions{})
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !v.OK() || !v.SourceChanged() {
+		t.Errorf("a comment-only edit should change the source but still verify: %s", v.Summary())
+	}
+
+	v, err = eng.Verify(strings.Replace(cabinetSource, ":width 900", ":width 850", 1), output, EvalOptions{})
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if v.OK() || !strings.Contains(v.Summary(), "design changed") {
+		t.Errorf("a changed design should not verify: %s", v.Summary())
+	}
+
+	if _, err := eng.Verify(cabinetSource, "digraph design {}", EvalOptions{}); err == nil {
+		t.Error("expected an error for an output without a fingerprint")
+	}
+}
+
+func TestVerifyReappliesStampedOverrides(t *testing.T) {
+	eng := NewEngine()
+	res, err := eng.EvaluateWithOptions(cabinetSource, EvalOptions{
+		Variant:   "wide",
+		Overrides: map[string]float64{"height": 1200, "depth": 300},
+	})
+	if err != nil || len(res.Errors) > 0 {
+		t.Fatalf("evaluation failed: %v %v", err, res.Errors)
+	}
+	// Only declared parameters are recorded; "depth" was ignored.
+	if fp := res.Graph.Fingerprint; fp.Params != "height=1200" {
+		t.Errorf("fingerprint params = %q, want height=1200", fp.Params)
+	}
+	output := graph.ExportMermaid(res.Graph, graph.ExportOptions{})
+
+	v, err := eng.Verify(cabinetSource, output, EvalOptions{})
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !v.OK() {
+		t.Errorf("output should verify from source alone: %s", v.Summary())
+	}
+}
@@ -1,5 +1,7 @@
 package graph
 
+import "math"
+
 // ---------------------------------------------------------------------------
 // Material
 // ---------------------------------------------------------------------------
@@ -30,6 +32,12 @@ type BoardData struct {
 	Dimensions Vec3          `json:"dimensions"` // length x width x thickness in mm
 	Grain      Axis          `json:"grain"`      // dominant grain direction
 	Material   MaterialSpec  `json:"material"`
+
+	// Nominal is the lumber size the board was specified by, such as "4/4"
+	// (thickness, in quarters) or "1x6" (S4S thickness and width), or empty.
+	Nominal string `json:"nominal,omitempty"`
+	// Milling replaces the design's milling allowance for this board.
+	Milling *MillingAllowance `json:"milling,omitempty"`
 }
 
 func (BoardData) nodeData() {}
@@ -111,7 +119,7 @@ type JoinData struct {
 	FaceA     FaceID   `json:"face_a"`
 	PartB     NodeID   `json:"part_b"`
 	FaceB     FaceID   `json:"face_b"`
-	Clearance float64  `json:"clearance"` // gap in mm (0 = use global default)
+	Clearance *float64 `json:"clearance,omitempty"` // gap in mm (nil = use global default)
 	Params    JoinParams `json:"params"`
 	Fasteners []NodeID `json:"fasteners,omitempty"`
 }
@@ -177,6 +185,17 @@ func (k FastenerKind) String() string {
 	}
 }
 
+// ParseFastenerKind returns the fastener kind with the given name, as
+// returned by String.
+func ParseFastenerKind(name string) (FastenerKind, bool) {
+	for k := FastenerScrew; k <= FastenerBolt; k++ {
+		if k.String() == name {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
 // FastenerData specifies a fastener placed through a join.
 type FastenerData struct {
 	Kind             FastenerKind `json:"kind"`
@@ -190,3 +209,57 @@ type FastenerData struct {
 }
 
 func (FastenerData) nodeData() {}
+
+// PilotHole returns the pilot hole diameter in mm: PilotHoleDia if set,
+// otherwise a hardwood default of about 70% of a screw's or 80% of a
+// nail's shank diameter, rounded to a 0.5 mm drill. Dowel pins are set in
+// holes of their own diameter; bolts take no pilot hole.
+func (f FastenerData) PilotHole() float64 {
+	if f.PilotHoleDia > 0 {
+		return f.PilotHoleDia
+	}
+	switch f.Kind {
+	case FastenerScrew:
+		return drillSize(0.7 * f.Diameter)
+	case FastenerNail:
+		return drillSize(0.8 * f.Diameter)
+	case FastenerDowelPin:
+		return f.Diameter
+	}
+	return 0
+}
+
+// ClearanceHole returns the diameter in mm of the hole the fastener passes
+// freely through in part A: ClearanceHoleDia if set, otherwise the shank
+// diameter plus 10% (at least 0.5 mm), rounded up to a 0.5 mm drill. Nails
+// and dowel pins take no clearance hole.
+func (f FastenerData) ClearanceHole() float64 {
+	if f.ClearanceHoleDia > 0 {
+		return f.ClearanceHoleDia
+	}
+	switch f.Kind {
+	case FastenerScrew, FastenerBolt:
+		return math.Ceil(2*(f.Diameter+math.Max(0.5, 0.1*f.Diameter))) / 2
+	}
+	return 0
+}
+
+// drillSize rounds a hole diameter to the nearest 0.5 mm.
+func drillSize(d float64) float64 {
+	return math.Round(2*d) / 2
+}
+
+// FastenerRule sets how far a fastener must reach into part B, the
+// receiving board, after passing through part A.
+type FastenerRule struct {
+	MinEmbedment        float64 `json:"min_embedment"`         // mm into part B
+	MinThreadEngagement float64 `json:"min_thread_engagement"` // length in part B as a multiple of the diameter
+}
+
+// DefaultFastenerRules are the embedment rules for fastener kinds that the
+// graph defaults do not override. Dowel pins and bolts have none: pins are
+// not driven through part A and bolts are held by a nut.
+var DefaultFastenerRules = map[FastenerKind]FastenerRule{
+	FastenerScrew: {MinEmbedment: 12, MinThreadEngagement: 5},
+	FastenerNail:  {MinEmbedment: 15, MinThreadEngagement: 10},
+}
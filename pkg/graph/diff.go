@@ -0,0 +1,319 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Structural diff
+// ---------------------------------------------------------------------------
+
+// FieldChange is a change to one field of a node. Field is a dotted path
+// such as "dimensions.X" or "material.species"; Old and New are display
+// values, empty when the field is unset on that side.
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// NodeDiff lists the field-level changes to a node present in both graphs.
+type NodeDiff struct {
+	Old     *Node
+	New     *Node
+	Changes []FieldChange
+}
+
+// GraphDiff is the structural difference between two design graphs. Nodes
+// are matched by NodeID; each list is sorted by kind, then in canonical
+// order (see CompareNodes).
+type GraphDiff struct {
+	Added    []*Node
+	Removed  []*Node
+	Modified []NodeDiff
+
+	// Old and New are the fingerprints of the compared graphs, if set.
+	Old, New *Fingerprint
+}
+
+// Empty reports whether the two graphs are structurally identical.
+func (d *GraphDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// Diff compares two graphs node by node. Nodes whose ContentHash is equal
+// are unchanged; the others are compared field by field. Source locations
+// are ignored, so moving a form within the source is not a change.
+func Diff(old, new *DesignGraph) *GraphDiff {
+	d := &GraphDiff{Old: old.Fingerprint, New: new.Fingerprint}
+	names := diffNames{old, new}
+
+	for id, on := range old.Nodes {
+		nn, ok := new.Nodes[id]
+		if !ok {
+			d.Removed = append(d.Removed, on)
+			continue
+		}
+		if contentHash(on) == contentHash(nn) {
+			continue
+		}
+		if changes := diffFields(on, nn, names); len(changes) > 0 {
+			d.Modified = append(d.Modified, NodeDiff{Old: on, New: nn, Changes: changes})
+		}
+	}
+	for id, nn := range new.Nodes {
+		if _, ok := old.Nodes[id]; !ok {
+			d.Added = append(d.Added, nn)
+		}
+	}
+
+	sortNodes(d.Added)
+	sortNodes(d.Removed)
+	sort.Slice(d.Modified, func(i, j int) bool {
+		return nodeLess(d.Modified[i].New, d.Modified[j].New)
+	})
+	return d
+}
+
+// contentHash returns the stored hash of n, computing it for nodes that
+// were not added through AddNode.
+func contentHash(n *Node) ContentHash {
+	if n.ContentHash != (ContentHash{}) {
+		return n.ContentHash
+	}
+	return n.ComputeContentHash()
+}
+
+func sortNodes(nodes []*Node) {
+	sort.Slice(nodes, func(i, j int) bool { return nodeLess(nodes[i], nodes[j]) })
+}
+
+func nodeLess(a, b *Node) bool {
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	return CompareNodes(a, b) < 0
+}
+
+// diffFields compares the flattened fields of two versions of a node.
+func diffFields(old, new *Node, names diffNames) []FieldChange {
+	of := flattenNode(old, names)
+	nf := flattenNode(new, names)
+
+	keys := make(map[string]bool, len(of)+len(nf))
+	for k := range of {
+		keys[k] = true
+	}
+	for k := range nf {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var changes []FieldChange
+	for _, k := range sorted {
+		if of[k] != nf[k] {
+			changes = append(changes, FieldChange{Field: k, Old: of[k], New: nf[k]})
+		}
+	}
+	return changes
+}
+
+// flattenNode returns the semantic fields of n as dotted paths mapped to
+// display values. Data fields are flattened without a "data." prefix.
+func flattenNode(n *Node, names diffNames) map[string]string {
+	fields := map[string]string{
+		"kind": n.Kind.String(),
+		"name": n.Name,
+	}
+	if len(n.Children) > 0 {
+		fields["children"] = names.list(n.Children)
+	}
+	if len(n.Tags) > 0 {
+		fields["tags"] = strings.Join(n.Tags, ", ")
+	}
+	for k, v := range n.Meta {
+		fields["meta."+k] = v
+	}
+	if n.Data == nil {
+		return fields
+	}
+
+	tag, err := nodeDataType(n.Data)
+	if err != nil {
+		fields["data"] = fmt.Sprintf("%+v", n.Data)
+		return fields
+	}
+	fields["data_type"] = tag
+
+	b, err := json.Marshal(n.Data)
+	if err != nil {
+		fields["data"] = fmt.Sprintf("%+v", n.Data)
+		return fields
+	}
+	var raw map[string]any
+	if err := json.Unmarshal(b, &raw); err != nil {
+		fields["data"] = string(b)
+		return fields
+	}
+	data := make(map[string]string)
+	flattenValue(data, "", raw, names)
+
+	// Enumerations are encoded as integers; show their names instead. The
+	// kind of a join or fastener is renamed so it does not collide with
+	// the node kind.
+	delete(data, "prim_kind")
+	delete(data, "kind")
+	switch d := n.Data.(type) {
+	case BoardData:
+		data["grain"] = d.Grain.String()
+	case DowelData:
+		data["grain"] = d.Grain.String()
+	case JoinData:
+		data["join_kind"] = d.Kind.String()
+	case FastenerData:
+		data["fastener_kind"] = d.Kind.String()
+	}
+	for k, v := range data {
+		fields[k] = v
+	}
+	return fields
+}
+
+// flattenValue adds v to fields under path, recursing into objects.
+func flattenValue(fields map[string]string, path string, v any, names diffNames) {
+	switch x := v.(type) {
+	case map[string]any:
+		for k, child := range x {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			flattenValue(fields, p, child, names)
+		}
+	case []any:
+		items := make([]string, len(x))
+		for i, item := range x {
+			items[i] = names.value(item)
+		}
+		fields[path] = strings.Join(items, ", ")
+	default:
+		fields[path] = names.value(x)
+	}
+}
+
+// diffNames renders values for display, replacing node IDs with the names
+// of the nodes they refer to in either graph.
+type diffNames struct {
+	old, new *DesignGraph
+}
+
+func (dn diffNames) value(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		var id NodeID
+		if len(x) == 2*len(id) && id.UnmarshalText([]byte(x)) == nil {
+			return dn.node(id)
+		}
+		return x
+	case float64:
+		return fmt.Sprintf("%g", x)
+	case bool:
+		return fmt.Sprintf("%t", x)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func (dn diffNames) node(id NodeID) string {
+	for _, g := range []*DesignGraph{dn.new, dn.old} {
+		if n := g.Get(id); n != nil {
+			return n.Label()
+		}
+	}
+	return id.Short()
+}
+
+func (dn diffNames) list(ids []NodeID) string {
+	items := make([]string, len(ids))
+	for i, id := range ids {
+		items[i] = dn.node(id)
+	}
+	return strings.Join(items, ", ")
+}
+
+// ---------------------------------------------------------------------------
+// Report
+// ---------------------------------------------------------------------------
+
+// Report renders the diff as Markdown suitable for a review comment.
+func (d *GraphDiff) Report() string {
+	var b strings.Builder
+	if d.Empty() {
+		b.WriteString("No structural changes.\n")
+		d.writeFingerprints(&b)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "**Design changes:** %d added, %d removed, %d modified\n",
+		len(d.Added), len(d.Removed), len(d.Modified))
+
+	if len(d.Added) > 0 {
+		b.WriteString("\n### Added\n\n")
+		for _, n := range d.Added {
+			fmt.Fprintf(&b, "- %s%s\n", n.Label(), sourceSuffix(n))
+		}
+	}
+	if len(d.Removed) > 0 {
+		b.WriteString("\n### Removed\n\n")
+		for _, n := range d.Removed {
+			fmt.Fprintf(&b, "- %s%s\n", n.Label(), sourceSuffix(n))
+		}
+	}
+	if len(d.Modified) > 0 {
+		b.WriteString("\n### Modified\n")
+		for _, m := range d.Modified {
+			fmt.Fprintf(&b, "\n- %s%s\n", m.New.Label(), sourceSuffix(m.New))
+			for _, c := range m.Changes {
+				fmt.Fprintf(&b, "  - `%s`: %s → %s\n", c.Field, reportValue(c.Old), reportValue(c.New))
+			}
+		}
+	}
+	d.writeFingerprints(&b)
+	return b.String()
+}
+
+// writeFingerprints appends the fingerprints of the compared designs, the
+// new one first so it is the one a verifier finds.
+func (d *GraphDiff) writeFingerprints(b *strings.Builder) {
+	if d.New == nil && d.Old == nil {
+		return
+	}
+	b.WriteString("\n")
+	if d.New != nil {
+		fmt.Fprintf(b, "- Design: `%s`\n", d.New)
+	}
+	if d.Old != nil {
+		fmt.Fprintf(b, "- Compared with: `%s`\n", d.Old)
+	}
+}
+
+func sourceSuffix(n *Node) string {
+	if n.Source.IsZero() {
+		return ""
+	}
+	return " (" + n.Source.String() + ")"
+}
+
+func reportValue(v string) string {
+	if v == "" {
+		return "_none_"
+	}
+	return "`" + v + "`"
+}
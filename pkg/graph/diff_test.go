@@ -0,0 +1,147 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffIdenticalGraphs(t *testing.T) {
+	d := Diff(jsonTestGraph(), jsonTestGraph())
+	if !d.Empty() {
+		t.Errorf("expected empty diff, got %+v", d)
+	}
+	if got := d.Report(); got != "No structural changes.\n" {
+		t.Errorf("unexpected report for empty diff: %q", got)
+	}
+}
+
+func TestDiffIgnoresSourceLocation(t *testing.T) {
+	old := jsonTestGraph()
+	new := jsonTestGraph()
+	side := new.MustLookup("side")
+	side.Source = SourceRef{Line: 12, Col: 3, FormID: "12:3"}
+	new.AddNode(side)
+
+	if d := Diff(old, new); !d.Empty() {
+		t.Errorf("moving a form should not be a change, got %+v", d)
+	}
+}
+
+func TestDiffAddedRemovedModified(t *testing.T) {
+	old := jsonTestGraph()
+	new := jsonTestGraph()
+
+	// Modify: resize the side and change its species.
+	side := new.MustLookup("side")
+	bd := side.Data.(BoardData)
+	bd.Dimensions.Y = 900
+	bd.Material.Species = "walnut"
+	side.Data = bd
+	new.AddNode(side)
+
+	// Modify: drop the fastener from the join.
+	for _, n := range new.Joins() {
+		jd := n.Data.(JoinData)
+		jd.Fasteners = nil
+		n.Data = jd
+		new.AddNode(n)
+	}
+
+	// Remove the dowel, add a shelf.
+	delete(new.Nodes, NewNodeID("pin"))
+	delete(new.NameIndex, "pin")
+	new.AddNode(&Node{
+		ID:   NewNodeID("shelf"),
+		Kind: NodePrimitive,
+		Name: "shelf",
+		Data: BoardData{Dimensions: Vec3{560, 280, 19}},
+	})
+
+	d := Diff(old, new)
+	if len(d.Added) != 1 || d.Added[0].Name != "shelf" {
+		t.Errorf("expected shelf to be added, got %v", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].Name != "pin" {
+		t.Errorf("expected pin to be removed, got %v", d.Removed)
+	}
+	if len(d.Modified) != 2 {
+		t.Fatalf("expected 2 modified nodes, got %d: %+v", len(d.Modified), d.Modified)
+	}
+
+	// Primitives sort before joins.
+	sideDiff := d.Modified[0]
+	if sideDiff.New.Name != "side" {
+		t.Fatalf("expected side first, got %s", sideDiff.New.Label())
+	}
+	want := []FieldChange{
+		{Field: "dimensions.Y", Old: "720", New: "900"},
+		{Field: "material.species", Old: "white-oak", New: "walnut"},
+	}
+	if len(sideDiff.Changes) != len(want) {
+		t.Fatalf("side changes = %+v, want %+v", sideDiff.Changes, want)
+	}
+	for i := range want {
+		if sideDiff.Changes[i] != want[i] {
+			t.Errorf("side change %d = %+v, want %+v", i, sideDiff.Changes[i], want[i])
+		}
+	}
+
+	joinDiff := d.Modified[1]
+	if len(joinDiff.Changes) != 1 || joinDiff.Changes[0].Field != "fasteners" || joinDiff.Changes[0].New != "" {
+		t.Errorf("unexpected join changes: %+v", joinDiff.Changes)
+	}
+	if !strings.HasPrefix(joinDiff.Changes[0].Old, "fastener ") {
+		t.Errorf("expected fastener IDs to be rendered as labels, got %q", joinDiff.Changes[0].Old)
+	}
+
+	report := d.Report()
+	for _, s := range []string{
+		"1 added, 1 removed, 2 modified",
+		`- primitive "shelf"`,
+		`- primitive "pin"`,
+		`- primitive "side" (line 1, col 1)`,
+		"`dimensions.Y`: `720` → `900`",
+		"`fasteners`: `fastener ",
+		"→ _none_",
+	} {
+		if !strings.Contains(report, s) {
+			t.Errorf("report does not contain %q:\n%s", s, report)
+		}
+	}
+}
+
+func TestContentHashIgnoresIDAndSource(t *testing.T) {
+	a := &Node{ID: NewNodeID("a"), Kind: NodePrimitive, Name: "p", Data: BoardData{Dimensions: Vec3{1, 2, 3}}}
+	b := *a
+	b.ID = NewNodeID("b")
+	b.Source = SourceRef{Line: 4, Col: 1}
+	if a.ComputeContentHash() != b.ComputeContentHash() {
+		t.Error("content hash should not depend on ID or source")
+	}
+	b.Data = BoardData{Dimensions: Vec3{1, 2, 4}}
+	if a.ComputeContentHash() == b.ComputeContentHash() {
+		t.Error("content hash should change with data")
+	}
+}
+
+func TestDiffTagsAndMeta(t *testing.T) {
+	old := jsonTestGraph()
+	new := jsonTestGraph()
+	side := new.MustLookup("side")
+	side.Tags = []string{"visible-face"}
+	side.Meta = map[string]string{"finish": "paint"}
+	new.AddNode(side)
+
+	d := Diff(old, new)
+	if len(d.Modified) != 1 {
+		t.Fatalf("expected 1 modified node, got %+v", d.Modified)
+	}
+	want := []FieldChange{
+		{Field: "meta.finish", Old: "oil", New: "paint"},
+		{Field: "tags", Old: "visible-face, primary-wood", New: "visible-face"},
+	}
+	got := d.Modified[0].Changes
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("changes = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,233 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Diagram export
+// ---------------------------------------------------------------------------
+
+// ExportOptions controls diagram export.
+type ExportOptions struct {
+	// Findings, if set, colors nodes with validation errors red and nodes
+	// with warnings amber. Graph-level findings are not shown.
+	Findings *ValidationResult
+}
+
+// diagramEdge is an edge in an exported diagram.
+type diagramEdge struct {
+	from, to NodeID
+	label    string // empty for child edges
+}
+
+// diagramEdges returns the edges to draw, in canonical node order: child
+// edges, join edges to parts A and B, fastener-to-join edges and drill
+// target edges. Edges to nodes missing from the graph are dropped.
+func diagramEdges(g *DesignGraph) []diagramEdge {
+	var edges []diagramEdge
+	seen := make(map[diagramEdge]bool)
+	add := func(e diagramEdge) {
+		if g.Nodes[e.from] == nil || g.Nodes[e.to] == nil || seen[e] {
+			return
+		}
+		seen[e] = true
+		edges = append(edges, e)
+	}
+	for _, n := range g.SortedNodes() {
+		for _, cid := range n.Children {
+			add(diagramEdge{from: n.ID, to: cid})
+		}
+		switch d := n.Data.(type) {
+		case JoinData:
+			add(diagramEdge{from: n.ID, to: d.PartA, label: "A: " + string(d.FaceA)})
+			add(diagramEdge{from: n.ID, to: d.PartB, label: "B: " + string(d.FaceB)})
+			for _, fid := range d.Fasteners {
+				add(diagramEdge{from: fid, to: n.ID, label: "fastens"})
+			}
+		case FastenerData:
+			add(diagramEdge{from: n.ID, to: d.JoinRef, label: "fastens"})
+		case DrillData:
+			add(diagramEdge{from: n.ID, to: d.TargetPart, label: "drills " + string(d.Face)})
+		}
+	}
+	return edges
+}
+
+// diagramLabel returns the lines of a node's label: its kind, its name and
+// a short summary of its data.
+func diagramLabel(n *Node) []string {
+	lines := []string{n.Kind.String()}
+	if n.Name != "" {
+		lines = append(lines, n.Name)
+	} else {
+		lines = append(lines, n.ID.Short())
+	}
+	switch d := n.Data.(type) {
+	case BoardData:
+		lines = append(lines, fmt.Sprintf("%g × %g × %g", d.Dimensions.X, d.Dimensions.Y, d.Dimensions.Z))
+	case DowelData:
+		lines = append(lines, fmt.Sprintf("Ø%g × %g", d.Diameter, d.Length))
+	case TransformData:
+		if d.Translation != nil {
+			lines = append(lines, fmt.Sprintf("at %g, %g, %g", d.Translation.X, d.Translation.Y, d.Translation.Z))
+		}
+		if d.Rotation != nil {
+			lines = append(lines, fmt.Sprintf("rotate %g, %g, %g", d.Rotation.X, d.Rotation.Y, d.Rotation.Z))
+		}
+	case JoinData:
+		lines[0] = d.Kind.String() + " join"
+	case FastenerData:
+		lines[0] = d.Kind.String()
+		lines = append(lines, fmt.Sprintf("Ø%g × %g", d.Diameter, d.Length))
+	case DrillData:
+		lines = append(lines, fmt.Sprintf("Ø%g", d.Diameter))
+	}
+	return lines
+}
+
+// findingSeverity returns the severity of the worst finding for each node.
+func findingSeverity(opts ExportOptions) map[NodeID]ValidationSeverity {
+	sev := make(map[NodeID]ValidationSeverity)
+	if opts.Findings == nil {
+		return sev
+	}
+	for _, w := range opts.Findings.Warnings {
+		if !w.NodeID.IsZero() {
+			sev[w.NodeID] = SeverityWarning
+		}
+	}
+	for _, e := range opts.Findings.Errors {
+		if !e.NodeID.IsZero() {
+			sev[e.NodeID] = SeverityError
+		}
+	}
+	return sev
+}
+
+// diagramID returns the identifier of a node in exported diagrams.
+func diagramID(id NodeID) string {
+	return "n" + id.Short()
+}
+
+// ---------------------------------------------------------------------------
+// Graphviz DOT
+// ---------------------------------------------------------------------------
+
+var dotShapes = map[NodeKind]string{
+	NodePrimitive: "box",
+	NodeTransform: "ellipse",
+	NodeGroup:     "folder",
+	NodeJoin:      "diamond",
+	NodeFastener:  "hexagon",
+	NodeDrill:     "circle",
+}
+
+// ExportDOT renders the graph in Graphviz DOT format. Child edges are
+// solid, join edges dashed, and fastener and drill edges dotted. The
+// graph's fingerprint, if set, is written as a comment.
+func ExportDOT(g *DesignGraph, opts ExportOptions) string {
+	sev := findingSeverity(opts)
+	var b strings.Builder
+
+	b.WriteString("digraph design {\n")
+	if g.Fingerprint != nil {
+		fmt.Fprintf(&b, "  // %s\n", g.Fingerprint)
+	}
+	b.WriteString("  rankdir=TB;\n")
+	b.WriteString("  node [fontname=\"Helvetica\", fontsize=10];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=9];\n")
+	for _, n := range g.SortedNodes() {
+		label := strings.Join(diagramLabel(n), "\n")
+		attrs := fmt.Sprintf("label=%s, shape=%s", dotQuote(label), dotShapes[n.Kind])
+		switch s, ok := sev[n.ID]; {
+		case ok && s == SeverityError:
+			attrs += `, style=filled, fillcolor="#f8d7da", color="#c0392b"`
+		case ok && s == SeverityWarning:
+			attrs += `, style=filled, fillcolor="#fff3cd", color="#d68910"`
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", diagramID(n.ID), attrs)
+	}
+	for _, e := range diagramEdges(g) {
+		switch {
+		case e.label == "":
+			fmt.Fprintf(&b, "  %s -> %s;\n", diagramID(e.from), diagramID(e.to))
+		case g.Nodes[e.from].Kind == NodeJoin:
+			fmt.Fprintf(&b, "  %s -> %s [label=%s, style=dashed];\n", diagramID(e.from), diagramID(e.to), dotQuote(e.label))
+		default:
+			fmt.Fprintf(&b, "  %s -> %s [label=%s, style=dotted];\n", diagramID(e.from), diagramID(e.to), dotQuote(e.label))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote returns s as a quoted DOT string.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+// ---------------------------------------------------------------------------
+// Mermaid
+// ---------------------------------------------------------------------------
+
+var mermaidShapes = map[NodeKind][2]string{
+	NodePrimitive: {"[", "]"},
+	NodeTransform: {"(", ")"},
+	NodeGroup:     {"[[", "]]"},
+	NodeJoin:      {"{", "}"},
+	NodeFastener:  {"{{", "}}"},
+	NodeDrill:     {"((", "))"},
+}
+
+// ExportMermaid renders the graph as a Mermaid flowchart, suitable for
+// embedding in Markdown. Child edges are solid, the others dotted. The
+// graph's fingerprint, if set, is written as a comment.
+func ExportMermaid(g *DesignGraph, opts ExportOptions) string {
+	sev := findingSeverity(opts)
+	var b strings.Builder
+
+	b.WriteString("flowchart TD\n")
+	if g.Fingerprint != nil {
+		fmt.Fprintf(&b, "  %%%% %s\n", g.Fingerprint)
+	}
+	var errNodes, warnNodes []string
+	for _, n := range g.SortedNodes() {
+		shape := mermaidShapes[n.Kind]
+		label := strings.Join(diagramLabel(n), "<br/>")
+		fmt.Fprintf(&b, "  %s%s%s%s\n", diagramID(n.ID), shape[0], mermaidQuote(label), shape[1])
+		if s, ok := sev[n.ID]; ok {
+			if s == SeverityError {
+				errNodes = append(errNodes, diagramID(n.ID))
+			} else {
+				warnNodes = append(warnNodes, diagramID(n.ID))
+			}
+		}
+	}
+	for _, e := range diagramEdges(g) {
+		if e.label == "" {
+			fmt.Fprintf(&b, "  %s --> %s\n", diagramID(e.from), diagramID(e.to))
+		} else {
+			fmt.Fprintf(&b, "  %s -. %s .-> %s\n", diagramID(e.from), mermaidQuote(e.label), diagramID(e.to))
+		}
+	}
+	if len(errNodes) > 0 {
+		b.WriteString("  classDef error fill:#f8d7da,stroke:#c0392b\n")
+		fmt.Fprintf(&b, "  class %s error\n", strings.Join(errNodes, ","))
+	}
+	if len(warnNodes) > 0 {
+		b.WriteString("  classDef warning fill:#fff3cd,stroke:#d68910\n")
+		fmt.Fprintf(&b, "  class %s warning\n", strings.Join(warnNodes, ","))
+	}
+	return b.String()
+}
+
+// mermaidQuote returns s as a quoted Mermaid label. Mermaid has no escape
+// for double quotes inside labels other than its #quot; entity.
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
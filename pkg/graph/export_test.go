@@ -0,0 +1,99 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportDOT(t *testing.T) {
+	g := traverseTestGraph()
+	side := diagramID(NewNodeID("side"))
+	shelf := diagramID(NewNodeID("shelf"))
+	join := diagramID(NewNodeID("join"))
+	screw := diagramID(NewNodeID("screw"))
+	left := diagramID(NewNodeID("place/left"))
+	drill := diagramID(NewNodeID("drill"))
+
+	out := ExportDOT(g, ExportOptions{})
+	for _, want := range []string{
+		"digraph design {",
+		side + ` [label="primitive\nside\n19 × 720 × 300", shape=box];`,
+		join + ` [label="butt join\njoin", shape=diamond];`,
+		left + " -> " + side + ";",
+		join + " -> " + shelf + ` [label="A: left", style=dashed];`,
+		join + " -> " + side + ` [label="B: right", style=dashed];`,
+		screw + " -> " + join + ` [label="fastens", style=dotted];`,
+		drill + " -> " + shelf + ` [label="drills top", style=dotted];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, screw+" -> "+join) != 1 {
+		t.Error("fastener edge should appear once")
+	}
+	if ExportDOT(g, ExportOptions{}) != out {
+		t.Error("DOT output is not deterministic")
+	}
+}
+
+func TestExportMermaid(t *testing.T) {
+	g := traverseTestGraph()
+	side := diagramID(NewNodeID("side"))
+	join := diagramID(NewNodeID("join"))
+	cabinet := diagramID(NewNodeID("cabinet"))
+
+	out := ExportMermaid(g, ExportOptions{})
+	for _, want := range []string{
+		"flowchart TD",
+		side + `["primitive<br/>side<br/>19 × 720 × 300"]`,
+		join + `{"butt join<br/>join"}`,
+		cabinet + `[["group<br/>cabinet"]]`,
+		join + ` -. "B: right" .-> ` + side,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Mermaid output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "classDef") {
+		t.Error("no classes expected without findings")
+	}
+}
+
+func TestExportFindingsColorNodes(t *testing.T) {
+	g := traverseTestGraph()
+	side := NewNodeID("side")
+	shelf := NewNodeID("shelf")
+	findings := &ValidationResult{
+		Errors:   []ValidationError{{NodeID: side, Message: "bad", Severity: SeverityError}},
+		Warnings: []ValidationWarning{{NodeID: shelf, Message: "hmm"}, {NodeID: side, Message: "also"}},
+	}
+	opts := ExportOptions{Findings: findings}
+
+	dot := ExportDOT(g, opts)
+	if !strings.Contains(dot, diagramID(side)+` [label="primitive\nside\n19 × 720 × 300", shape=box, style=filled, fillcolor="#f8d7da"`) {
+		t.Errorf("expected side to be colored as an error:\n%s", dot)
+	}
+	if !strings.Contains(dot, diagramID(shelf)+` [label="primitive\nshelf\n562 × 19 × 300", shape=box, style=filled, fillcolor="#fff3cd"`) {
+		t.Errorf("expected shelf to be colored as a warning:\n%s", dot)
+	}
+
+	mm := ExportMermaid(g, opts)
+	if !strings.Contains(mm, "class "+diagramID(side)+" error") {
+		t.Errorf("expected side in the error class:\n%s", mm)
+	}
+	if !strings.Contains(mm, "class "+diagramID(shelf)+" warning") {
+		t.Errorf("expected shelf in the warning class:\n%s", mm)
+	}
+}
+
+func TestExportEscapesLabels(t *testing.T) {
+	g := New()
+	g.AddNode(&Node{ID: NewNodeID("q"), Kind: NodeGroup, Name: `say "hi"\`, Data: GroupData{}})
+	if dot := ExportDOT(g, ExportOptions{}); !strings.Contains(dot, `label="group\nsay \"hi\"\\"`) {
+		t.Errorf("DOT label not escaped:\n%s", dot)
+	}
+	if mm := ExportMermaid(g, ExportOptions{}); !strings.Contains(mm, `"group<br/>say #quot;hi#quot;\"`) {
+		t.Errorf("Mermaid label not escaped:\n%s", mm)
+	}
+}
@@ -0,0 +1,230 @@
+package graph
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Design fingerprint
+// ---------------------------------------------------------------------------
+
+// Fingerprint identifies the design an output was produced from: the
+// evaluated graph, the source text it was evaluated from and the Lignin
+// version that did the evaluating. Exports and reports carry it so a file
+// found later can be checked against the current source.
+type Fingerprint struct {
+	Version string      // Lignin version, e.g. "0.1.0"
+	Variant string      // design variant, empty for the base design
+	Params  string      // parameter overrides, as written by FormatParams
+	Graph   ContentHash // CanonicalHash of the evaluated graph
+	Source  ContentHash // SourceHash of the source text
+}
+
+// fingerprintPrefix starts every encoded fingerprint. The trailing digit is
+// the version of the encoding itself.
+const fingerprintPrefix = "lignin-fp/1"
+
+// fingerprintPattern matches an encoded fingerprint anywhere in a text.
+var fingerprintPattern = regexp.MustCompile(
+	`lignin-fp/1 version=(\S+) (?:variant=(\S+) )?(?:params=(\S+) )?graph=([0-9a-f]{64}) source=([0-9a-f]{64})`)
+
+// NewFingerprint returns the fingerprint of g evaluated from source by the
+// given Lignin version, with the given parameter overrides.
+func NewFingerprint(g *DesignGraph, source, version string, overrides map[string]float64) Fingerprint {
+	return Fingerprint{
+		Version: version,
+		Variant: g.Variant,
+		Params:  FormatParams(overrides),
+		Graph:   g.CanonicalHash(),
+		Source:  SourceHash(source),
+	}
+}
+
+// String encodes the fingerprint on a single line, e.g.
+//
+//	lignin-fp/1 version=0.1.0 variant=tall params=depth=300,width=1000 graph=3f2a… source=9be0…
+//
+// The variant is omitted for the base design and the parameters when none
+// were overridden. The version and variant are path-escaped so the
+// encoding never contains spaces of its own.
+func (f Fingerprint) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s version=%s ", fingerprintPrefix, url.PathEscape(f.Version))
+	if f.Variant != "" {
+		fmt.Fprintf(&b, "variant=%s ", url.PathEscape(f.Variant))
+	}
+	if f.Params != "" {
+		fmt.Fprintf(&b, "params=%s ", f.Params)
+	}
+	fmt.Fprintf(&b, "graph=%x source=%x", f.Graph[:], f.Source[:])
+	return b.String()
+}
+
+// Short returns an abbreviated form for display, e.g. "3f2a9c0d/9be01f77".
+func (f Fingerprint) Short() string {
+	return fmt.Sprintf("%x/%x", f.Graph[:4], f.Source[:4])
+}
+
+// MarshalText encodes the fingerprint as String does.
+func (f Fingerprint) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText decodes a fingerprint written by MarshalText.
+func (f *Fingerprint) UnmarshalText(text []byte) error {
+	p, err := ParseFingerprint(string(text))
+	if err != nil {
+		return err
+	}
+	*f = p
+	return nil
+}
+
+// ParseFingerprint decodes a fingerprint written by Fingerprint.String.
+func ParseFingerprint(s string) (Fingerprint, error) {
+	s = strings.TrimSpace(s)
+	m := fingerprintPattern.FindStringSubmatch(s)
+	if m == nil || m[0] != s {
+		return Fingerprint{}, fmt.Errorf("fingerprint: malformed fingerprint %q", s)
+	}
+	return fingerprintFromMatch(m)
+}
+
+// FindFingerprint returns the first fingerprint stamped anywhere in text,
+// whatever the surrounding format: a comment line in a diagram, a field in
+// JSON or a line in a Markdown report.
+func FindFingerprint(text string) (Fingerprint, bool) {
+	m := fingerprintPattern.FindStringSubmatch(text)
+	if m == nil {
+		return Fingerprint{}, false
+	}
+	f, err := fingerprintFromMatch(m)
+	return f, err == nil
+}
+
+func fingerprintFromMatch(m []string) (Fingerprint, error) {
+	var f Fingerprint
+	var err error
+	if f.Version, err = url.PathUnescape(m[1]); err != nil {
+		return Fingerprint{}, fmt.Errorf("fingerprint: bad version %q: %w", m[1], err)
+	}
+	if f.Variant, err = url.PathUnescape(m[2]); err != nil {
+		return Fingerprint{}, fmt.Errorf("fingerprint: bad variant %q: %w", m[2], err)
+	}
+	if _, err := ParseParams(m[3]); err != nil {
+		return Fingerprint{}, err
+	}
+	f.Params = m[3]
+	if err := decodeHash(f.Graph[:], []byte(m[4]), "graph hash"); err != nil {
+		return Fingerprint{}, err
+	}
+	if err := decodeHash(f.Source[:], []byte(m[5]), "source hash"); err != nil {
+		return Fingerprint{}, err
+	}
+	return f, nil
+}
+
+// Overrides returns the parameter overrides the fingerprinted graph was
+// evaluated with, nil if there were none.
+func (f Fingerprint) Overrides() map[string]float64 {
+	overrides, _ := ParseParams(f.Params) // checked when parsed
+	return overrides
+}
+
+// FormatParams encodes parameter overrides as name=value pairs sorted by
+// name and separated by commas, such as "depth=300,width=1000". Names are
+// path-escaped, so they never contain commas or spaces.
+func FormatParams(overrides map[string]float64) string {
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = url.PathEscape(name) + "=" + strconv.FormatFloat(overrides[name], 'g', -1, 64)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// ParseParams decodes parameter overrides written by FormatParams.
+func ParseParams(s string) (map[string]float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	overrides := make(map[string]float64)
+	for _, pair := range strings.Split(s, ",") {
+		i := strings.LastIndex(pair, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("fingerprint: bad parameter %q", pair)
+		}
+		name, err := url.PathUnescape(pair[:i])
+		if err != nil {
+			return nil, fmt.Errorf("fingerprint: bad parameter name %q: %w", pair[:i], err)
+		}
+		v, err := strconv.ParseFloat(pair[i+1:], 64)
+		if err != nil {
+			return nil, fmt.Errorf("fingerprint: bad value for parameter %q: %w", name, err)
+		}
+		overrides[name] = v
+	}
+	return overrides, nil
+}
+
+// SourceHash returns the hash of a design's source text. Line endings are
+// normalized first so a checkout on Windows hashes the same.
+func SourceHash(source string) ContentHash {
+	return sha256.Sum256([]byte(strings.ReplaceAll(source, "\r\n", "\n")))
+}
+
+// CanonicalHash returns a hash of the graph's content that does not depend
+// on map iteration order or on where forms sit in the source: each node
+// contributes its ID and ContentHash in ID order, followed by the roots,
+// the defaults, the stock and the variant. The Fingerprint field is not
+// included.
+func (g *DesignGraph) CanonicalHash() ContentHash {
+	h := sha256.New()
+	ids := make([]NodeID, 0, len(g.Nodes))
+	for id := range g.Nodes {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, compareIDs)
+	for _, id := range ids {
+		ch := g.Nodes[id].ComputeContentHash()
+		h.Write(id[:])
+		h.Write(ch[:])
+	}
+
+	roots := slices.Clone(g.Roots)
+	slices.SortFunc(roots, compareIDs)
+	for _, id := range roots {
+		h.Write(id[:])
+	}
+
+	// GlobalDefaults holds only plain fields, so encoding cannot fail.
+	defaults, _ := json.Marshal(g.Defaults)
+	h.Write(defaults)
+	for _, st := range g.Stock {
+		st.Source = SourceRef{} // like nodes, stock may move in the source
+		b, _ := json.Marshal(st)
+		h.Write(b)
+	}
+	h.Write([]byte(g.Variant))
+
+	var sum ContentHash
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+func compareIDs(a, b NodeID) int {
+	return bytes.Compare(a[:], b[:])
+}
@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalHashIgnoresMapOrderAndSource(t *testing.T) {
+	a := jsonTestGraph()
+	want := a.CanonicalHash()
+	for i := 0; i < 20; i++ {
+		if got := jsonTestGraph().CanonicalHash(); got != want {
+			t.Fatal("canonical hash is not stable across builds of the same graph")
+		}
+	}
+
+	b := jsonTestGraph()
+	side := b.MustLookup("side")
+	side.Source = SourceRef{Line: 40, Col: 2}
+	b.AddNode(side)
+	b.Roots = append(b.Roots[1:], b.Roots[0])
+	if b.CanonicalHash() != want {
+		t.Error("moving forms should not change the canonical hash")
+	}
+
+	bd := side.Data.(BoardData)
+	bd.Dimensions.Y++
+	side.Data = bd
+	b.AddNode(side)
+	if b.CanonicalHash() == want {
+		t.Error("changing a dimension should change the canonical hash")
+	}
+
+	c := jsonTestGraph()
+	c.Variant = "wide"
+	if c.CanonicalHash() == want {
+		t.Error("the variant should be part of the canonical hash")
+	}
+
+	d := jsonTestGraph()
+	d.Stock = []Stock{{Name: "oak-4/4", Thickness: 22, Width: 150, Length: 2400, Quantity: 2, Source: SourceRef{Line: 1, Col: 1}}}
+	withStock := d.CanonicalHash()
+	if withStock == want {
+		t.Error("stock should be part of the canonical hash")
+	}
+	d.Stock[0].Source = SourceRef{Line: 9, Col: 1}
+	if d.CanonicalHash() != withStock {
+		t.Error("moving a stock declaration should not change the canonical hash")
+	}
+}
+
+func TestFingerprintRoundTrip(t *testing.T) {
+	g := jsonTestGraph()
+	g.Variant = "extra tall"
+	fp := NewFingerprint(g, "(defpart \"side\")\r\n", "1.2.0", map[string]float64{"width": 1000, "shelf gap": 250.5})
+	if fp.Source != SourceHash("(defpart \"side\")\n") {
+		t.Error("source hash should not depend on line endings")
+	}
+
+	s := fp.String()
+	if !strings.HasPrefix(s, "lignin-fp/1 version=1.2.0 variant=extra%20tall params=shelf%20gap=250.5,width=1000 graph=") {
+		t.Errorf("unexpected encoding %q", s)
+	}
+	got, err := ParseFingerprint(s)
+	if err != nil {
+		t.Fatalf("ParseFingerprint: %v", err)
+	}
+	if got != fp {
+		t.Errorf("round trip = %+v, want %+v", got, fp)
+	}
+	if o := got.Overrides(); len(o) != 2 || o["width"] != 1000 || o["shelf gap"] != 250.5 {
+		t.Errorf("overrides = %v", o)
+	}
+
+	found, ok := FindFingerprint("# Cut list\n\nFingerprint: `" + s + "`\n")
+	if !ok || found != fp {
+		t.Errorf("FindFingerprint = %+v, %v", found, ok)
+	}
+	if _, ok := FindFingerprint("no stamp here"); ok {
+		t.Error("found a fingerprint in unstamped text")
+	}
+	if _, err := ParseFingerprint("lignin-fp/1 version=1 graph=zz source=zz"); err == nil {
+		t.Error("expected an error for a malformed fingerprint")
+	}
+}
+
+func TestFingerprintStampedIntoOutputs(t *testing.T) {
+	g := jsonTestGraph()
+	fp := NewFingerprint(g, "source", "1.2.0", nil)
+	g.Fingerprint = &fp
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var back DesignGraph
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if back.Fingerprint == nil || *back.Fingerprint != fp {
+		t.Errorf("JSON fingerprint = %v, want %v", back.Fingerprint, fp)
+	}
+	if back.CanonicalHash() != fp.Graph {
+		t.Error("decoded graph should hash to the stamped graph hash")
+	}
+
+	old := jsonTestGraph()
+	oldFP := NewFingerprint(old, "older source", "1.1.0", nil)
+	old.Fingerprint = &oldFP
+
+	for name, out := range map[string]string{
+		"json":    string(data),
+		"dot":     ExportDOT(g, ExportOptions{}),
+		"mermaid": ExportMermaid(g, ExportOptions{}),
+		"diff":    Diff(old, g).Report(),
+	} {
+		if got, ok := FindFingerprint(out); !ok || got != fp {
+			t.Errorf("%s output: found %+v, %v; want %+v", name, got, ok, fp)
+		}
+	}
+}
@@ -1,25 +1,90 @@
 package graph
 
-import "fmt"
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
 
 // DefaultClearance is the default joint clearance in mm.
 const DefaultClearance = 0.25
 
+// UnitScale converts millimetres, the graph's internal unit, to the units
+// a design may be reported in.
+var UnitScale = map[string]float64{
+	"mm": 1,
+	"cm": 0.1,
+	"m":  0.001,
+	"in": 1 / 25.4,
+}
+
 // GlobalDefaults contains graph-wide default settings.
 type GlobalDefaults struct {
 	Clearance float64      `json:"clearance"` // default joint clearance mm
 	Material  MaterialSpec `json:"material"`  // default material for new parts
-	Units     string       `json:"units"`     // "mm" (only option for MVP)
+	Units     string       `json:"units"`     // units reports are written in, a key of UnitScale
+
+	// FastenerRules overrides DefaultFastenerRules, keyed by fastener kind
+	// name such as "screw".
+	FastenerRules map[string]FastenerRule `json:"fastener_rules,omitempty"`
+
+	// Movement overrides DefaultMovement for the wood movement check.
+	Movement *MovementSettings `json:"movement,omitempty"`
+
+	// Milling is the allowance parts are cut with from rough lumber.
+	Milling *MillingAllowance `json:"milling,omitempty"`
+}
+
+// MovementSettings returns the wood movement settings in force.
+func (d GlobalDefaults) MovementSettings() MovementSettings {
+	if d.Movement != nil {
+		return *d.Movement
+	}
+	return DefaultMovement
+}
+
+// FastenerRule returns the embedment rule for a fastener kind, preferring
+// an override in d. The boolean is false if the kind has no rule.
+func (d GlobalDefaults) FastenerRule(kind FastenerKind) (FastenerRule, bool) {
+	if r, ok := d.FastenerRules[kind.String()]; ok {
+		return r, true
+	}
+	r, ok := DefaultFastenerRules[kind]
+	return r, ok
 }
 
 // DesignGraph is the top-level immutable data structure produced by Lisp evaluation.
 // It is never mutated in place; each evaluation produces a new graph.
 type DesignGraph struct {
-	Nodes     map[NodeID]*Node   `json:"nodes"`
-	Roots     []NodeID           `json:"roots"`
-	NameIndex map[string]NodeID  `json:"name_index"`
-	Defaults  GlobalDefaults     `json:"defaults"`
-	Version   uint64             `json:"version"`
+	Nodes     map[NodeID]*Node  `json:"nodes"`
+	Roots     []NodeID          `json:"roots"`
+	NameIndex map[string]NodeID `json:"name_index"`
+	Defaults  GlobalDefaults    `json:"defaults"`
+	Version   uint64            `json:"version"`
+
+	// Variant is the name of the design variant this graph was evaluated
+	// for, or empty for the base design. Outputs derived from the graph
+	// carry it as a label.
+	Variant string `json:"variant,omitempty"`
+
+	// Fingerprint identifies the source and Lignin version the graph was
+	// evaluated from. It is set by the engine after a successful evaluation
+	// and stamped into every output derived from the graph.
+	Fingerprint *Fingerprint `json:"fingerprint,omitempty"`
+
+	// Stock lists the declared stock boards, in declaration order.
+	Stock []Stock `json:"stock,omitempty"`
+
+	// Species is the species table material checks use, or nil for the
+	// built-in table. It is configuration rather than design and is not
+	// serialized.
+	Species SpeciesTable `json:"-"`
+
+	// index caches reverse edges for Parents, Referrers and Ancestors. It
+	// is built on first use and replaced by AddNode.
+	index *indexCache
 }
 
 // New creates an empty DesignGraph with default settings.
@@ -27,6 +92,7 @@ func New() *DesignGraph {
 	return &DesignGraph{
 		Nodes:     make(map[NodeID]*Node),
 		NameIndex: make(map[string]NodeID),
+		index:     new(indexCache),
 		Defaults: GlobalDefaults{
 			Clearance: DefaultClearance,
 			Units:     "mm",
@@ -34,8 +100,20 @@ func New() *DesignGraph {
 	}
 }
 
-// AddNode adds a node to the graph. It does not check for duplicates.
+// LookupSpecies returns the properties of the named species from the
+// graph's species table.
+func (g *DesignGraph) LookupSpecies(name string) (Species, bool) {
+	if g.Species == nil {
+		return builtinSpecies.Lookup(name)
+	}
+	return g.Species.Lookup(name)
+}
+
+// AddNode adds a node to the graph and sets its ContentHash. It does not
+// check for duplicates.
 func (g *DesignGraph) AddNode(n *Node) {
+	n.ContentHash = n.ComputeContentHash()
+	g.index = new(indexCache)
 	g.Nodes[n.ID] = n
 	if n.Name != "" {
 		g.NameIndex[n.Name] = n.ID
@@ -47,6 +125,11 @@ func (g *DesignGraph) AddRoot(id NodeID) {
 	g.Roots = append(g.Roots, id)
 }
 
+// RemoveRoot unregisters a node ID as a root of the graph.
+func (g *DesignGraph) RemoveRoot(id NodeID) {
+	g.Roots = slices.DeleteFunc(g.Roots, func(r NodeID) bool { return r == id })
+}
+
 // Lookup returns the node with the given user-assigned name, or nil.
 func (g *DesignGraph) Lookup(name string) *Node {
 	id, ok := g.NameIndex[name]
@@ -70,10 +153,38 @@ func (g *DesignGraph) Get(id NodeID) *Node {
 	return g.Nodes[id]
 }
 
-// Parts returns all primitive nodes in the graph.
+// CompareNodes orders nodes canonically: by source location (file, line,
+// column), then by ID. It returns a negative number if a sorts before b,
+// a positive number if after, and zero if they are the same node.
+func CompareNodes(a, b *Node) int {
+	if c := strings.Compare(a.Source.File, b.Source.File); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Source.Line, b.Source.Line); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Source.Col, b.Source.Col); c != 0 {
+		return c
+	}
+	return bytes.Compare(a.ID[:], b.ID[:])
+}
+
+// SortedNodes returns all nodes in canonical order (see CompareNodes).
+// Everything that iterates over the graph uses this order, so identical
+// source yields identical meshes, colors and diagnostics.
+func (g *DesignGraph) SortedNodes() []*Node {
+	nodes := make([]*Node, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		nodes = append(nodes, n)
+	}
+	slices.SortFunc(nodes, CompareNodes)
+	return nodes
+}
+
+// Parts returns all primitive nodes in the graph, in canonical order.
 func (g *DesignGraph) Parts() []*Node {
 	var parts []*Node
-	for _, n := range g.Nodes {
+	for _, n := range g.SortedNodes() {
 		if n.Kind == NodePrimitive {
 			parts = append(parts, n)
 		}
@@ -81,10 +192,10 @@ func (g *DesignGraph) Parts() []*Node {
 	return parts
 }
 
-// Joins returns all join nodes in the graph.
+// Joins returns all join nodes in the graph, in canonical order.
 func (g *DesignGraph) Joins() []*Node {
 	var joins []*Node
-	for _, n := range g.Nodes {
+	for _, n := range g.SortedNodes() {
 		if n.Kind == NodeJoin {
 			joins = append(joins, n)
 		}
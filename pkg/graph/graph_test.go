@@ -1,6 +1,10 @@
 package graph
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
 
 func TestNewDesignGraph(t *testing.T) {
 	g := New()
@@ -235,3 +239,74 @@ func TestStringers(t *testing.T) {
 		t.Errorf("Vec3.String() = %q", v.String())
 	}
 }
+
+func TestSortedNodesCanonicalOrder(t *testing.T) {
+	g := New()
+	mk := func(name string, line, col int) *Node {
+		return &Node{
+			ID:     NewNodeID(name),
+			Kind:   NodePrimitive,
+			Name:   name,
+			Source: SourceRef{Line: line, Col: col},
+			Data:   BoardData{Dimensions: Vec3{1, 1, 1}},
+		}
+	}
+	for _, n := range []*Node{mk("d", 3, 5), mk("a", 1, 1), mk("c", 3, 1), mk("b", 2, 1)} {
+		g.AddNode(n)
+	}
+	// Two nodes at the same location are ordered by ID.
+	x, y := mk("x", 4, 1), mk("y", 4, 1)
+	g.AddNode(x)
+	g.AddNode(y)
+	tie := []string{"x", "y"}
+	if CompareNodes(y, x) < 0 {
+		tie = []string{"y", "x"}
+	}
+	want := append([]string{"a", "b", "c", "d"}, tie...)
+
+	for run := 0; run < 10; run++ {
+		var got []string
+		for _, n := range g.Parts() {
+			got = append(got, n.Name)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("run %d: got %v, want %v", run, got, want)
+			}
+		}
+	}
+}
+
+func TestValidationOrderDeterministic(t *testing.T) {
+	g := New()
+	for i := 0; i < 8; i++ {
+		name := fmt.Sprintf("orphan-%d", i)
+		g.AddNode(&Node{
+			ID:     NewNodeID(name),
+			Kind:   NodePrimitive,
+			Name:   name,
+			Source: SourceRef{Line: i + 1, Col: 1},
+			Data:   BoardData{Dimensions: Vec3{1, 1, 1}},
+		})
+	}
+	g.AddRoot(NewNodeID("orphan-0"))
+
+	first := ValidateAll(g).Warnings
+	if len(first) != 7 {
+		t.Fatalf("expected 7 orphan warnings, got %d", len(first))
+	}
+	if !strings.Contains(first[0].Message, "orphan-1") || !strings.Contains(first[6].Message, "orphan-7") {
+		t.Errorf("warnings not in source order: %v", first)
+	}
+	for run := 0; run < 10; run++ {
+		again := ValidateAll(g).Warnings
+		for i := range first {
+			if again[i] != first[i] {
+				t.Fatalf("run %d: warning %d = %v, want %v", run, i, again[i], first[i])
+			}
+		}
+	}
+}
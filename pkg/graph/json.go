@@ -0,0 +1,299 @@
+package graph
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// SchemaVersion is the version of the JSON encoding of a DesignGraph. It is
+// written with every encoded graph and must be bumped whenever the encoding
+// changes incompatibly.
+const SchemaVersion = 1
+
+// ---------------------------------------------------------------------------
+// Hashes
+// ---------------------------------------------------------------------------
+
+// MarshalText encodes the ID as lowercase hex, which also lets NodeIDs be
+// used as JSON object keys.
+func (id NodeID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText decodes an ID written by MarshalText.
+func (id *NodeID) UnmarshalText(text []byte) error {
+	return decodeHash(id[:], text, "node ID")
+}
+
+// MarshalText encodes the hash as lowercase hex.
+func (h ContentHash) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(h[:])), nil
+}
+
+// UnmarshalText decodes a hash written by MarshalText.
+func (h *ContentHash) UnmarshalText(text []byte) error {
+	return decodeHash(h[:], text, "content hash")
+}
+
+func decodeHash(dst []byte, text []byte, what string) error {
+	if hex.DecodedLen(len(text)) != len(dst) {
+		return fmt.Errorf("invalid %s %q: expected %d hex digits", what, text, 2*len(dst))
+	}
+	if _, err := hex.Decode(dst, text); err != nil {
+		return fmt.Errorf("invalid %s %q: %w", what, text, err)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Type tags
+// ---------------------------------------------------------------------------
+
+// nodeDataType returns the tag identifying the concrete type of d in JSON.
+func nodeDataType(d NodeData) (string, error) {
+	switch d.(type) {
+	case BoardData:
+		return "board", nil
+	case DowelData:
+		return "dowel", nil
+	case TransformData:
+		return "transform", nil
+	case GroupData:
+		return "group", nil
+	case JoinData:
+		return "join", nil
+	case DrillData:
+		return "drill", nil
+	case FastenerData:
+		return "fastener", nil
+	}
+	return "", fmt.Errorf("unsupported node data type %T", d)
+}
+
+// decodeNodeData decodes raw into the node data type named by tag.
+func decodeNodeData(tag string, raw json.RawMessage) (NodeData, error) {
+	var err error
+	switch tag {
+	case "board":
+		var d BoardData
+		err = json.Unmarshal(raw, &d)
+		return d, err
+	case "dowel":
+		var d DowelData
+		err = json.Unmarshal(raw, &d)
+		return d, err
+	case "transform":
+		var d TransformData
+		err = json.Unmarshal(raw, &d)
+		return d, err
+	case "group":
+		var d GroupData
+		err = json.Unmarshal(raw, &d)
+		return d, err
+	case "join":
+		var d JoinData
+		err = json.Unmarshal(raw, &d)
+		return d, err
+	case "drill":
+		var d DrillData
+		err = json.Unmarshal(raw, &d)
+		return d, err
+	case "fastener":
+		var d FastenerData
+		err = json.Unmarshal(raw, &d)
+		return d, err
+	}
+	return nil, fmt.Errorf("unknown node data type %q", tag)
+}
+
+// joinParamsType returns the tag identifying the concrete type of p in JSON.
+func joinParamsType(p JoinParams) (string, error) {
+	switch p.(type) {
+	case ButtJoinParams:
+		return "butt", nil
+	}
+	return "", fmt.Errorf("unsupported join params type %T", p)
+}
+
+// decodeJoinParams decodes raw into the join params type named by tag.
+func decodeJoinParams(tag string, raw json.RawMessage) (JoinParams, error) {
+	switch tag {
+	case "butt":
+		var p ButtJoinParams
+		err := json.Unmarshal(raw, &p)
+		return p, err
+	}
+	return nil, fmt.Errorf("unknown join params type %q", tag)
+}
+
+// ---------------------------------------------------------------------------
+// Node
+// ---------------------------------------------------------------------------
+
+// nodeAlias has the fields of Node without its methods, so the JSON
+// wrappers below can reuse the default encoding for everything but Data.
+type nodeAlias Node
+
+// nodeJSON is the wire form of a Node. Its Data field shadows the interface
+// field of the embedded alias and carries the encoded payload; DataType
+// names the payload's concrete type.
+type nodeJSON struct {
+	*nodeAlias
+	DataType string          `json:"data_type,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+}
+
+// MarshalJSON encodes the node with a type tag for its data.
+func (n Node) MarshalJSON() ([]byte, error) {
+	out := nodeJSON{nodeAlias: (*nodeAlias)(&n)}
+	if n.Data != nil {
+		tag, err := nodeDataType(n.Data)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", n.ID.Short(), err)
+		}
+		raw, err := json.Marshal(n.Data)
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", n.ID.Short(), err)
+		}
+		out.DataType, out.Data = tag, raw
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a node written by MarshalJSON.
+func (n *Node) UnmarshalJSON(b []byte) error {
+	in := nodeJSON{nodeAlias: (*nodeAlias)(n)}
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	n.Data = nil
+	if in.DataType == "" {
+		if len(in.Data) > 0 && string(in.Data) != "null" {
+			return fmt.Errorf("node %s: data without data_type", n.ID.Short())
+		}
+		return nil
+	}
+	d, err := decodeNodeData(in.DataType, in.Data)
+	if err != nil {
+		return fmt.Errorf("node %s: %w", n.ID.Short(), err)
+	}
+	n.Data = d
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// JoinData
+// ---------------------------------------------------------------------------
+
+type joinDataAlias JoinData
+
+// joinDataJSON is the wire form of JoinData, tagging its Params the same
+// way nodeJSON tags node data.
+type joinDataJSON struct {
+	*joinDataAlias
+	ParamsType string          `json:"params_type,omitempty"`
+	Params     json.RawMessage `json:"params,omitempty"`
+}
+
+// MarshalJSON encodes the join with a type tag for its params.
+func (d JoinData) MarshalJSON() ([]byte, error) {
+	out := joinDataJSON{joinDataAlias: (*joinDataAlias)(&d)}
+	if d.Params != nil {
+		tag, err := joinParamsType(d.Params)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := json.Marshal(d.Params)
+		if err != nil {
+			return nil, err
+		}
+		out.ParamsType, out.Params = tag, raw
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a join written by MarshalJSON.
+func (d *JoinData) UnmarshalJSON(b []byte) error {
+	in := joinDataJSON{joinDataAlias: (*joinDataAlias)(d)}
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	d.Params = nil
+	if in.ParamsType == "" {
+		if len(in.Params) > 0 && string(in.Params) != "null" {
+			return fmt.Errorf("join params without params_type")
+		}
+		return nil
+	}
+	p, err := decodeJoinParams(in.ParamsType, in.Params)
+	if err != nil {
+		return err
+	}
+	d.Params = p
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// DesignGraph
+// ---------------------------------------------------------------------------
+
+type graphAlias DesignGraph
+
+// graphJSON is the wire form of a DesignGraph: the graph's fields plus the
+// schema version they were written with.
+type graphJSON struct {
+	SchemaVersion int `json:"schema_version"`
+	*graphAlias
+}
+
+// MarshalJSON encodes the graph together with SchemaVersion. The encoding
+// is lossless: UnmarshalJSON restores an identical graph.
+func (g DesignGraph) MarshalJSON() ([]byte, error) {
+	return json.Marshal(graphJSON{SchemaVersion: SchemaVersion, graphAlias: (*graphAlias)(&g)})
+}
+
+// UnmarshalJSON decodes a graph written by MarshalJSON. Graphs written with
+// a different schema version are rejected.
+func (g *DesignGraph) UnmarshalJSON(b []byte) error {
+	var probe struct {
+		SchemaVersion *int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(b, &probe); err != nil {
+		return err
+	}
+	if probe.SchemaVersion == nil {
+		return fmt.Errorf("design graph: missing schema_version")
+	}
+	if *probe.SchemaVersion != SchemaVersion {
+		return fmt.Errorf("design graph: unsupported schema version %d (want %d)", *probe.SchemaVersion, SchemaVersion)
+	}
+
+	*g = DesignGraph{}
+	if err := json.Unmarshal(b, &graphJSON{graphAlias: (*graphAlias)(g)}); err != nil {
+		return err
+	}
+	if g.Nodes == nil {
+		g.Nodes = make(map[NodeID]*Node)
+	}
+	if g.NameIndex == nil {
+		g.NameIndex = make(map[string]NodeID)
+	}
+	g.index = new(indexCache)
+	ids := make([]NodeID, 0, len(g.Nodes))
+	for id := range g.Nodes {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, compareIDs)
+	for _, id := range ids {
+		n := g.Nodes[id]
+		if n == nil {
+			return fmt.Errorf("design graph: node %s is null", id.Short())
+		}
+		if n.ID != id {
+			return fmt.Errorf("design graph: node stored under %s has ID %s", id.Short(), n.ID.Short())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package graph
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// jsonTestGraph builds a graph that uses every node data and join params type.
+func jsonTestGraph() *DesignGraph {
+	g := New()
+	g.Version = 7
+	g.Variant = "tall"
+
+	side := &Node{
+		ID:     NewNodeID("side"),
+		Kind:   NodePrimitive,
+		Name:   "side",
+		Source: SourceRef{Line: 1, Col: 1, FormID: "1:1"},
+		Data: BoardData{
+			PrimKind:   PrimBoard,
+			Dimensions: Vec3{19, 720, 300},
+			Grain:      AxisY,
+			Material:   MaterialSpec{Species: "white-oak", Thickness: 19, Grade: "FAS"},
+		},
+		Tags: []string{"visible-face", "primary-wood"},
+		Meta: map[string]string{"finish": "oil"},
+	}
+	pin := &Node{
+		ID:   NewNodeID("pin"),
+		Kind: NodePrimitive,
+		Name: "pin",
+		Data: DowelData{PrimKind: PrimDowel, Diameter: 8, Length: 40, Grain: AxisZ},
+	}
+	at := Vec3{0, 0, 19}
+	place := &Node{
+		ID:       NewNodeID("place/side"),
+		Kind:     NodeTransform,
+		Children: []NodeID{side.ID},
+		Data:     TransformData{Translation: &at},
+	}
+	screw := &Node{
+		ID:   NewNodeID("screw/_anon_1"),
+		Kind: NodeFastener,
+		Data: FastenerData{Kind: FastenerScrew, Diameter: 4, Length: 40, Position: Vec3{0, 0, 100}, PilotHoleDia: 2.5},
+	}
+	clearance := 0.5
+	join := &Node{
+		ID:   NewNodeID("butt-joint/_anon_2"),
+		Kind: NodeJoin,
+		Data: JoinData{
+			Kind: JoinButt, PartA: side.ID, FaceA: FaceTop, PartB: pin.ID, FaceB: FaceBottom,
+			Clearance: &clearance, Params: ButtJoinParams{GlueUp: true}, Fasteners: []NodeID{screw.ID},
+		},
+	}
+	cs := 8.0
+	drill := &Node{
+		ID:   NewNodeID("drill/_anon_3"),
+		Kind: NodeDrill,
+		Data: DrillData{TargetPart: side.ID, Face: FaceFront, Position: Vec3{10, 20, 0}, Diameter: 5, Countersink: &cs},
+	}
+	asm := &Node{
+		ID:       NewNodeID("cabinet"),
+		Kind:     NodeGroup,
+		Name:     "cabinet",
+		Children: []NodeID{place.ID, join.ID, drill.ID},
+		Data:     GroupData{Description: "carcass"},
+	}
+	for _, n := range []*Node{side, pin, place, screw, join, drill, asm} {
+		g.AddNode(n)
+	}
+	g.AddRoot(asm.ID)
+	return g
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	g := jsonTestGraph()
+
+	b, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var got DesignGraph
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(g, &got) {
+		t.Errorf("round trip changed the graph\nbefore: %+v\nafter:  %+v", g, &got)
+	}
+
+	// Encoding the decoded graph again must produce identical bytes.
+	b2, err := json.Marshal(&got)
+	if err != nil {
+		t.Fatalf("re-marshal: %v", err)
+	}
+	if string(b) != string(b2) {
+		t.Error("re-encoding the decoded graph produced different JSON")
+	}
+}
+
+func TestJSONTypeTags(t *testing.T) {
+	b, err := json.Marshal(jsonTestGraph())
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	s := string(b)
+	for _, want := range []string{
+		`"schema_version":1`,
+		`"data_type":"board"`,
+		`"data_type":"dowel"`,
+		`"data_type":"transform"`,
+		`"data_type":"group"`,
+		`"data_type":"join"`,
+		`"data_type":"drill"`,
+		`"data_type":"fastener"`,
+		`"params_type":"butt"`,
+		`"id":"` + NewNodeID("side").String() + `"`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("encoded graph does not contain %s", want)
+		}
+	}
+}
+
+func TestJSONRejectsBadInput(t *testing.T) {
+	id := NewNodeID("a").String()
+	other := NewNodeID("b").String()
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"missing version", `{"nodes":{}}`, "missing schema_version"},
+		{"future version", `{"schema_version":99,"nodes":{}}`, "unsupported schema version 99"},
+		{"unknown data type", `{"schema_version":1,"nodes":{"` + id + `":{"id":"` + id + `","kind":0,"data_type":"tenon","data":{}}}}`, `unknown node data type "tenon"`},
+		{"untagged data", `{"schema_version":1,"nodes":{"` + id + `":{"id":"` + id + `","kind":0,"data":{}}}}`, "data without data_type"},
+		{"mismatched key", `{"schema_version":1,"nodes":{"` + other + `":{"id":"` + id + `","kind":0}}}`, "has ID"},
+		{"bad node ID", `{"schema_version":1,"nodes":{"xyz":{"kind":0}}}`, "invalid node ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var g DesignGraph
+			err := json.Unmarshal([]byte(tt.json), &g)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not contain %q", err, tt.want)
+			}
+		})
+	}
+}
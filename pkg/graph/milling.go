@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Milling allowances and nominal lumber sizes
+// ---------------------------------------------------------------------------
+
+// MillingAllowance is the extra material, in mm, a part is cut with from
+// rough lumber before it is milled to its finished dimensions.
+type MillingAllowance struct {
+	Length    float64 `json:"length,omitempty"`
+	Width     float64 `json:"width,omitempty"`
+	Thickness float64 `json:"thickness,omitempty"`
+
+	// Nominal rounds the rough thickness up to the next nominal rough
+	// thickness, such as 4/4 or 8/4.
+	Nominal bool `json:"nominal,omitempty"`
+}
+
+// MillingAllowance returns the design's milling allowance, zero if none is
+// set.
+func (d GlobalDefaults) MillingAllowance() MillingAllowance {
+	if d.Milling != nil {
+		return *d.Milling
+	}
+	return MillingAllowance{}
+}
+
+// Allowance returns the milling allowance for the board: its own if set,
+// which replaces the design's, otherwise the design's.
+func (b BoardData) Allowance(d GlobalDefaults) MillingAllowance {
+	if b.Milling != nil {
+		return *b.Milling
+	}
+	return d.MillingAllowance()
+}
+
+// QuarterThickness is a nominal hardwood thickness in quarters of an inch.
+type QuarterThickness struct {
+	Name     string  // e.g. "8/4"
+	Rough    float64 // rough sawn thickness, mm
+	Surfaced float64 // thickness surfaced two sides (S2S), mm
+}
+
+// QuarterThicknesses lists the common rough hardwood thicknesses, thinnest
+// first, with their standard S2S thicknesses per the NHLA rules.
+var QuarterThicknesses = []QuarterThickness{
+	{"4/4", 25.4, 20.6},   // 13/16"
+	{"5/4", 31.75, 27},    // 1-1/16"
+	{"6/4", 38.1, 33.3},   // 1-5/16"
+	{"8/4", 50.8, 44.5},   // 1-3/4"
+	{"10/4", 63.5, 57.2},  // 2-1/4"
+	{"12/4", 76.2, 69.9},  // 2-3/4"
+	{"16/4", 101.6, 95.3}, // 3-3/4"
+}
+
+// ParseQuarter returns the quarter thickness with the given name, such as
+// "8/4".
+func ParseQuarter(name string) (QuarterThickness, bool) {
+	name = strings.TrimSpace(name)
+	for _, q := range QuarterThicknesses {
+		if q.Name == name {
+			return q, true
+		}
+	}
+	return QuarterThickness{}, false
+}
+
+// RoundToQuarter returns the thinnest quarter thickness whose rough
+// thickness is at least t mm. The boolean is false if t is thicker than
+// 16/4.
+func RoundToQuarter(t float64) (QuarterThickness, bool) {
+	for _, q := range QuarterThicknesses {
+		if q.Rough >= t-0.01 {
+			return q, true
+		}
+	}
+	return QuarterThickness{}, false
+}
+
+// ParseS4S returns the actual thickness and width in mm of dimensional
+// lumber sold surfaced four sides under a nominal size such as "1x6" or
+// "2x4", per the American Softwood Lumber Standard: 1" nominal is 3/4",
+// other sizes up to 7" are 1/2" under, and wider ones 3/4" under.
+func ParseS4S(size string) (thickness, width float64, ok bool) {
+	t, w, found := strings.Cut(strings.ToLower(strings.TrimSpace(size)), "x")
+	if !found {
+		return 0, 0, false
+	}
+	nt, err1 := strconv.Atoi(strings.TrimSpace(t))
+	nw, err2 := strconv.Atoi(strings.TrimSpace(w))
+	if err1 != nil || err2 != nil || nt < 1 || nw < nt {
+		return 0, 0, false
+	}
+	return s4sActual(nt), s4sActual(nw), true
+}
+
+// s4sActual returns the actual size in mm of a nominal size in inches.
+func s4sActual(nominal int) float64 {
+	inches := float64(nominal) - 0.75
+	switch {
+	case nominal == 1:
+		inches = 0.75
+	case nominal <= 7:
+		inches = float64(nominal) - 0.5
+	}
+	return math.Round(inches*2540) / 100
+}
@@ -0,0 +1,60 @@
+package graph
+
+import "testing"
+
+func TestParseS4S(t *testing.T) {
+	tests := []struct {
+		size             string
+		thickness, width float64
+		ok               bool
+	}{
+		{"1x6", 19.05, 139.7, true},
+		{"2x4", 38.1, 88.9, true},
+		{"2X10", 38.1, 234.95, true},
+		{"4x4", 88.9, 88.9, true},
+		{"6x1", 0, 0, false},
+		{"1x", 0, 0, false},
+		{"4/4", 0, 0, false},
+	}
+	for _, tt := range tests {
+		th, w, ok := ParseS4S(tt.size)
+		if th != tt.thickness || w != tt.width || ok != tt.ok {
+			t.Errorf("ParseS4S(%q) = %v, %v, %v; want %v, %v, %v", tt.size, th, w, ok, tt.thickness, tt.width, tt.ok)
+		}
+	}
+}
+
+func TestQuarterThickness(t *testing.T) {
+	if q, ok := ParseQuarter(" 8/4 "); !ok || q.Rough != 50.8 || q.Surfaced != 44.5 {
+		t.Errorf("ParseQuarter(8/4) = %+v, %v", q, ok)
+	}
+	if _, ok := ParseQuarter("7/4"); ok {
+		t.Error("ParseQuarter(7/4) succeeded")
+	}
+	for in, want := range map[float64]string{19: "4/4", 25.4: "4/4", 25.5: "5/4", 40: "8/4", 100: "16/4"} {
+		if q, ok := RoundToQuarter(in); !ok || q.Name != want {
+			t.Errorf("RoundToQuarter(%v) = %q, want %q", in, q.Name, want)
+		}
+	}
+	if _, ok := RoundToQuarter(120); ok {
+		t.Error("RoundToQuarter(120) succeeded")
+	}
+}
+
+func TestStockQuarter(t *testing.T) {
+	for _, tt := range []struct {
+		stock Stock
+		want  string
+	}{
+		{Stock{Name: "walnut-8/4", Thickness: 44}, "8/4"},
+		{Stock{Name: "oak 5/4"}, "5/4"},
+		{Stock{Name: "oak", Nominal: "12/4"}, "12/4"},
+		{Stock{Name: "pine-1x6", Nominal: "1x6"}, ""},
+		{Stock{Name: "ply-18"}, ""},
+	} {
+		q, ok := tt.stock.Quarter()
+		if q.Name != tt.want || ok != (tt.want != "") {
+			t.Errorf("%q.Quarter() = %q, %v; want %q", tt.stock.Name, q.Name, ok, tt.want)
+		}
+	}
+}
@@ -1,5 +1,12 @@
 package graph
 
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
 // NodeKind enumerates the types of nodes in the design graph.
 type NodeKind int
 
@@ -40,6 +47,41 @@ type Node struct {
 	ContentHash ContentHash `json:"content_hash"`
 	Children    []NodeID    `json:"children,omitempty"`
 	Data        NodeData    `json:"data"`
+
+	// Tags and Meta are user-defined annotations from :tags and :meta,
+	// e.g. "visible-face" or finish "oil". They do not affect geometry.
+	Tags []string          `json:"tags,omitempty"`
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+// Label names the node for messages: its kind and quoted name, as in
+// `primitive "side"`, or its kind and short ID if it has no name.
+func (n *Node) Label() string {
+	if n.Name != "" {
+		return fmt.Sprintf("%s %q", n.Kind, n.Name)
+	}
+	return fmt.Sprintf("%s %s", n.Kind, n.ID.Short())
+}
+
+// HasTag reports whether the node itself carries the given tag.
+func (n *Node) HasTag(tag string) bool {
+	return slices.Contains(n.Tags, tag)
+}
+
+// ComputeContentHash hashes the node's semantic content: its kind, name,
+// children and data. The ID, source location and stored hash are excluded,
+// so moving a form within the source does not change the hash. Nodes whose
+// data cannot be encoded hash to the zero value.
+func (n *Node) ComputeContentHash() ContentHash {
+	c := *n
+	c.ID = ZeroID
+	c.Source = SourceRef{}
+	c.ContentHash = ContentHash{}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return ContentHash{}
+	}
+	return sha256.Sum256(b)
 }
 
 // NodeData is the interface for kind-specific node payloads.
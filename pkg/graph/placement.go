@@ -0,0 +1,100 @@
+package graph
+
+import "fmt"
+
+// ---------------------------------------------------------------------------
+// World-space placements
+// ---------------------------------------------------------------------------
+
+// Placement is one instance of a primitive in world space. A part placed
+// twice has two placements.
+type Placement struct {
+	Part      *Node     // the primitive
+	Path      []*Node   // enclosing nodes from the root down, excluding Part
+	Transform Transform // local-to-world transform of Part
+	Grain     Vec3      // world-space grain direction, zero if Part has none
+}
+
+// Placements returns every primitive instance reachable from the roots,
+// in walk order, with its accumulated world transform. A graph without
+// roots places each primitive once at the origin, as tessellation does.
+func (g *DesignGraph) Placements() []Placement {
+	if len(g.Roots) == 0 {
+		var out []Placement
+		for _, n := range g.Parts() {
+			out = append(out, Placement{Part: n, Grain: worldGrain(n, Transform{})})
+		}
+		return out
+	}
+
+	var out []Placement
+	g.Walk(Visitor{Pre: func(n *Node, path []*Node) error {
+		if n.Kind != NodePrimitive {
+			return nil
+		}
+		var t Transform
+		for _, p := range path {
+			if td, ok := p.Data.(TransformData); ok {
+				t = t.Compose(td)
+			}
+		}
+		out = append(out, Placement{Part: n, Path: append([]*Node(nil), path...), Transform: t, Grain: worldGrain(n, t)})
+		return nil
+	}})
+	return out
+}
+
+// worldGrain resolves a primitive's grain axis to a world-space unit
+// vector under t, so that it follows the part's rotation.
+func worldGrain(n *Node, t Transform) Vec3 {
+	switch d := n.Data.(type) {
+	case BoardData:
+		return t.ApplyDir(d.Grain.Unit())
+	case DowelData:
+		return t.ApplyDir(d.Grain.Unit())
+	}
+	return Vec3{}
+}
+
+// PlacementsOf returns the placements of the primitive with the given ID.
+func (g *DesignGraph) PlacementsOf(id NodeID) []Placement {
+	var out []Placement
+	for _, p := range g.Placements() {
+		if p.Part.ID == id {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Bounds returns the world-space bounding box of the placed part.
+func (p Placement) Bounds() (Box, bool) {
+	b, ok := LocalBounds(p.Part.Data)
+	if !ok {
+		return Box{}, false
+	}
+	return b.Transformed(p.Transform), true
+}
+
+// Node returns the node that places the part: the innermost enclosing
+// placement, or the part itself if it is not placed.
+func (p Placement) Node() *Node {
+	for i := len(p.Path) - 1; i >= 0; i-- {
+		if p.Path[i].Kind == NodeTransform {
+			return p.Path[i]
+		}
+	}
+	return p.Part
+}
+
+// Label names the instance for messages, e.g. `"side" (placed at line 12)`.
+func (p Placement) Label() string {
+	name := fmt.Sprintf("%q", p.Part.Name)
+	if p.Part.Name == "" {
+		name = p.Part.ID.Short()
+	}
+	if n := p.Node(); n != p.Part && n.Source.Line > 0 {
+		return fmt.Sprintf("%s (placed at %s)", name, n.Source)
+	}
+	return name
+}
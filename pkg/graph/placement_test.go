@@ -0,0 +1,20 @@
+package graph
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPlacementGrainFollowsRotation(t *testing.T) {
+	g := interferenceGraph(
+		placed{part: "rail", dims: Vec3{600, 19, 60}},
+		placed{part: "stile", dims: Vec3{600, 19, 60}, at: Vec3{60, 0, 0}, rotate: &Vec3{0, -90, 0}},
+	)
+	want := map[string]Vec3{"rail": {1, 0, 0}, "stile": {0, 0, 1}}
+	for _, p := range g.Placements() {
+		d := p.Grain.Sub(want[p.Part.Name])
+		if math.Sqrt(d.Dot(d)) > 1e-9 {
+			t.Errorf("%s grain = %v, want %v", p.Part.Name, p.Grain, want[p.Part.Name])
+		}
+	}
+}
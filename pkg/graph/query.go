@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"fmt"
+	"path"
+	"slices"
+)
+
+// Query selects nodes from a design graph. All set criteria must match;
+// the zero Query selects every node.
+type Query struct {
+	// Kinds restricts the result to nodes of any of these kinds.
+	Kinds []NodeKind
+
+	// Tags lists tags a node must all carry. With InheritTags, tags on a
+	// node's ancestors count too, so parts inside an assembly tagged
+	// "drawer-box" match "drawer-box".
+	Tags        []string
+	InheritTags bool
+
+	// Name is a glob pattern (see path.Match) the node's name must match,
+	// e.g. "drawer-*". Unnamed nodes never match a non-empty pattern.
+	Name string
+
+	// Within is the name of an assembly the node must be contained in,
+	// directly or through nested placements and assemblies.
+	Within string
+}
+
+// Query returns the nodes selected by q, in canonical order. It fails only
+// if q.Name is a malformed pattern.
+func (g *DesignGraph) Query(q Query) ([]*Node, error) {
+	if q.Name != "" {
+		if _, err := path.Match(q.Name, ""); err != nil {
+			return nil, fmt.Errorf("query: bad name pattern %q: %w", q.Name, err)
+		}
+	}
+
+	var out []*Node
+	for _, n := range g.SortedNodes() {
+		if len(q.Kinds) > 0 && !slices.Contains(q.Kinds, n.Kind) {
+			continue
+		}
+		if q.Name != "" {
+			if ok, _ := path.Match(q.Name, n.Name); !ok || n.Name == "" {
+				continue
+			}
+		}
+		if !g.hasTags(n, q.Tags, q.InheritTags) {
+			continue
+		}
+		if q.Within != "" && !g.within(n, q.Within) {
+			continue
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// EffectiveTags returns the tags of the node with the given ID together
+// with those of its ancestors, nearest first, without duplicates.
+func (g *DesignGraph) EffectiveTags(id NodeID) []string {
+	var tags []string
+	add := func(n *Node) {
+		for _, t := range n.Tags {
+			if !slices.Contains(tags, t) {
+				tags = append(tags, t)
+			}
+		}
+	}
+	if n := g.Nodes[id]; n != nil {
+		add(n)
+	}
+	for _, a := range g.Ancestors(id) {
+		add(a)
+	}
+	return tags
+}
+
+func (g *DesignGraph) hasTags(n *Node, want []string, inherit bool) bool {
+	if len(want) == 0 {
+		return true
+	}
+	have := n.Tags
+	if inherit {
+		have = g.EffectiveTags(n.ID)
+	}
+	for _, t := range want {
+		if !slices.Contains(have, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *DesignGraph) within(n *Node, assembly string) bool {
+	for _, a := range g.Ancestors(n.ID) {
+		if a.Kind == NodeGroup && a.Name == assembly {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package graph
+
+import "testing"
+
+// queryTestGraph is traverseTestGraph with tags, plus a drawer assembly
+// nested inside the cabinet.
+func queryTestGraph() *DesignGraph {
+	g := traverseTestGraph()
+	tag := func(name string, tags ...string) {
+		n := g.MustLookup(name)
+		n.Tags = tags
+		g.AddNode(n)
+	}
+	tag("side", "visible-face")
+	tag("shelf", "secondary-wood")
+	tag("left", "visible-face", "paint-grade")
+
+	front := &Node{ID: NewNodeID("drawer-front"), Kind: NodePrimitive, Name: "drawer-front",
+		Source: SourceRef{Line: 20}, Data: BoardData{Dimensions: Vec3{500, 150, 19}}, Tags: []string{"visible-face"}}
+	bottom := &Node{ID: NewNodeID("drawer-bottom"), Kind: NodePrimitive, Name: "drawer-bottom",
+		Source: SourceRef{Line: 21}, Data: BoardData{Dimensions: Vec3{480, 400, 6}}}
+	drawer := &Node{ID: NewNodeID("drawer"), Kind: NodeGroup, Name: "drawer", Source: SourceRef{Line: 22},
+		Children: []NodeID{front.ID, bottom.ID}, Data: GroupData{}, Tags: []string{"drawer-box"}}
+	g.AddNode(front)
+	g.AddNode(bottom)
+	g.AddNode(drawer)
+
+	cabinet := g.MustLookup("cabinet")
+	cabinet.Children = append(cabinet.Children, drawer.ID)
+	g.AddNode(cabinet)
+	return g
+}
+
+func TestQuery(t *testing.T) {
+	g := queryTestGraph()
+	tests := []struct {
+		name string
+		q    Query
+		want string
+	}{
+		{"all parts", Query{Kinds: []NodeKind{NodePrimitive}}, "side shelf drawer-front drawer-bottom"},
+		{"by tag", Query{Tags: []string{"visible-face"}}, "side left drawer-front"},
+		{"all tags", Query{Tags: []string{"visible-face", "paint-grade"}}, "left"},
+		{"kind and tag", Query{Kinds: []NodeKind{NodePrimitive}, Tags: []string{"visible-face"}}, "side drawer-front"},
+		{"inherited tag", Query{Kinds: []NodeKind{NodePrimitive}, Tags: []string{"drawer-box"}, InheritTags: true}, "drawer-front drawer-bottom"},
+		{"inherited through placement", Query{Kinds: []NodeKind{NodePrimitive}, Tags: []string{"paint-grade"}, InheritTags: true}, "side"},
+		{"own tags only", Query{Kinds: []NodeKind{NodePrimitive}, Tags: []string{"drawer-box"}}, ""},
+		{"name glob", Query{Name: "drawer-*"}, "drawer-front drawer-bottom"},
+		{"name glob single char", Query{Name: "s?elf"}, "shelf"},
+		{"within assembly", Query{Kinds: []NodeKind{NodePrimitive}, Within: "drawer"}, "drawer-front drawer-bottom"},
+		{"within outer assembly", Query{Kinds: []NodeKind{NodePrimitive}, Within: "cabinet"}, "side shelf drawer-front drawer-bottom"},
+		{"within unknown", Query{Within: "nope"}, ""},
+		{"joins", Query{Kinds: []NodeKind{NodeJoin, NodeDrill}}, "join drill"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.Query(tt.q)
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			if nodeNames(got) != tt.want {
+				t.Errorf("got %q, want %q", nodeNames(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryBadPattern(t *testing.T) {
+	if _, err := queryTestGraph().Query(Query{Name: "[a-"}); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
+
+func TestEffectiveTags(t *testing.T) {
+	g := queryTestGraph()
+	got := g.EffectiveTags(NewNodeID("side"))
+	want := []string{"visible-face", "paint-grade"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("EffectiveTags(side) = %v, want %v", got, want)
+	}
+}
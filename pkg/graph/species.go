@@ -0,0 +1,175 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Species database
+// ---------------------------------------------------------------------------
+
+// Species holds the physical properties of a wood species at 12% moisture
+// content.
+type Species struct {
+	Density float64 `json:"density"` // kg/m³
+	Janka   float64 `json:"janka"`   // side hardness, N
+	MOE     float64 `json:"moe"`     // modulus of elasticity along the grain, MPa
+
+	// Dimensional change per 1% change in moisture content, as a fraction
+	// of the dimension, across the grain.
+	TangentialShrinkage float64 `json:"tangential_shrinkage"`
+	RadialShrinkage     float64 `json:"radial_shrinkage"`
+}
+
+// SpeciesTable maps normalized species names, such as "white-oak", to
+// their properties.
+type SpeciesTable map[string]Species
+
+// builtinSpecies is drawn from the USDA Wood Handbook (FPL-GTR-282).
+var builtinSpecies = SpeciesTable{
+	"white-oak":         {Density: 755, Janka: 6050, MOE: 12300, TangentialShrinkage: 0.00365, RadialShrinkage: 0.00180},
+	"red-oak":           {Density: 705, Janka: 5740, MOE: 12500, TangentialShrinkage: 0.00369, RadialShrinkage: 0.00158},
+	"hard-maple":        {Density: 705, Janka: 6450, MOE: 12600, TangentialShrinkage: 0.00353, RadialShrinkage: 0.00165},
+	"soft-maple":        {Density: 610, Janka: 4230, MOE: 11300, TangentialShrinkage: 0.00289, RadialShrinkage: 0.00137},
+	"walnut":            {Density: 610, Janka: 4490, MOE: 11600, TangentialShrinkage: 0.00274, RadialShrinkage: 0.00190},
+	"cherry":            {Density: 560, Janka: 4230, MOE: 10300, TangentialShrinkage: 0.00248, RadialShrinkage: 0.00126},
+	"white-ash":         {Density: 675, Janka: 5870, MOE: 12000, TangentialShrinkage: 0.00274, RadialShrinkage: 0.00169},
+	"yellow-birch":      {Density: 690, Janka: 5600, MOE: 13900, TangentialShrinkage: 0.00338, RadialShrinkage: 0.00256},
+	"beech":             {Density: 720, Janka: 5780, MOE: 11900, TangentialShrinkage: 0.00431, RadialShrinkage: 0.00190},
+	"hickory":           {Density: 800, Janka: 8100, MOE: 14900, TangentialShrinkage: 0.00411, RadialShrinkage: 0.00259},
+	"poplar":            {Density: 455, Janka: 2400, MOE: 10900, TangentialShrinkage: 0.00289, RadialShrinkage: 0.00158},
+	"mahogany":          {Density: 590, Janka: 3560, MOE: 10100, TangentialShrinkage: 0.00238, RadialShrinkage: 0.00172},
+	"teak":              {Density: 655, Janka: 4740, MOE: 12300, TangentialShrinkage: 0.00186, RadialShrinkage: 0.00101},
+	"white-pine":        {Density: 400, Janka: 1690, MOE: 8500, TangentialShrinkage: 0.00212, RadialShrinkage: 0.00071},
+	"douglas-fir":       {Density: 530, Janka: 3160, MOE: 13400, TangentialShrinkage: 0.00267, RadialShrinkage: 0.00165},
+	"western-red-cedar": {Density: 370, Janka: 1560, MOE: 7700, TangentialShrinkage: 0.00234, RadialShrinkage: 0.00111},
+}
+
+// BuiltinSpecies returns a copy of the built-in species table.
+func BuiltinSpecies() SpeciesTable {
+	t := make(SpeciesTable, len(builtinSpecies))
+	for name, s := range builtinSpecies {
+		t[name] = s
+	}
+	return t
+}
+
+// NormalizeSpecies folds a species name to the form used as a table key:
+// lower case, with spaces and underscores turned into hyphens.
+func NormalizeSpecies(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-'
+	}), "-")
+}
+
+// Lookup returns the properties of the named species.
+func (t SpeciesTable) Lookup(name string) (Species, bool) {
+	s, ok := t[NormalizeSpecies(name)]
+	return s, ok
+}
+
+// Names returns the species names in the table, sorted.
+func (t SpeciesTable) Names() []string {
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Suggest returns up to three names in the table that are close to name,
+// nearest first, for "did you mean" hints.
+func (t SpeciesTable) Suggest(name string) []string {
+	name = NormalizeSpecies(name)
+	type candidate struct {
+		name string
+		dist int
+	}
+	var cands []candidate
+	for _, known := range t.Names() {
+		d := editDistance(name, known)
+		if d <= max(2, len(known)/3) || strings.Contains(known, name) || strings.Contains(name, known) {
+			cands = append(cands, candidate{known, d})
+		}
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].dist < cands[j].dist })
+
+	var out []string
+	for i := 0; i < len(cands) && i < 3; i++ {
+		out = append(out, cands[i].name)
+	}
+	return out
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// LoadSpecies reads species overrides from JSON and returns the built-in
+// table with them applied. The JSON is an object keyed by species name;
+// an entry for a built-in species replaces only the properties it sets,
+// so {"white-oak": {"density": 770}} keeps the other white oak values.
+func LoadSpecies(r io.Reader) (SpeciesTable, error) {
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("species: %w", err)
+	}
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	t := BuiltinSpecies()
+	for _, name := range names {
+		data := raw[name]
+		key := NormalizeSpecies(name)
+		if key == "" {
+			return nil, fmt.Errorf("species: empty species name")
+		}
+		s := t[key]
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("species %q: %w", name, err)
+		}
+		t[key] = s
+	}
+	return t, nil
+}
+
+// LoadSpeciesFile is LoadSpecies for a file.
+func LoadSpeciesFile(path string) (SpeciesTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	t, err := LoadSpecies(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSpeciesLookupNormalizesNames(t *testing.T) {
+	table := BuiltinSpecies()
+	for _, name := range []string{"white-oak", "White Oak", "white_oak", " WHITE-OAK "} {
+		s, ok := table.Lookup(name)
+		if !ok || s.Density != 755 {
+			t.Errorf("Lookup(%q) = %+v, %v; want white oak", name, s, ok)
+		}
+	}
+	if _, ok := table.Lookup("unobtainium"); ok {
+		t.Error("expected an unknown species to be missing")
+	}
+}
+
+func TestSpeciesSuggest(t *testing.T) {
+	table := BuiltinSpecies()
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"whte-oak", []string{"white-oak"}},
+		{"maple", []string{"hard-maple", "soft-maple"}},
+		{"walnut-black", []string{"walnut"}},
+		{"zebrawood", nil},
+	}
+	for _, tc := range tests {
+		if got := table.Suggest(tc.name); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Suggest(%q) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestLoadSpeciesMergesOverrides(t *testing.T) {
+	table, err := LoadSpecies(strings.NewReader(`{
+		"White Oak": {"density": 770},
+		"sapele": {"density": 640, "janka": 6280, "moe": 12040, "tangential_shrinkage": 0.0025, "radial_shrinkage": 0.0018}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oak, _ := table.Lookup("white-oak")
+	if oak.Density != 770 || oak.Janka != builtinSpecies["white-oak"].Janka {
+		t.Errorf("white oak = %+v, want density overridden and other properties kept", oak)
+	}
+	if _, ok := table.Lookup("sapele"); !ok {
+		t.Error("expected sapele to be added")
+	}
+	if builtinSpecies["white-oak"].Density != 755 {
+		t.Error("overrides must not change the built-in table")
+	}
+
+	if _, err := LoadSpecies(strings.NewReader(`{"ash": {"density": "heavy"}}`)); err == nil {
+		t.Error("expected an error for a malformed property")
+	}
+}
+
+func TestValidateSpecies(t *testing.T) {
+	g := New()
+	for _, name := range []string{"whte-oak", "zebrawood", "walnut", ""} {
+		g.AddNode(&Node{
+			ID: NewNodeID("defpart/" + name), Kind: NodePrimitive, Name: "part-" + name,
+			Data: BoardData{PrimKind: PrimBoard, Dimensions: Vec3{400, 200, 19}, Material: MaterialSpec{Species: name}},
+		})
+	}
+
+	warnings := validateSpecies(g)
+	if len(warnings) != 2 {
+		t.Fatalf("expected 2 warnings, got %v", warnings)
+	}
+	if want := `part "part-whte-oak" uses unknown species "whte-oak"; did you mean "white-oak"?`; warnings[0].Message != want {
+		t.Errorf("got %q, want %q", warnings[0].Message, want)
+	}
+	if !strings.Contains(warnings[1].Message, `unknown species "zebrawood"; add it to a species file`) {
+		t.Errorf("expected a warning for the unknown species, got %q", warnings[1].Message)
+	}
+
+	g.Species, _ = LoadSpecies(strings.NewReader(`{"zebrawood": {"density": 800}}`))
+	if warnings := validateSpecies(g); len(warnings) != 1 {
+		t.Errorf("expected the graph's species table to know zebrawood, got %v", warnings)
+	}
+}
+
+func TestValidateSpeciesDefaultMaterial(t *testing.T) {
+	g := New()
+	g.Defaults.Material.Species = "wallnut"
+	g.AddNode(&Node{
+		ID: NewNodeID("defpart/shelf"), Kind: NodePrimitive, Name: "shelf",
+		Data: BoardData{PrimKind: PrimBoard, Dimensions: Vec3{400, 200, 19}},
+	})
+
+	warnings := validateSpecies(g)
+	if want := `part "shelf" uses unknown species "wallnut"; did you mean "walnut"?`; len(warnings) != 1 || warnings[0].Message != want {
+		t.Errorf("warnings = %v, want %q", warnings, want)
+	}
+}
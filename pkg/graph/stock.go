@@ -0,0 +1,44 @@
+package graph
+
+import "strings"
+
+// ---------------------------------------------------------------------------
+// Stock
+// ---------------------------------------------------------------------------
+
+// DefaultKerf is the saw kerf in mm assumed for stock that does not
+// declare one.
+const DefaultKerf = 3.0
+
+// Stock declares boards available to build the design from. Stock is
+// advisory: it never changes geometry, and parts that cannot be allocated
+// to it are reported as warnings.
+type Stock struct {
+	Name      string    `json:"name"`
+	Species   string    `json:"species,omitempty"`
+	Thickness float64   `json:"thickness"`         // mm
+	Width     float64   `json:"width"`             // mm
+	Length    float64   `json:"length"`            // mm, along the grain
+	Quantity  int       `json:"quantity"`          // number of boards
+	Nominal   string    `json:"nominal,omitempty"` // size it is sold as, e.g. "8/4" or "1x6"
+	Kerf      *float64  `json:"kerf,omitempty"`    // mm lost per cut; DefaultKerf if unset
+	Source    SourceRef `json:"source"`
+}
+
+// Quarter returns the quarter thickness the stock is sold as: its nominal
+// thickness, or else a quarter ending its name, as in "walnut-8/4".
+func (s Stock) Quarter() (QuarterThickness, bool) {
+	if q, ok := ParseQuarter(s.Nominal); ok {
+		return q, true
+	}
+	return ParseQuarter(s.Name[strings.LastIndexAny(s.Name, "-_ ")+1:])
+}
+
+// KerfOrDefault returns the stock's kerf, DefaultKerf if none is set. A
+// kerf of zero is kept, for stock that is sheared or already cut to size.
+func (s Stock) KerfOrDefault() float64 {
+	if s.Kerf != nil {
+		return *s.Kerf
+	}
+	return DefaultKerf
+}
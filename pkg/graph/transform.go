@@ -0,0 +1,262 @@
+package graph
+
+import "math"
+
+// ---------------------------------------------------------------------------
+// Spatial transforms
+// ---------------------------------------------------------------------------
+
+// Transform is an accumulated placement: a rotation by Euler angles in
+// degrees (about X, then Y, then Z, around the origin) followed by a
+// translation. It mirrors how tessellate positions solids, so coordinates
+// computed with a Transform match what the viewport renders.
+type Transform struct {
+	Rotation    Vec3 // Euler angles in degrees
+	Translation Vec3
+}
+
+// Compose returns the transform obtained by nesting a (place ...) with the
+// given data inside t. Like tessellate, rotations and translations of
+// nested placements are summed.
+func (t Transform) Compose(td TransformData) Transform {
+	out := t
+	if td.Rotation != nil {
+		out.Rotation = out.Rotation.Add(*td.Rotation)
+	}
+	if td.Translation != nil {
+		out.Translation = out.Translation.Add(*td.Translation)
+	}
+	return out
+}
+
+// Apply maps a point from local to transformed coordinates.
+func (t Transform) Apply(p Vec3) Vec3 {
+	return t.ApplyDir(p).Add(t.Translation)
+}
+
+// ApplyDir rotates a direction vector; translation does not affect it.
+func (t Transform) ApplyDir(v Vec3) Vec3 {
+	if t.Rotation == (Vec3{}) {
+		return v
+	}
+	rx := t.Rotation.X * math.Pi / 180
+	ry := t.Rotation.Y * math.Pi / 180
+	rz := t.Rotation.Z * math.Pi / 180
+
+	// Rotate about X.
+	sx, cx := math.Sincos(rx)
+	v = Vec3{v.X, v.Y*cx - v.Z*sx, v.Y*sx + v.Z*cx}
+	// Rotate about Y.
+	sy, cy := math.Sincos(ry)
+	v = Vec3{v.X*cy + v.Z*sy, v.Y, -v.X*sy + v.Z*cy}
+	// Rotate about Z.
+	sz, cz := math.Sincos(rz)
+	v = Vec3{v.X*cz - v.Y*sz, v.X*sz + v.Y*cz, v.Z}
+
+	return v.snap()
+}
+
+// snap rounds components that are within floating-point noise of an
+// integer, so that quarter turns produce exact axis-aligned results.
+func (v Vec3) snap() Vec3 {
+	r := func(f float64) float64 {
+		if n := math.Round(f); math.Abs(f-n) < 1e-9 {
+			return n
+		}
+		return f
+	}
+	return Vec3{r(v.X), r(v.Y), r(v.Z)}
+}
+
+// ---------------------------------------------------------------------------
+// Bounding boxes
+// ---------------------------------------------------------------------------
+
+// Box is an axis-aligned bounding box.
+type Box struct {
+	Min, Max Vec3
+}
+
+// Size returns the extent of the box along each axis.
+func (b Box) Size() Vec3 {
+	return Vec3{b.Max.X - b.Min.X, b.Max.Y - b.Min.Y, b.Max.Z - b.Min.Z}
+}
+
+// Center returns the center point of the box.
+func (b Box) Center() Vec3 {
+	return b.Min.Add(b.Max).Scale(0.5)
+}
+
+// Union returns the smallest box containing both b and other.
+func (b Box) Union(other Box) Box {
+	return Box{
+		Min: Vec3{math.Min(b.Min.X, other.Min.X), math.Min(b.Min.Y, other.Min.Y), math.Min(b.Min.Z, other.Min.Z)},
+		Max: Vec3{math.Max(b.Max.X, other.Max.X), math.Max(b.Max.Y, other.Max.Y), math.Max(b.Max.Z, other.Max.Z)},
+	}
+}
+
+// Corners returns the eight corner points of the box.
+func (b Box) Corners() [8]Vec3 {
+	var cs [8]Vec3
+	for i := range cs {
+		c := b.Min
+		if i&1 != 0 {
+			c.X = b.Max.X
+		}
+		if i&2 != 0 {
+			c.Y = b.Max.Y
+		}
+		if i&4 != 0 {
+			c.Z = b.Max.Z
+		}
+		cs[i] = c
+	}
+	return cs
+}
+
+// Transformed returns the axis-aligned box enclosing b after applying t.
+func (b Box) Transformed(t Transform) Box {
+	corners := b.Corners()
+	p := t.Apply(corners[0])
+	out := Box{Min: p, Max: p}
+	for _, c := range corners[1:] {
+		p := t.Apply(c)
+		out = out.Union(Box{Min: p, Max: p})
+	}
+	return out
+}
+
+// LocalBounds returns the bounding box of a primitive in its own frame,
+// using the same conventions as the geometry kernel: boards span from the
+// origin to their dimensions, dowels are centered on the origin along Z.
+func LocalBounds(data NodeData) (Box, bool) {
+	switch d := data.(type) {
+	case BoardData:
+		return Box{Max: d.Dimensions}, true
+	case DowelData:
+		r := d.Diameter / 2
+		return Box{Min: Vec3{-r, -r, -d.Length / 2}, Max: Vec3{r, r, d.Length / 2}}, true
+	default:
+		return Box{}, false
+	}
+}
+
+// Bounds returns the bounding box of the node with the given ID in the
+// frame of its parent: a primitive's local bounds, a placement's child
+// bounds after its transform, or the union of a group's children. Joins,
+// drills and fasteners have no bounds of their own. The boolean result is
+// false if the node contributes no geometry.
+func (g *DesignGraph) Bounds(id NodeID) (Box, bool) {
+	return g.bounds(id, Transform{}, make(map[NodeID]bool))
+}
+
+func (g *DesignGraph) bounds(id NodeID, t Transform, visiting map[NodeID]bool) (Box, bool) {
+	n := g.Nodes[id]
+	if n == nil || visiting[id] {
+		return Box{}, false // dangling references and cycles are Tier 1 errors
+	}
+	visiting[id] = true
+	defer delete(visiting, id)
+
+	switch n.Kind {
+	case NodePrimitive:
+		b, ok := LocalBounds(n.Data)
+		if !ok {
+			return Box{}, false
+		}
+		return b.Transformed(t), true
+	case NodeTransform, NodeGroup:
+		if td, ok := n.Data.(TransformData); ok {
+			t = t.Compose(td)
+		}
+		var out Box
+		found := false
+		for _, cid := range n.Children {
+			b, ok := g.bounds(cid, t, visiting)
+			if !ok {
+				continue
+			}
+			if !found {
+				out, found = b, true
+			} else {
+				out = out.Union(b)
+			}
+		}
+		return out, found
+	default:
+		return Box{}, false
+	}
+}
+
+// FaceCenter returns the center of a board face in the board's local frame.
+func FaceCenter(dims Vec3, face FaceID) (Vec3, bool) {
+	c := dims.Scale(0.5)
+	switch face {
+	case FaceTop:
+		c.Y = dims.Y
+	case FaceBottom:
+		c.Y = 0
+	case FaceRight:
+		c.X = dims.X
+	case FaceLeft:
+		c.X = 0
+	case FaceBack:
+		c.Z = dims.Z
+	case FaceFront:
+		c.Z = 0
+	default:
+		return Vec3{}, false
+	}
+	return c, true
+}
+
+// Face is a rectangular board face: its center, its outward unit normal,
+// and unit axes U and V spanning the face with the half extents along
+// them.
+type Face struct {
+	Center, Normal Vec3
+	U, V           Vec3
+	HalfU, HalfV   float64
+}
+
+// BoardFace returns a face of a board in the board's local frame.
+func BoardFace(dims Vec3, face FaceID) (Face, bool) {
+	c, ok := FaceCenter(dims, face)
+	if !ok {
+		return Face{}, false
+	}
+	x, y, z := Vec3{1, 0, 0}, Vec3{0, 1, 0}, Vec3{0, 0, 1}
+	f := Face{Center: c}
+	switch face {
+	case FaceTop, FaceBottom:
+		f.Normal, f.U, f.V, f.HalfU, f.HalfV = y, x, z, dims.X/2, dims.Z/2
+	case FaceLeft, FaceRight:
+		f.Normal, f.U, f.V, f.HalfU, f.HalfV = x, y, z, dims.Y/2, dims.Z/2
+	case FaceFront, FaceBack:
+		f.Normal, f.U, f.V, f.HalfU, f.HalfV = z, x, y, dims.X/2, dims.Y/2
+	}
+	if face == FaceBottom || face == FaceLeft || face == FaceFront {
+		f.Normal = f.Normal.Scale(-1)
+	}
+	return f, true
+}
+
+// Transformed returns the face moved by t.
+func (f Face) Transformed(t Transform) Face {
+	f.Center = t.Apply(f.Center)
+	f.Normal = t.ApplyDir(f.Normal)
+	f.U = t.ApplyDir(f.U)
+	f.V = t.ApplyDir(f.V)
+	return f
+}
+
+// Corners returns the corners of the face in order around its edge.
+func (f Face) Corners() [4]Vec3 {
+	u, v := f.U.Scale(f.HalfU), f.V.Scale(f.HalfV)
+	return [4]Vec3{
+		f.Center.Sub(u).Sub(v),
+		f.Center.Add(u).Sub(v),
+		f.Center.Add(u).Add(v),
+		f.Center.Sub(u).Add(v),
+	}
+}
@@ -0,0 +1,117 @@
+package graph
+
+import "testing"
+
+func TestTransformApply(t *testing.T) {
+	tests := []struct {
+		name string
+		tf   Transform
+		in   Vec3
+		want Vec3
+	}{
+		{"identity", Transform{}, Vec3{1, 2, 3}, Vec3{1, 2, 3}},
+		{"translate", Transform{Translation: Vec3{10, 0, -5}}, Vec3{1, 2, 3}, Vec3{11, 2, -2}},
+		{"rotate z 90", Transform{Rotation: Vec3{0, 0, 90}}, Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{"rotate x 90", Transform{Rotation: Vec3{90, 0, 0}}, Vec3{0, 1, 0}, Vec3{0, 0, 1}},
+		{"rotate y 90", Transform{Rotation: Vec3{0, 90, 0}}, Vec3{0, 0, 1}, Vec3{1, 0, 0}},
+		{"rotate then translate", Transform{Rotation: Vec3{0, 0, 90}, Translation: Vec3{5, 0, 0}}, Vec3{1, 0, 0}, Vec3{5, 1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tf.Apply(tt.in); got != tt.want {
+				t.Errorf("Apply(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformCompose(t *testing.T) {
+	rot := Vec3{0, 0, 90}
+	at := Vec3{10, 20, 30}
+	tf := Transform{Translation: Vec3{1, 1, 1}}.Compose(TransformData{Rotation: &rot, Translation: &at})
+	if tf.Rotation != rot {
+		t.Errorf("rotation = %v, want %v", tf.Rotation, rot)
+	}
+	if tf.Translation != (Vec3{11, 21, 31}) {
+		t.Errorf("translation = %v, want (11, 21, 31)", tf.Translation)
+	}
+}
+
+func TestBoundsPlacedBoard(t *testing.T) {
+	g := New()
+	partID := NewNodeID("shelf")
+	g.AddNode(&Node{ID: partID, Kind: NodePrimitive, Name: "shelf", Data: BoardData{
+		PrimKind: PrimBoard, Dimensions: Vec3{400, 200, 19},
+	}})
+	rot := Vec3{0, 0, 90}
+	at := Vec3{100, 0, 0}
+	placeID := NewNodeID("place/shelf")
+	g.AddNode(&Node{ID: placeID, Kind: NodeTransform, Children: []NodeID{partID},
+		Data: TransformData{Rotation: &rot, Translation: &at}})
+
+	b, ok := g.Bounds(partID)
+	if !ok || b != (Box{Max: Vec3{400, 200, 19}}) {
+		t.Errorf("part bounds = %v (%v), want [0, (400, 200, 19)]", b, ok)
+	}
+
+	b, ok = g.Bounds(placeID)
+	if !ok {
+		t.Fatal("expected placed part to have bounds")
+	}
+	want := Box{Min: Vec3{-100, 0, 0}, Max: Vec3{100, 400, 19}}
+	if b != want {
+		t.Errorf("placed bounds = %v, want %v", b, want)
+	}
+}
+
+func TestBoundsGroupUnion(t *testing.T) {
+	g := New()
+	a := NewNodeID("a")
+	b := NewNodeID("b")
+	g.AddNode(&Node{ID: a, Kind: NodePrimitive, Name: "a", Data: BoardData{Dimensions: Vec3{10, 10, 10}}})
+	g.AddNode(&Node{ID: b, Kind: NodePrimitive, Name: "b", Data: DowelData{Diameter: 8, Length: 30}})
+	at := Vec3{50, 0, 0}
+	pb := NewNodeID("place/b")
+	g.AddNode(&Node{ID: pb, Kind: NodeTransform, Children: []NodeID{b}, Data: TransformData{Translation: &at}})
+	join := NewNodeID("join")
+	g.AddNode(&Node{ID: join, Kind: NodeJoin, Data: JoinData{Kind: JoinButt}})
+	grp := NewNodeID("grp")
+	g.AddNode(&Node{ID: grp, Kind: NodeGroup, Name: "grp", Children: []NodeID{a, pb, join}, Data: GroupData{}})
+
+	box, ok := g.Bounds(grp)
+	if !ok {
+		t.Fatal("expected group to have bounds")
+	}
+	want := Box{Min: Vec3{0, -4, -15}, Max: Vec3{54, 10, 15}}
+	if box != want {
+		t.Errorf("group bounds = %v, want %v", box, want)
+	}
+
+	if _, ok := g.Bounds(join); ok {
+		t.Error("joins should have no bounds")
+	}
+}
+
+func TestFaceCenter(t *testing.T) {
+	dims := Vec3{400, 200, 20}
+	tests := []struct {
+		face FaceID
+		want Vec3
+	}{
+		{FaceTop, Vec3{200, 200, 10}},
+		{FaceBottom, Vec3{200, 0, 10}},
+		{FaceLeft, Vec3{0, 100, 10}},
+		{FaceRight, Vec3{400, 100, 10}},
+		{FaceFront, Vec3{200, 100, 0}},
+		{FaceBack, Vec3{200, 100, 20}},
+	}
+	for _, tt := range tests {
+		got, ok := FaceCenter(dims, tt.face)
+		if !ok || got != tt.want {
+			t.Errorf("FaceCenter(%s) = %v, want %v", tt.face, got, tt.want)
+		}
+	}
+	if _, ok := FaceCenter(dims, FaceID("side")); ok {
+		t.Error("expected invalid face to be rejected")
+	}
+}
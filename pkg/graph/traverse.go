@@ -0,0 +1,334 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+)
+
+// ---------------------------------------------------------------------------
+// Data references
+// ---------------------------------------------------------------------------
+
+// DataRef is a reference from a node's data to another node, as opposed to
+// a Children edge. Field names the referencing field, e.g. "part_a".
+type DataRef struct {
+	Field string
+	ID    NodeID
+}
+
+// DataRefs returns the references held in the node's data: a join's parts
+// and fasteners, a drill's target part and a fastener's join. Zero IDs are
+// omitted.
+func (n *Node) DataRefs() []DataRef {
+	var refs []DataRef
+	add := func(field string, id NodeID) {
+		if !id.IsZero() {
+			refs = append(refs, DataRef{Field: field, ID: id})
+		}
+	}
+	switch d := n.Data.(type) {
+	case JoinData:
+		add("part_a", d.PartA)
+		add("part_b", d.PartB)
+		for _, fid := range d.Fasteners {
+			add("fasteners", fid)
+		}
+	case DrillData:
+		add("target_part", d.TargetPart)
+	case FastenerData:
+		add("join_ref", d.JoinRef)
+	}
+	return refs
+}
+
+// ---------------------------------------------------------------------------
+// Walk
+// ---------------------------------------------------------------------------
+
+// SkipChildren may be returned by a Pre hook to skip the children of the
+// current node. The Post hook is still called for the node.
+var SkipChildren = errors.New("skip children")
+
+// SkipAll may be returned by a hook to stop the walk. Walk then returns nil.
+var SkipAll = errors.New("skip all")
+
+// WalkFunc is called for each node visited by Walk. path holds the
+// ancestors of n from the root down to its parent; it is reused between
+// calls and must not be retained.
+type WalkFunc func(n *Node, path []*Node) error
+
+// Visitor holds the hooks called by Walk. Pre runs before a node's
+// children are visited, Post after. Either may be nil.
+type Visitor struct {
+	Pre  WalkFunc
+	Post WalkFunc
+}
+
+// Walk visits the nodes reachable from the graph's roots along Children
+// edges, depth first, in root and child order. A node reachable along
+// several paths (e.g. a part placed twice) is visited once per path, the
+// way tessellation instantiates it. Dangling children are skipped and a
+// node that is already on the current path is not entered again, so
+// malformed graphs cannot make Walk loop.
+//
+// If a hook returns an error other than SkipChildren or SkipAll, the walk
+// stops and Walk returns that error.
+func (g *DesignGraph) Walk(v Visitor) error {
+	for _, rid := range g.Roots {
+		if err := g.WalkFrom(rid, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WalkFrom is like Walk but starts at the node with the given ID.
+func (g *DesignGraph) WalkFrom(id NodeID, v Visitor) error {
+	err := g.walk(id, v, nil)
+	if err == SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (g *DesignGraph) walk(id NodeID, v Visitor, path []*Node) error {
+	n := g.Nodes[id]
+	if n == nil {
+		return nil
+	}
+	for _, p := range path {
+		if p.ID == id {
+			return nil // cycle; reported by Validate
+		}
+	}
+
+	skip := false
+	if v.Pre != nil {
+		switch err := v.Pre(n, path); err {
+		case nil:
+		case SkipChildren:
+			skip = true
+		default:
+			return err
+		}
+	}
+	if !skip {
+		path = append(path, n)
+		for _, cid := range n.Children {
+			if err := g.walk(cid, v, path); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+	}
+	if v.Post != nil {
+		if err := v.Post(n, path); err != nil && err != SkipChildren {
+			return err
+		}
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// Reverse edges
+// ---------------------------------------------------------------------------
+
+// graphIndex holds the reverse edges of a graph.
+type graphIndex struct {
+	parents   map[NodeID][]*Node // nodes listing the key among their Children
+	referrers map[NodeID][]*Node // nodes whose DataRefs include the key
+}
+
+// indexCache builds a graph's index once, however many goroutines read the
+// graph at the same time. AddNode replaces it rather than resetting it, so
+// a cache is never written after it has been built.
+type indexCache struct {
+	once sync.Once
+	idx  *graphIndex
+}
+
+// reverseIndex returns the graph's index. A graph not made by New or
+// decoded from JSON has no cache and builds the index on every call.
+func (g *DesignGraph) reverseIndex() *graphIndex {
+	if g.index == nil {
+		return g.buildIndex()
+	}
+	g.index.once.Do(func() { g.index.idx = g.buildIndex() })
+	return g.index.idx
+}
+
+func (g *DesignGraph) buildIndex() *graphIndex {
+	idx := &graphIndex{
+		parents:   make(map[NodeID][]*Node),
+		referrers: make(map[NodeID][]*Node),
+	}
+	for _, n := range g.SortedNodes() {
+		for _, cid := range n.Children {
+			if !slices.Contains(idx.parents[cid], n) {
+				idx.parents[cid] = append(idx.parents[cid], n)
+			}
+		}
+		for _, ref := range n.DataRefs() {
+			if !slices.Contains(idx.referrers[ref.ID], n) {
+				idx.referrers[ref.ID] = append(idx.referrers[ref.ID], n)
+			}
+		}
+	}
+	return idx
+}
+
+// Parents returns the nodes that list id among their Children, in
+// canonical order. For a part this is the placements that position it.
+func (g *DesignGraph) Parents(id NodeID) []*Node {
+	return slices.Clone(g.reverseIndex().parents[id])
+}
+
+// Referrers returns the nodes whose data references id, in canonical
+// order: the joins that connect a part, the drills that target it, the
+// join a fastener belongs to, and so on.
+func (g *DesignGraph) Referrers(id NodeID) []*Node {
+	return slices.Clone(g.reverseIndex().referrers[id])
+}
+
+// Ancestors returns every node from which id can be reached along Children
+// edges, nearest first, each once. Filtering for NodeGroup answers which
+// assemblies contain a part.
+func (g *DesignGraph) Ancestors(id NodeID) []*Node {
+	idx := g.reverseIndex()
+	seen := map[NodeID]bool{id: true}
+	var out []*Node
+	queue := []NodeID{id}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, p := range idx.parents[cur] {
+			if !seen[p.ID] {
+				seen[p.ID] = true
+				out = append(out, p)
+				queue = append(queue, p.ID)
+			}
+		}
+	}
+	return out
+}
+
+// ---------------------------------------------------------------------------
+// Topological order
+// ---------------------------------------------------------------------------
+
+// TopoOrder returns all nodes ordered so that every node comes after the
+// nodes it depends on: its Children and the targets of its DataRefs. Parts
+// therefore precede their placements, placements their assemblies, and
+// fasteners the joins that list them. Ties are broken in canonical order.
+// Dangling references are ignored; a cycle is an error.
+//
+// A fastener's join_ref points back at its join, which lists the fastener
+// in turn; that back reference is not treated as a dependency.
+func (g *DesignGraph) TopoOrder() ([]*Node, error) {
+	order, cycle := g.topoOrder(topoDeps)
+	if cycle != nil {
+		return nil, fmt.Errorf("graph: cycle through node %s", cycle.Label())
+	}
+	return order, nil
+}
+
+// topoOrder implements TopoOrder over the dependencies returned by deps. If
+// the graph has a cycle, it returns a node on the cycle instead of an
+// order.
+func (g *DesignGraph) topoOrder(deps func(*Node) []NodeID) ([]*Node, *Node) {
+	nodes := g.SortedNodes()
+	rank := make(map[NodeID]int, len(nodes))
+	for i, n := range nodes {
+		rank[n.ID] = i
+	}
+
+	// pending counts unresolved dependencies; dependents is the reverse.
+	pending := make([]int, len(nodes))
+	dependents := make([][]int, len(nodes))
+	for i, n := range nodes {
+		for _, dep := range deps(n) {
+			j, ok := rank[dep]
+			if !ok {
+				continue
+			}
+			pending[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	// Kahn's algorithm, always taking the ready node that sorts first.
+	var ready []int
+	for i := range nodes {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	order := make([]*Node, 0, len(nodes))
+	for len(ready) > 0 {
+		slices.Sort(ready)
+		i := ready[0]
+		ready = ready[1:]
+		order = append(order, nodes[i])
+		for _, j := range dependents[i] {
+			pending[j]--
+			if pending[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+
+	if len(order) != len(nodes) {
+		return nil, cycleNode(nodes, rank, pending, deps)
+	}
+	return order, nil
+}
+
+// cycleNode returns a node on a cycle among the nodes Kahn's algorithm
+// could not order. Following unresolved dependencies from any such node
+// must eventually revisit one.
+func cycleNode(nodes []*Node, rank map[NodeID]int, pending []int, deps func(*Node) []NodeID) *Node {
+	i := slices.IndexFunc(pending, func(p int) bool { return p > 0 })
+	seen := make(map[int]bool)
+	for !seen[i] {
+		seen[i] = true
+		for _, dep := range deps(nodes[i]) {
+			if j, ok := rank[dep]; ok && pending[j] > 0 {
+				i = j
+				break
+			}
+		}
+	}
+	return nodes[i]
+}
+
+// Topological returns an iterator over the nodes in TopoOrder. If the graph
+// has a cycle it yields a single nil node with the error.
+func (g *DesignGraph) Topological() iter.Seq2[*Node, error] {
+	return func(yield func(*Node, error) bool) {
+		order, err := g.TopoOrder()
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for _, n := range order {
+			if !yield(n, nil) {
+				return
+			}
+		}
+	}
+}
+
+// topoDeps returns the IDs n depends on for topological ordering.
+func topoDeps(n *Node) []NodeID {
+	deps := slices.Clone(n.Children)
+	for _, ref := range n.DataRefs() {
+		if ref.Field == "join_ref" {
+			continue
+		}
+		deps = append(deps, ref.ID)
+	}
+	return deps
+}
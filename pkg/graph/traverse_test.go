@@ -0,0 +1,230 @@
+package graph
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// traverseTestGraph builds a cabinet with one side placed twice, a shelf,
+// a butt joint with a screw, and a drill on the shelf.
+//
+//	cabinet
+//	├── place/left  → side
+//	├── place/right → side
+//	├── place/shelf → shelf
+//	└── join (side, shelf, fasteners: screw)
+func traverseTestGraph() *DesignGraph {
+	g := New()
+	line := 0
+	add := func(n *Node) *Node {
+		line++
+		n.Source = SourceRef{Line: line, Col: 1}
+		g.AddNode(n)
+		return n
+	}
+	side := add(&Node{ID: NewNodeID("side"), Kind: NodePrimitive, Name: "side", Data: BoardData{Dimensions: Vec3{19, 720, 300}}})
+	shelf := add(&Node{ID: NewNodeID("shelf"), Kind: NodePrimitive, Name: "shelf", Data: BoardData{Dimensions: Vec3{562, 19, 300}}})
+	left := add(&Node{ID: NewNodeID("place/left"), Kind: NodeTransform, Name: "left", Children: []NodeID{side.ID}, Data: TransformData{}})
+	right := add(&Node{ID: NewNodeID("place/right"), Kind: NodeTransform, Name: "right", Children: []NodeID{side.ID}, Data: TransformData{}})
+	placeShelf := add(&Node{ID: NewNodeID("place/shelf"), Kind: NodeTransform, Name: "place-shelf", Children: []NodeID{shelf.ID}, Data: TransformData{}})
+	screw := add(&Node{ID: NewNodeID("screw"), Kind: NodeFastener, Name: "screw", Data: FastenerData{Diameter: 4, Length: 40}})
+	join := add(&Node{ID: NewNodeID("join"), Kind: NodeJoin, Name: "join", Data: JoinData{
+		PartA: shelf.ID, FaceA: FaceLeft, PartB: side.ID, FaceB: FaceRight,
+		Params: ButtJoinParams{}, Fasteners: []NodeID{screw.ID},
+	}})
+	fd := screw.Data.(FastenerData)
+	fd.JoinRef = join.ID
+	screw.Data = fd
+	g.AddNode(screw)
+	add(&Node{ID: NewNodeID("drill"), Kind: NodeDrill, Name: "drill", Data: DrillData{TargetPart: shelf.ID, Face: FaceTop, Diameter: 5}})
+	cabinet := add(&Node{ID: NewNodeID("cabinet"), Kind: NodeGroup, Name: "cabinet",
+		Children: []NodeID{left.ID, right.ID, placeShelf.ID, join.ID}, Data: GroupData{}})
+	g.AddRoot(cabinet.ID)
+	return g
+}
+
+func nodeNames(nodes []*Node) string {
+	s := make([]string, len(nodes))
+	for i, n := range nodes {
+		s[i] = n.Name
+	}
+	return strings.Join(s, " ")
+}
+
+func TestWalkPrePostOrder(t *testing.T) {
+	g := traverseTestGraph()
+	var events []string
+	err := g.Walk(Visitor{
+		Pre: func(n *Node, path []*Node) error {
+			events = append(events, "+"+n.Name)
+			return nil
+		},
+		Post: func(n *Node, path []*Node) error {
+			events = append(events, "-"+n.Name)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	want := "+cabinet +left +side -side -left +right +side -side -right " +
+		"+place-shelf +shelf -shelf -place-shelf +join -join -cabinet"
+	if got := strings.Join(events, " "); got != want {
+		t.Errorf("events:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestWalkPathAndSkip(t *testing.T) {
+	g := traverseTestGraph()
+	var visited []string
+	err := g.Walk(Visitor{Pre: func(n *Node, path []*Node) error {
+		if n.Name == "side" {
+			visited = append(visited, nodeNames(path)+" > side")
+		}
+		if n.Name == "place-shelf" {
+			return SkipChildren
+		}
+		if n.Name == "shelf" {
+			t.Error("children of a skipped node should not be visited")
+		}
+		return nil
+	}})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if got := strings.Join(visited, ", "); got != "cabinet left > side, cabinet right > side" {
+		t.Errorf("paths to side: %s", got)
+	}
+}
+
+func TestWalkStops(t *testing.T) {
+	g := traverseTestGraph()
+	count := 0
+	err := g.Walk(Visitor{Pre: func(n *Node, path []*Node) error {
+		count++
+		if n.Name == "side" {
+			return SkipAll
+		}
+		return nil
+	}})
+	if err != nil || count != 3 {
+		t.Errorf("SkipAll: err = %v, visited %d nodes, want nil and 3", err, count)
+	}
+
+	boom := errors.New("boom")
+	err = g.Walk(Visitor{Post: func(n *Node, path []*Node) error { return boom }})
+	if err != boom {
+		t.Errorf("expected hook error to be returned, got %v", err)
+	}
+}
+
+func TestParentsReferrersAncestors(t *testing.T) {
+	g := traverseTestGraph()
+	side := NewNodeID("side")
+	shelf := NewNodeID("shelf")
+
+	if got := nodeNames(g.Parents(side)); got != "left right" {
+		t.Errorf("Parents(side) = %s", got)
+	}
+	if got := nodeNames(g.Referrers(side)); got != "join" {
+		t.Errorf("Referrers(side) = %s", got)
+	}
+	if got := nodeNames(g.Referrers(shelf)); got != "join drill" {
+		t.Errorf("Referrers(shelf) = %s", got)
+	}
+	if got := nodeNames(g.Referrers(NewNodeID("screw"))); got != "join" {
+		t.Errorf("Referrers(screw) = %s", got)
+	}
+	if got := nodeNames(g.Ancestors(side)); got != "left right cabinet" {
+		t.Errorf("Ancestors(side) = %s", got)
+	}
+
+	// AddNode invalidates the index.
+	extra := &Node{ID: NewNodeID("place/extra"), Kind: NodeTransform, Name: "extra", Children: []NodeID{side}, Source: SourceRef{Line: 99}}
+	g.AddNode(extra)
+	if got := nodeNames(g.Parents(side)); got != "left right extra" {
+		t.Errorf("Parents(side) after AddNode = %s", got)
+	}
+}
+
+func TestReverseIndexConcurrentReaders(t *testing.T) {
+	// The index is built lazily by readers; run with -race.
+	g := traverseTestGraph()
+	side := NewNodeID("side")
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := nodeNames(g.Parents(side)); got != "left right" {
+				t.Errorf("Parents(side) = %s", got)
+			}
+			g.Referrers(side)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTopoOrder(t *testing.T) {
+	g := traverseTestGraph()
+	order, err := g.TopoOrder()
+	if err != nil {
+		t.Fatalf("TopoOrder: %v", err)
+	}
+	pos := make(map[string]int)
+	for i, n := range order {
+		pos[n.Name] = i
+	}
+	for _, edge := range [][2]string{
+		{"side", "left"}, {"side", "right"}, {"shelf", "place-shelf"},
+		{"left", "cabinet"}, {"join", "cabinet"}, {"screw", "join"},
+		{"side", "join"}, {"shelf", "drill"},
+	} {
+		if pos[edge[0]] > pos[edge[1]] {
+			t.Errorf("%s should come before %s in %s", edge[0], edge[1], nodeNames(order))
+		}
+	}
+
+	var iterated []*Node
+	for n, err := range g.Topological() {
+		if err != nil {
+			t.Fatalf("Topological: %v", err)
+		}
+		iterated = append(iterated, n)
+	}
+	if nodeNames(iterated) != nodeNames(order) {
+		t.Errorf("iterator order %s differs from TopoOrder %s", nodeNames(iterated), nodeNames(order))
+	}
+}
+
+func TestTopoOrderCycle(t *testing.T) {
+	g := New()
+	a, b, c := NewNodeID("a"), NewNodeID("b"), NewNodeID("c")
+	g.AddNode(&Node{ID: a, Kind: NodeGroup, Name: "a", Children: []NodeID{b}})
+	g.AddNode(&Node{ID: b, Kind: NodeGroup, Name: "b", Children: []NodeID{a}})
+	g.AddNode(&Node{ID: c, Kind: NodeGroup, Name: "c", Children: []NodeID{a}})
+
+	_, err := g.TopoOrder()
+	if err == nil {
+		t.Fatal("expected a cycle error")
+	}
+	if strings.Contains(err.Error(), `"c"`) {
+		t.Errorf("error should name a node on the cycle, not %v", err)
+	}
+
+	for n, err := range g.Topological() {
+		if n != nil || err == nil {
+			t.Errorf("expected the iterator to yield only the error, got %v, %v", n, err)
+		}
+	}
+
+	// Walk must terminate on cyclic graphs.
+	g.AddRoot(c)
+	count := 0
+	_ = g.Walk(Visitor{Pre: func(n *Node, path []*Node) error { count++; return nil }})
+	if count != 3 {
+		t.Errorf("expected 3 visits on cyclic graph, got %d", count)
+	}
+}
@@ -42,6 +42,22 @@ type SourceRef struct {
 	FormID string `json:"form_id"`        // unique identifier for the S-expression
 }
 
+// IsZero reports whether the SourceRef carries no location.
+func (r SourceRef) IsZero() bool {
+	return r.Line == 0
+}
+
+func (r SourceRef) String() string {
+	if r.IsZero() {
+		return "unknown location"
+	}
+	loc := fmt.Sprintf("line %d, col %d", r.Line, r.Col)
+	if r.File != "" {
+		return r.File + ":" + loc
+	}
+	return loc
+}
+
 // Vec3 is a 3D vector for dimensions, positions, and directions.
 type Vec3 struct {
 	X, Y, Z float64
@@ -52,11 +68,26 @@ func (v Vec3) Add(other Vec3) Vec3 {
 	return Vec3{v.X + other.X, v.Y + other.Y, v.Z + other.Z}
 }
 
+// Sub returns v - other.
+func (v Vec3) Sub(other Vec3) Vec3 {
+	return Vec3{v.X - other.X, v.Y - other.Y, v.Z - other.Z}
+}
+
 // Scale returns v * s.
 func (v Vec3) Scale(s float64) Vec3 {
 	return Vec3{v.X * s, v.Y * s, v.Z * s}
 }
 
+// Dot returns the dot product of v and other.
+func (v Vec3) Dot(other Vec3) float64 {
+	return v.X*other.X + v.Y*other.Y + v.Z*other.Z
+}
+
+// Cross returns the cross product of v and other.
+func (v Vec3) Cross(other Vec3) Vec3 {
+	return Vec3{v.Y*other.Z - v.Z*other.Y, v.Z*other.X - v.X*other.Z, v.X*other.Y - v.Y*other.X}
+}
+
 func (v Vec3) String() string {
 	return fmt.Sprintf("(%.1f, %.1f, %.1f)", v.X, v.Y, v.Z)
 }
@@ -83,6 +114,21 @@ func (a Axis) String() string {
 	}
 }
 
+// Unit returns the unit vector along the axis, or the zero vector for an
+// invalid axis.
+func (a Axis) Unit() Vec3 {
+	switch a {
+	case AxisX:
+		return Vec3{1, 0, 0}
+	case AxisY:
+		return Vec3{0, 1, 0}
+	case AxisZ:
+		return Vec3{0, 0, 1}
+	default:
+		return Vec3{}
+	}
+}
+
 // FaceID identifies one of the six faces of a rectangular part.
 type FaceID string
 
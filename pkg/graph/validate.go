@@ -1,6 +1,9 @@
 package graph
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 // ValidationSeverity indicates whether a validation finding blocks evaluation
 // or is merely informational.
@@ -95,64 +98,19 @@ func ValidateAll(g *DesignGraph) ValidationResult {
 	return result
 }
 
-// validateDAG checks for cycles using DFS with 3-color marking.
-// White (0) = unvisited, gray (1) = in current DFS path, black (2) = fully explored.
-// If we encounter a gray node during traversal, we have found a cycle.
+// validateDAG checks that the graph has no cycles along Children edges.
+// Data references such as a join's parts are not edges of the hierarchy
+// and cannot form a cycle here. One cycle error is sufficient.
 func validateDAG(g *DesignGraph) []ValidationError {
-	const (
-		white = iota
-		gray
-		black
-	)
-
-	color := make(map[NodeID]int) // default zero = white
-	var errs []ValidationError
-
-	var visit func(id NodeID) bool // returns true if cycle found
-	visit = func(id NodeID) bool {
-		switch color[id] {
-		case black:
-			return false
-		case gray:
-			errs = append(errs, ValidationError{
-				NodeID:   id,
-				Message:  fmt.Sprintf("cycle detected: node %s is part of a cycle", id.Short()),
-				Severity: SeverityError,
-			})
-			return true
-		}
-
-		color[id] = gray
-
-		node, ok := g.Nodes[id]
-		if !ok {
-			// Dangling reference; handled by validateReferences.
-			color[id] = black
-			return false
-		}
-
-		// Walk Children edges.
-		for _, childID := range node.Children {
-			if visit(childID) {
-				return true
-			}
-		}
-
-		color[id] = black
-		return false
-	}
-
-	// Start DFS from every node to catch disconnected components.
-	for id := range g.Nodes {
-		if color[id] == white {
-			if visit(id) {
-				// One cycle error is sufficient; stop early.
-				break
-			}
-		}
+	_, cycle := g.topoOrder(func(n *Node) []NodeID { return n.Children })
+	if cycle == nil {
+		return nil
 	}
-
-	return errs
+	return []ValidationError{{
+		NodeID:   cycle.ID,
+		Message:  fmt.Sprintf("cycle detected: node %s is part of a cycle", cycle.ID.Short()),
+		Severity: SeverityError,
+	}}
 }
 
 // validateReferences checks that every NodeID referenced anywhere in the graph
@@ -160,7 +118,7 @@ func validateDAG(g *DesignGraph) []ValidationError {
 func validateReferences(g *DesignGraph) []ValidationError {
 	var errs []ValidationError
 
-	for _, node := range g.Nodes {
+	for _, node := range g.SortedNodes() {
 		// Check Children references.
 		for _, childID := range node.Children {
 			if _, ok := g.Nodes[childID]; !ok {
@@ -236,7 +194,13 @@ func validateNames(g *DesignGraph) []ValidationError {
 	var errs []ValidationError
 
 	// Check that every NameIndex entry references an existing node.
-	for name, id := range g.NameIndex {
+	names := make([]string, 0, len(g.NameIndex))
+	for name := range g.NameIndex {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		id := g.NameIndex[name]
 		if _, ok := g.Nodes[id]; !ok {
 			errs = append(errs, ValidationError{
 				Message:  fmt.Sprintf("name index entry %q references non-existent node %s", name, id.Short()),
@@ -248,13 +212,17 @@ func validateNames(g *DesignGraph) []ValidationError {
 	// Check injectivity: build a reverse map from NodeID to name, looking at
 	// actual node Name fields. If two nodes share the same non-empty Name, error.
 	nameToNodes := make(map[string][]NodeID)
-	for id, node := range g.Nodes {
+	var nodeNames []string // in canonical order of first use
+	for _, node := range g.SortedNodes() {
 		if node.Name != "" {
-			nameToNodes[node.Name] = append(nameToNodes[node.Name], id)
+			if _, seen := nameToNodes[node.Name]; !seen {
+				nodeNames = append(nodeNames, node.Name)
+			}
+			nameToNodes[node.Name] = append(nameToNodes[node.Name], node.ID)
 		}
 	}
-	for name, ids := range nameToNodes {
-		if len(ids) > 1 {
+	for _, name := range nodeNames {
+		if ids := nameToNodes[name]; len(ids) > 1 {
 			errs = append(errs, ValidationError{
 				Message:  fmt.Sprintf("duplicate name %q assigned to %d nodes", name, len(ids)),
 				Severity: SeverityError,
@@ -315,44 +283,23 @@ func validateRoots(g *DesignGraph) []ValidationError {
 
 		// Also traverse join/drill/fastener data references to reach
 		// nodes that are only referenced via data fields.
-		switch d := node.Data.(type) {
-		case JoinData:
-			if !d.PartA.IsZero() && !reachable[d.PartA] {
-				reachable[d.PartA] = true
-				queue = append(queue, d.PartA)
-			}
-			if !d.PartB.IsZero() && !reachable[d.PartB] {
-				reachable[d.PartB] = true
-				queue = append(queue, d.PartB)
-			}
-			for _, fid := range d.Fasteners {
-				if !reachable[fid] {
-					reachable[fid] = true
-					queue = append(queue, fid)
-				}
-			}
-		case DrillData:
-			if !d.TargetPart.IsZero() && !reachable[d.TargetPart] {
-				reachable[d.TargetPart] = true
-				queue = append(queue, d.TargetPart)
-			}
-		case FastenerData:
-			if !d.JoinRef.IsZero() && !reachable[d.JoinRef] {
-				reachable[d.JoinRef] = true
-				queue = append(queue, d.JoinRef)
+		for _, ref := range node.DataRefs() {
+			if !reachable[ref.ID] {
+				reachable[ref.ID] = true
+				queue = append(queue, ref.ID)
 			}
 		}
 	}
 
 	// Report any unreachable nodes as warnings.
-	for id, node := range g.Nodes {
-		if !reachable[id] {
+	for _, node := range g.SortedNodes() {
+		if !reachable[node.ID] {
 			name := node.Name
 			if name == "" {
-				name = id.Short()
+				name = node.ID.Short()
 			}
 			errs = append(errs, ValidationError{
-				NodeID:   id,
+				NodeID:   node.ID,
 				Message:  fmt.Sprintf("node %q is not reachable from any root (orphan)", name),
 				Severity: SeverityWarning,
 			})
@@ -367,7 +314,7 @@ func validateRoots(g *DesignGraph) []ValidationError {
 func validateFaceIDs(g *DesignGraph) []ValidationError {
 	var errs []ValidationError
 
-	for _, node := range g.Nodes {
+	for _, node := range g.SortedNodes() {
 		if jd, ok := node.Data.(JoinData); ok {
 			if !ValidFaceIDs[jd.FaceA] {
 				errs = append(errs, ValidationError{
@@ -395,7 +342,7 @@ func validateFaceIDs(g *DesignGraph) []ValidationError {
 func validateJoinParts(g *DesignGraph) []ValidationError {
 	var errs []ValidationError
 
-	for _, node := range g.Nodes {
+	for _, node := range g.SortedNodes() {
 		jd, ok := node.Data.(JoinData)
 		if !ok {
 			continue
@@ -0,0 +1,181 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+)
+
+// ContactAngleTolerance is how far in degrees two joined faces may be from
+// exactly opposed before they are reported.
+const ContactAngleTolerance = 0.1
+
+// jointContact is a joint resolved to world space for one pair of placed
+// instances of its parts.
+type jointContact struct {
+	a, b         Placement
+	faceA, faceB Face    // world space
+	angle        float64 // degrees between face B's normal and the reverse of face A's
+	gap          float64 // distance from face A's plane to face B's center along A's normal
+	region       []Vec3  // contact polygon on face A, in world space
+	area         float64 // area of region
+}
+
+func (c jointContact) opposed() bool { return c.angle <= ContactAngleTolerance }
+
+// resolveJoint resolves the faces of a joint to world space. Parts placed
+// more than once are paired up by the closest face centers, since a joint
+// names parts rather than instances. The boolean result is false if either
+// part is not a placed board or a face is invalid; those cases are left
+// to other checks.
+func resolveJoint(g *DesignGraph, jd JoinData) (jointContact, bool) {
+	na, nb := g.Nodes[jd.PartA], g.Nodes[jd.PartB]
+	if na == nil || nb == nil {
+		return jointContact{}, false
+	}
+	bda, okA := na.Data.(BoardData)
+	bdb, okB := nb.Data.(BoardData)
+	if !okA || !okB {
+		return jointContact{}, false
+	}
+	la, okA := BoardFace(bda.Dimensions, jd.FaceA)
+	lb, okB := BoardFace(bdb.Dimensions, jd.FaceB)
+	if !okA || !okB {
+		return jointContact{}, false
+	}
+
+	var best jointContact
+	bestDist := math.Inf(1)
+	for _, pa := range placedOnly(g.PlacementsOf(jd.PartA)) {
+		fa := la.Transformed(pa.Transform)
+		for _, pb := range placedOnly(g.PlacementsOf(jd.PartB)) {
+			fb := lb.Transformed(pb.Transform)
+			d := fb.Center.Sub(fa.Center)
+			if dist := d.Dot(d); dist < bestDist {
+				bestDist = dist
+				best = jointContact{a: pa, b: pb, faceA: fa, faceB: fb}
+			}
+		}
+	}
+	if math.IsInf(bestDist, 1) {
+		return jointContact{}, false
+	}
+
+	cos := math.Max(-1, math.Min(1, -best.faceA.Normal.Dot(best.faceB.Normal)))
+	best.angle = math.Acos(cos) * 180 / math.Pi
+	best.gap = best.faceB.Center.Sub(best.faceA.Center).Dot(best.faceA.Normal)
+	best.region, best.area = contactRegion(best.faceA, best.faceB)
+	return best, true
+}
+
+// placedOnly drops instances that are not inside a placement and so have
+// no meaningful position.
+func placedOnly(ps []Placement) []Placement {
+	var out []Placement
+	for _, p := range ps {
+		if p.Node() != p.Part {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// contactRegion projects face b onto the plane of face a and clips it to
+// a, returning the overlap polygon in world space and its area.
+func contactRegion(a, b Face) ([]Vec3, float64) {
+	type pt struct{ u, v float64 }
+	var poly []pt
+	for _, c := range b.Corners() {
+		d := c.Sub(a.Center)
+		poly = append(poly, pt{d.Dot(a.U), d.Dot(a.V)})
+	}
+
+	// Sutherland–Hodgman clipping against the four edges of face a, each
+	// given as inside(p) >= 0.
+	edges := []func(p pt) float64{
+		func(p pt) float64 { return p.u + a.HalfU },
+		func(p pt) float64 { return a.HalfU - p.u },
+		func(p pt) float64 { return p.v + a.HalfV },
+		func(p pt) float64 { return a.HalfV - p.v },
+	}
+	for _, inside := range edges {
+		if len(poly) == 0 {
+			break
+		}
+		var out []pt
+		prev := poly[len(poly)-1]
+		for _, cur := range poly {
+			dp, dc := inside(prev), inside(cur)
+			if (dp >= 0) != (dc >= 0) {
+				t := dp / (dp - dc)
+				out = append(out, pt{prev.u + t*(cur.u-prev.u), prev.v + t*(cur.v-prev.v)})
+			}
+			if dc >= 0 {
+				out = append(out, cur)
+			}
+			prev = cur
+		}
+		poly = out
+	}
+
+	var area float64
+	region := make([]Vec3, len(poly))
+	for i, p := range poly {
+		q := poly[(i+1)%len(poly)]
+		area += p.u*q.v - q.u*p.v
+		region[i] = a.Center.Add(a.U.Scale(p.u)).Add(a.V.Scale(p.v))
+	}
+	return region, math.Abs(area) / 2
+}
+
+// jointClearance returns the clearance of a joint, falling back to the
+// graph default if the joint sets none. An explicit zero demands contact.
+func jointClearance(g *DesignGraph, jd JoinData) float64 {
+	if jd.Clearance != nil {
+		return *jd.Clearance
+	}
+	return g.Defaults.Clearance
+}
+
+// problem describes why the faces do not meet, or returns "" if they do.
+func (c jointContact) problem(clearance float64) string {
+	switch {
+	case !c.opposed():
+		return fmt.Sprintf("faces are not opposed (%.4g° off)", c.angle)
+	case c.gap > clearance+InterferenceTolerance:
+		return fmt.Sprintf("gap of %.4g mm exceeds the clearance of %.4g mm", c.gap, clearance)
+	case c.gap < -InterferenceTolerance:
+		return fmt.Sprintf("faces are not coplanar: face B is %.4g mm behind face A", -c.gap)
+	case c.area <= InterferenceTolerance*InterferenceTolerance:
+		return "faces do not overlap"
+	}
+	return ""
+}
+
+// validateJointContact checks that the faces of every butt joint between
+// placed boards actually meet: they must be opposed, no further apart than
+// the joint's clearance, not interpenetrating, and overlap in a contact
+// area.
+func validateJointContact(g *DesignGraph) []ValidationError {
+	var errs []ValidationError
+	for _, n := range g.Joins() {
+		jd := n.Data.(JoinData)
+		if jd.Kind != JoinButt {
+			continue
+		}
+		c, ok := resolveJoint(g, jd)
+		if !ok {
+			continue
+		}
+		problem := c.problem(jointClearance(g, jd))
+		if problem == "" {
+			continue
+		}
+		errs = append(errs, ValidationError{
+			NodeID: n.ID,
+			Message: fmt.Sprintf("butt joint between %s :%s and %s :%s: %s",
+				c.a.Label(), jd.FaceA, c.b.Label(), jd.FaceB, problem),
+			Severity: SeverityError,
+		})
+	}
+	return errs
+}
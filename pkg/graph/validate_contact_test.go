@@ -0,0 +1,152 @@
+package graph
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// cornerGraph places the front and left panels of a box, with the left
+// panel at leftAt, and joins front :faceA to left :front. The joint's
+// clearance is nil to use the graph default.
+func cornerGraph(leftAt Vec3, faceA FaceID, clearance *float64) *DesignGraph {
+	g := interferenceGraph(
+		placed{part: "front", dims: Vec3{400, 200, 19}},
+		placed{part: "left", dims: Vec3{19, 200, 262}, at: leftAt},
+	)
+	join := &Node{ID: NewNodeID("join"), Kind: NodeJoin, Source: SourceRef{Line: 3, Col: 1}, Data: JoinData{
+		Kind:  JoinButt,
+		PartA: NewNodeID("defpart/front"), FaceA: faceA,
+		PartB: NewNodeID("defpart/left"), FaceB: FaceFront,
+		Clearance: clearance,
+		Params:    ButtJoinParams{},
+	}}
+	g.AddNode(join)
+	box := g.Nodes[NewNodeID("assembly/box")]
+	box.Children = append(box.Children, join.ID)
+	g.AddNode(box)
+	return g
+}
+
+func contactErrors(g *DesignGraph) []string {
+	var out []string
+	for _, e := range ValidateAll(g).Errors {
+		if strings.HasPrefix(e.Message, "butt joint between") {
+			out = append(out, e.Message)
+		}
+	}
+	return out
+}
+
+func TestJointContact(t *testing.T) {
+	zero, one := 0.0, 1.0
+	tests := []struct {
+		name      string
+		leftAt    Vec3
+		faceA     FaceID
+		clearance *float64
+		want      string // substring of the single expected error, or "" for none
+	}{
+		{"touching", Vec3{0, 0, 19}, FaceBack, nil, ""},
+		{"within default clearance", Vec3{0, 0, 19.2}, FaceBack, nil, ""},
+		{"beyond default clearance", Vec3{0, 0, 19.5}, FaceBack, nil, "gap of 0.5 mm exceeds the clearance of 0.25 mm"},
+		{"within joint clearance", Vec3{0, 0, 19.5}, FaceBack, &one, ""},
+		{"zero joint clearance", Vec3{0, 0, 19.2}, FaceBack, &zero, "gap of 0.2 mm exceeds the clearance of 0 mm"},
+		{"touching with zero clearance", Vec3{0, 0, 19}, FaceBack, &zero, ""},
+		{"a meter apart", Vec3{0, 0, 1019}, FaceBack, nil, "gap of 1000 mm"},
+		{"wrong face", Vec3{0, 0, 19}, FaceLeft, nil, "faces are not opposed (90° off)"},
+		{"same direction", Vec3{0, 0, 19}, FaceFront, nil, "faces are not opposed (180° off)"},
+		{"interpenetrating", Vec3{0, 0, 10}, FaceBack, nil, "not coplanar: face B is 9 mm behind face A"},
+		{"coplanar but apart", Vec3{500, 0, 19}, FaceBack, nil, "faces do not overlap"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := contactErrors(cornerGraph(tt.leftAt, tt.faceA, tt.clearance))
+			if tt.want == "" {
+				if len(errs) != 0 {
+					t.Errorf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0], tt.want) {
+				t.Errorf("errors = %v, want one containing %q", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestJointContactMessage(t *testing.T) {
+	errs := contactErrors(cornerGraph(Vec3{0, 0, 1019}, FaceBack, nil))
+	want := `butt joint between "front" (placed at line 1, col 1) :back and "left" (placed at line 2, col 1) :front: gap of 1000 mm exceeds the clearance of 0.25 mm`
+	if len(errs) != 1 || errs[0] != want {
+		t.Errorf("errors = %q, want %q", errs, want)
+	}
+}
+
+func TestJointContactRegion(t *testing.T) {
+	g := cornerGraph(Vec3{0, 0, 19}, FaceBack, nil)
+	c, ok := resolveJoint(g, g.Nodes[NewNodeID("join")].Data.(JoinData))
+	if !ok {
+		t.Fatal("expected the joint to resolve")
+	}
+	if math.Abs(c.area-19*200) > 1e-9 {
+		t.Errorf("contact area = %v, want %v", c.area, 19*200)
+	}
+	for _, p := range c.region {
+		if p.Z != 19 || p.X < 0 || p.X > 19 || p.Y < 0 || p.Y > 200 {
+			t.Errorf("contact point %v outside the expected region", p)
+		}
+	}
+}
+
+func TestJointContactPicksClosestInstances(t *testing.T) {
+	// The side is placed on both ends of the shelf; the joint names the
+	// shelf's left face, which only the first side touches.
+	g := interferenceGraph(
+		placed{part: "side", dims: Vec3{19, 720, 300}},
+		placed{part: "side", dims: Vec3{19, 720, 300}, at: Vec3{519, 0, 0}},
+		placed{part: "shelf", dims: Vec3{500, 19, 300}, at: Vec3{19, 300, 0}},
+	)
+	join := &Node{ID: NewNodeID("join"), Kind: NodeJoin, Data: JoinData{
+		Kind:  JoinButt,
+		PartA: NewNodeID("defpart/shelf"), FaceA: FaceLeft,
+		PartB: NewNodeID("defpart/side"), FaceB: FaceRight,
+		Params: ButtJoinParams{},
+	}}
+	g.AddNode(join)
+	if errs := contactErrors(g); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
+
+func TestBoardFace(t *testing.T) {
+	dims := Vec3{400, 200, 19}
+	f, ok := BoardFace(dims, FaceBack)
+	if !ok {
+		t.Fatal("expected a face")
+	}
+	if f.Normal != (Vec3{0, 0, 1}) || f.Center != (Vec3{200, 100, 19}) || f.HalfU != 200 || f.HalfV != 100 {
+		t.Errorf("back face = %+v", f)
+	}
+	for _, face := range []FaceID{FaceTop, FaceBottom, FaceLeft, FaceRight, FaceFront, FaceBack} {
+		f, _ := BoardFace(dims, face)
+		// The normal points away from the board's center.
+		if f.Center.Sub(dims.Scale(0.5)).Dot(f.Normal) <= 0 {
+			t.Errorf("%s: normal %v points inward", face, f.Normal)
+		}
+		for _, c := range f.Corners() {
+			if c.X < 0 || c.X > dims.X || c.Y < 0 || c.Y > dims.Y || c.Z < 0 || c.Z > dims.Z {
+				t.Errorf("%s: corner %v outside the board", face, c)
+			}
+		}
+	}
+
+	rot := Vec3{0, 90, 0}
+	wf := f.Transformed(Transform{Rotation: rot})
+	if wf.Normal != (Vec3{1, 0, 0}) {
+		t.Errorf("back face rotated 90° about Y has normal %v, want +X", wf.Normal)
+	}
+	if _, ok := BoardFace(dims, "side"); ok {
+		t.Error("expected an unknown face to fail")
+	}
+}
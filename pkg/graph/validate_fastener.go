@@ -0,0 +1,153 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+)
+
+// Minimum fastener distances, in multiples of the fastener diameter. End
+// distance is measured to a contact-area boundary that runs across the
+// grain of part A, edge distance to one that runs along it.
+const (
+	MinFastenerEdgeDistance = 1.5
+	MinFastenerEndDistance  = 2.0
+	MinFastenerSpacing      = 3.0
+)
+
+// fastenerPoint resolves a fastener position to world space. Positions
+// are relative to the join: they are measured along part A's local axes
+// from an origin on the contact area of face A, at the low end of its
+// longer side and midway across its shorter side. For a row of screws
+// along a joint, X or Y is then the distance from the end of the joint
+// and the other the offset from its centre line. The component along face
+// A's normal is ignored, since fasteners are driven through face A.
+func fastenerPoint(c jointContact, local Face, pos Vec3) Vec3 {
+	fa := c.faceA
+	axes := [2]Vec3{fa.U, fa.V}
+	lo := [2]float64{math.Inf(1), math.Inf(1)}
+	hi := [2]float64{math.Inf(-1), math.Inf(-1)}
+	for _, p := range c.region {
+		for i, ax := range axes {
+			t := p.Sub(fa.Center).Dot(ax)
+			lo[i], hi[i] = math.Min(lo[i], t), math.Max(hi[i], t)
+		}
+	}
+	long := 0
+	if hi[1]-lo[1] > hi[0]-lo[0] {
+		long = 1
+	}
+	offsets := [2]float64{pos.Dot(local.U), pos.Dot(local.V)}
+	p := fa.Center
+	for i, ax := range axes {
+		origin := (lo[i] + hi[i]) / 2
+		if i == long {
+			origin = lo[i]
+		}
+		p = p.Add(ax.Scale(origin + offsets[i]))
+	}
+	return p
+}
+
+// boundaryDistance returns the distance from p to the nearest edge of a
+// convex polygon, the direction of that edge, and whether p lies inside
+// the polygon. The polygon lies in a plane with the given normal.
+func boundaryDistance(poly []Vec3, normal, p Vec3) (dist float64, dir Vec3, inside bool) {
+	dist = math.Inf(1)
+	pos, neg := false, false
+	for i, a := range poly {
+		b := poly[(i+1)%len(poly)]
+		ab := b.Sub(a)
+		l2 := ab.Dot(ab)
+		if l2 == 0 {
+			continue
+		}
+		side := ab.Cross(p.Sub(a)).Dot(normal)
+		pos = pos || side > 1e-9
+		neg = neg || side < -1e-9
+
+		t := math.Max(0, math.Min(1, p.Sub(a).Dot(ab)/l2))
+		d := p.Sub(a.Add(ab.Scale(t)))
+		if dl := math.Sqrt(d.Dot(d)); dl < dist {
+			dist, dir = dl, ab.Scale(1/math.Sqrt(l2))
+		}
+	}
+	return dist, dir, !(pos && neg)
+}
+
+// validateFastenerPlacement checks where the fasteners of each butt joint
+// between placed boards land on the contact area of the two faces. It
+// warns about fasteners outside the area, closer to its edges or ends than
+// MinFastenerEdgeDistance or MinFastenerEndDistance diameters, and closer
+// to each other than MinFastenerSpacing diameters. Joints whose faces do
+// not meet are reported by validateJointContact and skipped here.
+func validateFastenerPlacement(g *DesignGraph) []ValidationWarning {
+	var warnings []ValidationWarning
+	for _, n := range g.Joins() {
+		jd := n.Data.(JoinData)
+		if jd.Kind != JoinButt || len(jd.Fasteners) == 0 {
+			continue
+		}
+		c, ok := resolveJoint(g, jd)
+		if !ok || c.problem(jointClearance(g, jd)) != "" {
+			continue
+		}
+		bd := c.a.Part.Data.(BoardData)
+		local, _ := BoardFace(bd.Dimensions, jd.FaceA)
+
+		type resolved struct {
+			node *Node
+			fd   FastenerData
+			p    Vec3
+		}
+		var placed []resolved
+		for _, fid := range jd.Fasteners {
+			fn := g.Nodes[fid]
+			if fn == nil {
+				continue
+			}
+			fd, ok := fn.Data.(FastenerData)
+			if !ok {
+				continue
+			}
+			p := fastenerPoint(c, local, fd.Position)
+			warn := func(format string, args ...any) {
+				warnings = append(warnings, ValidationWarning{
+					NodeID:  fn.ID,
+					Message: fmt.Sprintf("fastener at %s "+format, append([]any{fd.Position}, args...)...),
+				})
+			}
+
+			dist, dir, inside := boundaryDistance(c.region, c.faceA.Normal, p)
+			if !inside {
+				warn("lies outside the contact area of joint %s", n.ID.Short())
+				continue
+			}
+			placed = append(placed, resolved{fn, fd, p})
+
+			kind, min := "edge", MinFastenerEdgeDistance
+			if math.Abs(dir.Dot(c.a.Grain)) < 0.5 {
+				kind, min = "end", MinFastenerEndDistance
+			}
+			if dist < min*fd.Diameter-InterferenceTolerance {
+				warn("is %.4g mm from the %s of the contact area of joint %s (minimum %.4g mm for Ø%g)",
+					dist, kind, n.ID.Short(), min*fd.Diameter, fd.Diameter)
+			}
+		}
+
+		for i, a := range placed {
+			for _, b := range placed[i+1:] {
+				d := b.p.Sub(a.p)
+				gap := math.Sqrt(d.Dot(d))
+				min := MinFastenerSpacing * math.Max(a.fd.Diameter, b.fd.Diameter)
+				if gap < min-InterferenceTolerance {
+					warnings = append(warnings, ValidationWarning{
+						NodeID: b.node.ID,
+						Message: fmt.Sprintf("fasteners at %s and %s in joint %s are %.4g mm apart (minimum %.4g mm)",
+							a.fd.Position, b.fd.Position, n.ID.Short(), gap, min),
+					})
+				}
+			}
+		}
+	}
+	return warnings
+}
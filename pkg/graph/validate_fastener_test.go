@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fastenedCorner is cornerGraph with touching panels and Ø4 screws at the
+// given positions relative to the joint.
+func fastenedCorner(positions ...Vec3) *DesignGraph {
+	g := cornerGraph(Vec3{0, 0, 19}, FaceBack, nil)
+	join := g.Nodes[NewNodeID("join")]
+	jd := join.Data.(JoinData)
+	for i, p := range positions {
+		id := NewNodeID(fmt.Sprintf("screw/%d", i))
+		g.AddNode(&Node{ID: id, Kind: NodeFastener, Source: SourceRef{Line: 10 + i, Col: 1},
+			Data: FastenerData{Kind: FastenerScrew, Diameter: 4, Length: 30, Position: p, JoinRef: join.ID}})
+		jd.Fasteners = append(jd.Fasteners, id)
+	}
+	join.Data = jd
+	g.AddNode(join)
+	return g
+}
+
+func fastenerWarnings(g *DesignGraph) []string {
+	var out []string
+	for _, w := range ValidateAll(g).Warnings {
+		if strings.HasPrefix(w.Message, "fastener") {
+			out = append(out, w.Message)
+		}
+	}
+	return out
+}
+
+func TestFastenerPlacement(t *testing.T) {
+	tests := []struct {
+		name      string
+		positions []Vec3
+		want      string // substring of the single expected warning, or "" for none
+	}{
+		{"centered", []Vec3{{0, 50, 0}, {0, 150, 0}}, ""},
+		{"depth is ignored", []Vec3{{0, 100, -40}}, ""},
+		{"outside", []Vec3{{200, 100, 0}}, "fastener at (200.0, 100.0, 0.0) lies outside the contact area of joint"},
+		{"past the end", []Vec3{{0, 210, 0}}, "lies outside the contact area of joint"},
+		{"near end", []Vec3{{-6.5, 100, 0}}, "is 3 mm from the end of the contact area of joint"},
+		{"near edge", []Vec3{{0, 4, 0}}, "is 4 mm from the edge of the contact area of joint"},
+		{"crowded", []Vec3{{0, 100, 0}, {0, 108, 0}}, "are 8 mm apart (minimum 12 mm)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := fastenerWarnings(fastenedCorner(tt.positions...))
+			if tt.want == "" {
+				if len(ws) != 0 {
+					t.Errorf("unexpected warnings: %v", ws)
+				}
+				return
+			}
+			if len(ws) != 1 || !strings.Contains(ws[0], tt.want) {
+				t.Errorf("warnings = %v, want one containing %q", ws, tt.want)
+			}
+		})
+	}
+}
+
+func TestFastenerPositionsAreJoinRelative(t *testing.T) {
+	// The same positions fit a joint at the other end of the front panel.
+	g := cornerGraph(Vec3{381, 0, 19}, FaceBack, nil)
+	join := g.Nodes[NewNodeID("join")]
+	jd := join.Data.(JoinData)
+	for i, p := range []Vec3{{0, 50, 0}, {0, 150, 0}} {
+		id := NewNodeID(fmt.Sprintf("screw/%d", i))
+		g.AddNode(&Node{ID: id, Kind: NodeFastener, Data: FastenerData{Kind: FastenerScrew, Diameter: 4, Length: 30, Position: p, JoinRef: join.ID}})
+		jd.Fasteners = append(jd.Fasteners, id)
+	}
+	join.Data = jd
+	g.AddNode(join)
+	if errs := contactErrors(g); len(errs) != 0 {
+		t.Fatalf("unexpected contact errors: %v", errs)
+	}
+	if ws := fastenerWarnings(g); len(ws) != 0 {
+		t.Errorf("unexpected warnings: %v", ws)
+	}
+}
+
+func TestFastenerPlacementSkipsBrokenJoints(t *testing.T) {
+	g := cornerGraph(Vec3{0, 0, 1019}, FaceBack, nil)
+	join := g.Nodes[NewNodeID("join")]
+	jd := join.Data.(JoinData)
+	id := NewNodeID("screw")
+	g.AddNode(&Node{ID: id, Kind: NodeFastener, Data: FastenerData{Diameter: 4, Length: 30, Position: Vec3{200, 100, 0}, JoinRef: join.ID}})
+	jd.Fasteners = []NodeID{id}
+	join.Data = jd
+	g.AddNode(join)
+	if ws := fastenerWarnings(g); len(ws) != 0 {
+		t.Errorf("a joint whose faces do not meet should only get the contact error: %v", ws)
+	}
+}
+
+func TestFastenerHoleSizes(t *testing.T) {
+	tests := []struct {
+		fd               FastenerData
+		pilot, clearance float64
+	}{
+		{FastenerData{Kind: FastenerScrew, Diameter: 4}, 3, 4.5},
+		{FastenerData{Kind: FastenerScrew, Diameter: 4.2}, 3, 5},
+		{FastenerData{Kind: FastenerScrew, Diameter: 4, PilotHoleDia: 2.5, ClearanceHoleDia: 4.2}, 2.5, 4.2},
+		{FastenerData{Kind: FastenerNail, Diameter: 2.5}, 2, 0},
+		{FastenerData{Kind: FastenerDowelPin, Diameter: 8}, 8, 0},
+		{FastenerData{Kind: FastenerBolt, Diameter: 8}, 0, 9},
+	}
+	for _, tt := range tests {
+		if p, c := tt.fd.PilotHole(), tt.fd.ClearanceHole(); p != tt.pilot || c != tt.clearance {
+			t.Errorf("%s Ø%g: pilot %g, clearance %g; want %g, %g", tt.fd.Kind, tt.fd.Diameter, p, c, tt.pilot, tt.clearance)
+		}
+	}
+}
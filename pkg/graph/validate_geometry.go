@@ -1,6 +1,10 @@
 package graph
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"strings"
+)
 
 // ---------------------------------------------------------------------------
 // Tier 2 — Geometric validation (errors + warnings)
@@ -14,9 +18,13 @@ func validateGeometry(g *DesignGraph) ([]ValidationError, []ValidationWarning) {
 
 	errs = append(errs, validateNonZeroDimensions(g)...)
 	errs = append(errs, validateDuplicateJoins(g)...)
+	errs = append(errs, validateInterference(g)...)
+	errs = append(errs, validateJointContact(g)...)
 
 	fastenerWarnings := validateFastenerLength(g)
 	warnings = append(warnings, fastenerWarnings...)
+	warnings = append(warnings, validateFastenerEmbedment(g)...)
+	warnings = append(warnings, validateFastenerPlacement(g)...)
 
 	return errs, warnings
 }
@@ -25,7 +33,7 @@ func validateGeometry(g *DesignGraph) ([]ValidationError, []ValidationWarning) {
 func validateNonZeroDimensions(g *DesignGraph) []ValidationError {
 	var errs []ValidationError
 
-	for _, node := range g.Nodes {
+	for _, node := range g.SortedNodes() {
 		bd, ok := node.Data.(BoardData)
 		if !ok {
 			continue
@@ -85,7 +93,7 @@ func validateDuplicateJoins(g *DesignGraph) []ValidationError {
 	var errs []ValidationError
 	seen := make(map[joinKey]NodeID) // first join node that used this key
 
-	for _, node := range g.Nodes {
+	for _, node := range g.SortedNodes() {
 		jd, ok := node.Data.(JoinData)
 		if !ok {
 			continue
@@ -129,7 +137,7 @@ func faceThickness(bd BoardData, face FaceID) float64 {
 func validateFastenerLength(g *DesignGraph) []ValidationWarning {
 	var warnings []ValidationWarning
 
-	for _, node := range g.Nodes {
+	for _, node := range g.SortedNodes() {
 		jd, ok := node.Data.(JoinData)
 		if !ok {
 			continue
@@ -178,25 +186,96 @@ func validateFastenerLength(g *DesignGraph) []ValidationWarning {
 	return warnings
 }
 
+// validateFastenerEmbedment checks that fasteners in butt joints reach far
+// enough into part B after passing through part A. The embedment is the
+// fastener length beyond part A's thickness at face A, at most part B's
+// thickness at face B, and must meet the FastenerRule for the fastener's
+// kind both in mm and as a multiple of its diameter.
+func validateFastenerEmbedment(g *DesignGraph) []ValidationWarning {
+	var warnings []ValidationWarning
+
+	for _, node := range g.SortedNodes() {
+		jd, ok := node.Data.(JoinData)
+		if !ok || jd.Kind != JoinButt {
+			continue
+		}
+
+		partANode := g.Nodes[jd.PartA]
+		partBNode := g.Nodes[jd.PartB]
+		if partANode == nil || partBNode == nil {
+			continue // dangling references handled by Tier 1
+		}
+		bdA, okA := partANode.Data.(BoardData)
+		bdB, okB := partBNode.Data.(BoardData)
+		if !okA || !okB {
+			continue
+		}
+		throughA := faceThickness(bdA, jd.FaceA)
+		receiving := faceThickness(bdB, jd.FaceB)
+
+		for _, fastenerID := range jd.Fasteners {
+			fNode := g.Nodes[fastenerID]
+			if fNode == nil {
+				continue
+			}
+			fd, ok := fNode.Data.(FastenerData)
+			if !ok {
+				continue
+			}
+			rule, ok := g.Defaults.FastenerRule(fd.Kind)
+			if !ok {
+				continue
+			}
+
+			required, reason := rule.MinEmbedment, fmt.Sprintf("minimum embedment %.1fmm", rule.MinEmbedment)
+			if e := rule.MinThreadEngagement * fd.Diameter; e > required {
+				required, reason = e, fmt.Sprintf("thread engagement of %g × Ø%g", rule.MinThreadEngagement, fd.Diameter)
+			}
+			embedment := math.Min(fd.Length-throughA, receiving)
+
+			var msg string
+			switch {
+			case embedment <= 0:
+				msg = fmt.Sprintf("%s length %.1fmm does not reach part B through %.1fmm of part A at joint %s",
+					fd.Kind, fd.Length, throughA, node.ID.Short())
+			case required > receiving:
+				msg = fmt.Sprintf("%s needs %.1fmm of embedment (%s) but part B is only %.1fmm thick at joint %s",
+					fd.Kind, required, reason, receiving, node.ID.Short())
+			case embedment < required:
+				msg = fmt.Sprintf("%s embeds %.1fmm into part B at joint %s, needs %.1fmm (%s)",
+					fd.Kind, embedment, node.ID.Short(), required, reason)
+			default:
+				continue
+			}
+			warnings = append(warnings, ValidationWarning{NodeID: fNode.ID, Message: msg})
+		}
+	}
+
+	return warnings
+}
+
 // ---------------------------------------------------------------------------
 // Tier 3 — Material warnings
 // ---------------------------------------------------------------------------
 
 // isEndGrainFace returns true if the given face is an end-grain face
-// for a board with the specified grain direction.
+// for a board with the specified grain direction, both in the board's
+// local frame. A placement rotates the grain and the face normal together,
+// which leaves the angle between them unchanged, so the answer in the local
+// frame holds for every placed instance.
 //
 // The end-grain faces are the faces perpendicular to the grain axis:
-//   - Grain X: end-grain faces are left and right
-//   - Grain Y: end-grain faces are front and back
-//   - Grain Z: end-grain faces are top and bottom
+//   - Grain X: end-grain faces are left and right (±X)
+//   - Grain Y: end-grain faces are top and bottom (±Y)
+//   - Grain Z: end-grain faces are front and back (±Z)
 func isEndGrainFace(grain Axis, face FaceID) bool {
 	switch grain {
 	case AxisX:
 		return face == FaceLeft || face == FaceRight
 	case AxisY:
-		return face == FaceFront || face == FaceBack
-	case AxisZ:
 		return face == FaceTop || face == FaceBottom
+	case AxisZ:
+		return face == FaceFront || face == FaceBack
 	default:
 		return false
 	}
@@ -206,6 +285,8 @@ func isEndGrainFace(grain Axis, face FaceID) bool {
 func validateMaterial(g *DesignGraph) []ValidationWarning {
 	var warnings []ValidationWarning
 	warnings = append(warnings, validateEndGrainButtJoint(g)...)
+	warnings = append(warnings, validateSpecies(g)...)
+	warnings = append(warnings, validateWoodMovement(g)...)
 	return warnings
 }
 
@@ -214,7 +295,7 @@ func validateMaterial(g *DesignGraph) []ValidationWarning {
 func validateEndGrainButtJoint(g *DesignGraph) []ValidationWarning {
 	var warnings []ValidationWarning
 
-	for _, node := range g.Nodes {
+	for _, node := range g.SortedNodes() {
 		jd, ok := node.Data.(JoinData)
 		if !ok {
 			continue
@@ -246,3 +327,49 @@ func validateEndGrainButtJoint(g *DesignGraph) []ValidationWarning {
 
 	return warnings
 }
+
+// validateSpecies warns about parts whose material, or the default
+// material for parts without their own species, names a species missing
+// from the graph's species table, suggesting close matches. Checks that
+// need material properties skip such parts.
+func validateSpecies(g *DesignGraph) []ValidationWarning {
+	var warnings []ValidationWarning
+	table := g.Species
+	if table == nil {
+		table = builtinSpecies
+	}
+
+	for _, node := range g.SortedNodes() {
+		var species string
+		switch d := node.Data.(type) {
+		case BoardData:
+			species = materialSpecies(g, d.Material)
+		case DowelData:
+			species = materialSpecies(g, d.Material)
+		}
+		if species == "" {
+			continue
+		}
+		if _, ok := table.Lookup(species); ok {
+			continue
+		}
+
+		name := fmt.Sprintf("%q", node.Name)
+		if node.Name == "" {
+			name = node.ID.Short()
+		}
+		msg := fmt.Sprintf("part %s uses unknown species %q", name, species)
+		if suggestions := table.Suggest(species); len(suggestions) > 0 {
+			quoted := make([]string, len(suggestions))
+			for i, s := range suggestions {
+				quoted[i] = fmt.Sprintf("%q", s)
+			}
+			msg += "; did you mean " + strings.Join(quoted, " or ") + "?"
+		} else {
+			msg += "; add it to a species file to enable material checks"
+		}
+		warnings = append(warnings, ValidationWarning{NodeID: node.ID, Message: msg})
+	}
+
+	return warnings
+}
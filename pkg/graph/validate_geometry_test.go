@@ -486,7 +486,7 @@ func TestValidateAll_EndGrainButtJoint(t *testing.T) {
 func TestValidateAll_EndGrainGrainY(t *testing.T) {
 	g := New()
 
-	// Grain Y: end-grain faces are front and back.
+	// Grain Y: end-grain faces are top and bottom.
 	boardAID := NewNodeID("defpart/a")
 	boardBID := NewNodeID("defpart/b")
 	joinID := NewNodeID("join/endgrain-y")
@@ -504,8 +504,8 @@ func TestValidateAll_EndGrainGrainY(t *testing.T) {
 		ID: joinID, Kind: NodeJoin,
 		Data: JoinData{
 			Kind:   JoinButt,
-			PartA:  boardAID, FaceA: FaceFront,
-			PartB:  boardBID, FaceB: FaceBack,
+			PartA:  boardAID, FaceA: FaceTop,
+			PartB:  boardBID, FaceB: FaceBottom,
 			Params: ButtJoinParams{},
 		},
 	})
@@ -518,14 +518,14 @@ func TestValidateAll_EndGrainGrainY(t *testing.T) {
 
 	result := ValidateAll(g)
 	if !resultHasWarning(result, "end-grain") {
-		t.Error("expected end-grain warning for grain Y front/back joint")
+		t.Error("expected end-grain warning for grain Y top/bottom joint")
 	}
 }
 
 func TestValidateAll_EndGrainGrainZ(t *testing.T) {
 	g := New()
 
-	// Grain Z: end-grain faces are top and bottom.
+	// Grain Z: end-grain faces are front and back.
 	boardAID := NewNodeID("defpart/a")
 	boardBID := NewNodeID("defpart/b")
 	joinID := NewNodeID("join/endgrain-z")
@@ -543,8 +543,8 @@ func TestValidateAll_EndGrainGrainZ(t *testing.T) {
 		ID: joinID, Kind: NodeJoin,
 		Data: JoinData{
 			Kind:   JoinButt,
-			PartA:  boardAID, FaceA: FaceTop,
-			PartB:  boardBID, FaceB: FaceBottom,
+			PartA:  boardAID, FaceA: FaceFront,
+			PartB:  boardBID, FaceB: FaceBack,
 			Params: ButtJoinParams{},
 		},
 	})
@@ -557,7 +557,7 @@ func TestValidateAll_EndGrainGrainZ(t *testing.T) {
 
 	result := ValidateAll(g)
 	if !resultHasWarning(result, "end-grain") {
-		t.Error("expected end-grain warning for grain Z top/bottom joint")
+		t.Error("expected end-grain warning for grain Z front/back joint")
 	}
 }
 
@@ -692,20 +692,20 @@ func TestIsEndGrainFace(t *testing.T) {
 		{AxisX, FaceBottom, false},
 		{AxisX, FaceFront, false},
 		{AxisX, FaceBack, false},
-		// Grain Y: end-grain is front/back.
-		{AxisY, FaceFront, true},
-		{AxisY, FaceBack, true},
+		// Grain Y: end-grain is top/bottom.
+		{AxisY, FaceTop, true},
+		{AxisY, FaceBottom, true},
 		{AxisY, FaceLeft, false},
 		{AxisY, FaceRight, false},
-		{AxisY, FaceTop, false},
-		{AxisY, FaceBottom, false},
-		// Grain Z: end-grain is top/bottom.
-		{AxisZ, FaceTop, true},
-		{AxisZ, FaceBottom, true},
+		{AxisY, FaceFront, false},
+		{AxisY, FaceBack, false},
+		// Grain Z: end-grain is front/back.
+		{AxisZ, FaceFront, true},
+		{AxisZ, FaceBack, true},
 		{AxisZ, FaceLeft, false},
 		{AxisZ, FaceRight, false},
-		{AxisZ, FaceFront, false},
-		{AxisZ, FaceBack, false},
+		{AxisZ, FaceTop, false},
+		{AxisZ, FaceBottom, false},
 	}
 
 	for _, tt := range tests {
@@ -745,3 +745,88 @@ func TestFaceThickness(t *testing.T) {
 		}
 	}
 }
+
+// embedmentGraph joins a 19mm front board, through its front face, to the
+// front face of a left board of the given thickness with one fastener.
+func embedmentGraph(receiving float64, fd FastenerData) *DesignGraph {
+	g := New()
+
+	frontID := NewNodeID("defpart/front")
+	leftID := NewNodeID("defpart/left")
+	fastenerID := NewNodeID("fastener/screw")
+	joinID := NewNodeID("join/test")
+	groupID := NewNodeID("group/test")
+
+	fd.JoinRef = joinID
+	g.AddNode(&Node{
+		ID: frontID, Kind: NodePrimitive, Name: "front",
+		Data: BoardData{PrimKind: PrimBoard, Dimensions: Vec3{400, 200, 19}, Grain: AxisX},
+	})
+	g.AddNode(&Node{
+		ID: leftID, Kind: NodePrimitive, Name: "left",
+		Data: BoardData{PrimKind: PrimBoard, Dimensions: Vec3{262, 200, receiving}, Grain: AxisX},
+	})
+	g.AddNode(&Node{ID: fastenerID, Kind: NodeFastener, Data: fd})
+	g.AddNode(&Node{
+		ID: joinID, Kind: NodeJoin,
+		Data: JoinData{
+			Kind:      JoinButt,
+			PartA:     frontID,
+			FaceA:     FaceFront,
+			PartB:     leftID,
+			FaceB:     FaceFront,
+			Params:    ButtJoinParams{},
+			Fasteners: []NodeID{fastenerID},
+		},
+	})
+	g.AddNode(&Node{
+		ID: groupID, Kind: NodeGroup, Name: "root",
+		Children: []NodeID{frontID, leftID, joinID, fastenerID},
+		Data:     GroupData{},
+	})
+	g.AddRoot(groupID)
+	return g
+}
+
+func TestValidateAll_FastenerEmbedment(t *testing.T) {
+	tests := []struct {
+		name      string
+		receiving float64
+		fd        FastenerData
+		want      string // "" for no embedment warning
+	}{
+		{"enough", 19, FastenerData{Kind: FastenerScrew, Diameter: 3, Length: 38}, ""},
+		{"thread engagement", 19, FastenerData{Kind: FastenerScrew, Diameter: 3.5, Length: 35}, "needs 17.5mm (thread engagement of 5 × Ø3.5)"},
+		{"min embedment", 19, FastenerData{Kind: FastenerScrew, Diameter: 2, Length: 28}, "needs 12.0mm (minimum embedment 12.0mm)"},
+		{"does not reach", 19, FastenerData{Kind: FastenerScrew, Diameter: 4, Length: 16}, "does not reach part B"},
+		{"thin receiving board", 12, FastenerData{Kind: FastenerScrew, Diameter: 4, Length: 31}, "part B is only 12.0mm thick"},
+		{"nail", 19, FastenerData{Kind: FastenerNail, Diameter: 1.8, Length: 32}, "nail embeds 13.0mm"},
+		{"bolt has no rule", 19, FastenerData{Kind: FastenerBolt, Diameter: 6, Length: 20}, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			warnings := validateFastenerEmbedment(embedmentGraph(tc.receiving, tc.fd))
+			if tc.want == "" {
+				if len(warnings) != 0 {
+					t.Errorf("expected no warnings, got %v", warnings)
+				}
+				return
+			}
+			if len(warnings) != 1 || !strings.Contains(warnings[0].Message, tc.want) {
+				t.Errorf("expected one warning containing %q, got %v", tc.want, warnings)
+			}
+		})
+	}
+}
+
+func TestValidateAll_FastenerEmbedmentOverride(t *testing.T) {
+	g := embedmentGraph(19, FastenerData{Kind: FastenerScrew, Diameter: 3, Length: 30})
+	if !resultHasWarning(ValidateAll(g), "screw embeds 11.0mm") {
+		t.Fatal("expected the default rule to flag an 11mm embedment")
+	}
+
+	g.Defaults.FastenerRules = map[string]FastenerRule{"screw": {MinEmbedment: 10, MinThreadEngagement: 2.5}}
+	if resultHasWarning(ValidateAll(g), "embed") {
+		t.Error("expected the overridden rule to accept an 11mm embedment")
+	}
+}
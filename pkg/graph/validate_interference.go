@@ -0,0 +1,182 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// InterferenceTolerance is the penetration depth in mm below which two
+// placed boards are considered touching rather than overlapping.
+const InterferenceTolerance = 0.01
+
+// orientedBox is a board in world space: a center, three unit axes and the
+// half extents along them.
+type orientedBox struct {
+	center Vec3
+	axes   [3]Vec3
+	half   Vec3
+}
+
+func boardBox(p Placement, dims Vec3) orientedBox {
+	half := dims.Scale(0.5)
+	return orientedBox{
+		center: p.Transform.Apply(half),
+		axes: [3]Vec3{
+			p.Transform.ApplyDir(Vec3{1, 0, 0}),
+			p.Transform.ApplyDir(Vec3{0, 1, 0}),
+			p.Transform.ApplyDir(Vec3{0, 0, 1}),
+		},
+		half: half,
+	}
+}
+
+// radius returns the half length of the box's projection onto axis.
+func (b orientedBox) radius(axis Vec3) float64 {
+	return b.half.X*math.Abs(b.axes[0].Dot(axis)) +
+		b.half.Y*math.Abs(b.axes[1].Dot(axis)) +
+		b.half.Z*math.Abs(b.axes[2].Dot(axis))
+}
+
+// penetration returns how far two boxes interpenetrate: the smallest
+// overlap of their projections over the separating axes of the box-box
+// test. It is zero or negative if the boxes are disjoint or touching.
+func penetration(a, b orientedBox) float64 {
+	axes := make([]Vec3, 0, 15)
+	axes = append(axes, a.axes[:]...)
+	axes = append(axes, b.axes[:]...)
+	for _, u := range a.axes {
+		for _, v := range b.axes {
+			c := u.Cross(v)
+			// Parallel edges give no new axis.
+			if l := math.Sqrt(c.Dot(c)); l > 1e-9 {
+				axes = append(axes, c.Scale(1/l))
+			}
+		}
+	}
+
+	d := b.center.Sub(a.center)
+	depth := math.Inf(1)
+	for _, axis := range axes {
+		overlap := a.radius(axis) + b.radius(axis) - math.Abs(d.Dot(axis))
+		depth = math.Min(depth, overlap)
+	}
+	return depth
+}
+
+// overlapVolume returns the volume shared by two world-space boxes.
+func overlapVolume(a, b Box) float64 {
+	x := math.Min(a.Max.X, b.Max.X) - math.Max(a.Min.X, b.Min.X)
+	y := math.Min(a.Max.Y, b.Max.Y) - math.Max(a.Min.Y, b.Min.Y)
+	z := math.Min(a.Max.Z, b.Max.Z) - math.Max(a.Min.Z, b.Min.Z)
+	if x <= 0 || y <= 0 || z <= 0 {
+		return 0
+	}
+	return x * y * z
+}
+
+// axisAligned reports whether all axes of b lie along world axes, in which
+// case its bounding box is exact.
+func (b orientedBox) axisAligned() bool {
+	for _, a := range b.axes {
+		n := 0
+		for _, c := range []float64{a.X, a.Y, a.Z} {
+			if c != 0 {
+				n++
+			}
+		}
+		if n != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// overlapJoinKinds lists the joints whose parts legitimately share volume,
+// such as a tenon seated in its mortise. Butt joints only touch.
+var overlapJoinKinds = map[JoinKind]bool{
+	JoinRabbet:   true,
+	JoinDado:     true,
+	JoinMortise:  true,
+	JoinDovetail: true,
+}
+
+// validateInterference reports placed boards that occupy the same space.
+// A sweep over world-space bounding boxes finds candidate pairs; each is
+// confirmed with an exact oriented box test and reported if the boards
+// interpenetrate by more than InterferenceTolerance, unless a declared
+// joint between the two parts accounts for the overlap. Parts that are not
+// inside a placement have no meaningful position and are skipped.
+func validateInterference(g *DesignGraph) []ValidationError {
+	type instance struct {
+		p    Placement
+		aabb Box
+		obb  orientedBox
+	}
+	var insts []instance
+	for _, p := range g.Placements() {
+		bd, ok := p.Part.Data.(BoardData)
+		if !ok || p.Node() == p.Part {
+			continue // only boards given a position by (place ...) are checked
+		}
+		if bd.Dimensions.X <= 0 || bd.Dimensions.Y <= 0 || bd.Dimensions.Z <= 0 {
+			continue // reported by validateNonZeroDimensions
+		}
+		aabb, _ := p.Bounds()
+		insts = append(insts, instance{p: p, aabb: aabb, obb: boardBox(p, bd.Dimensions)})
+	}
+
+	explained := make(map[[2]NodeID]bool)
+	for _, n := range g.Joins() {
+		jd := n.Data.(JoinData)
+		if overlapJoinKinds[jd.Kind] {
+			explained[[2]NodeID{jd.PartA, jd.PartB}] = true
+			explained[[2]NodeID{jd.PartB, jd.PartA}] = true
+		}
+	}
+
+	// Broad phase: sweep along X. The sort is stable so that findings keep
+	// walk order for equal coordinates.
+	sort.SliceStable(insts, func(i, j int) bool { return insts[i].aabb.Min.X < insts[j].aabb.Min.X })
+
+	var errs []ValidationError
+	for i := range insts {
+		a := insts[i]
+		for j := i + 1; j < len(insts); j++ {
+			b := insts[j]
+			if b.aabb.Min.X >= a.aabb.Max.X-InterferenceTolerance {
+				break
+			}
+			if b.aabb.Min.Y >= a.aabb.Max.Y-InterferenceTolerance || a.aabb.Min.Y >= b.aabb.Max.Y-InterferenceTolerance ||
+				b.aabb.Min.Z >= a.aabb.Max.Z-InterferenceTolerance || a.aabb.Min.Z >= b.aabb.Max.Z-InterferenceTolerance {
+				continue
+			}
+			if explained[[2]NodeID{a.p.Part.ID, b.p.Part.ID}] {
+				continue
+			}
+			depth := penetration(a.obb, b.obb)
+			if depth <= InterferenceTolerance {
+				continue
+			}
+
+			first, second := a.p, b.p
+			if CompareNodes(second.Node(), first.Node()) < 0 {
+				first, second = second, first
+			}
+			msg := fmt.Sprintf("parts %s and %s overlap by %.4g mm", first.Label(), second.Label(), depth)
+			if a.obb.axisAligned() && b.obb.axisAligned() {
+				msg += fmt.Sprintf(" (%.4g mm³)", overlapVolume(a.aabb, b.aabb))
+			}
+			errs = append(errs, ValidationError{
+				NodeID:   second.Node().ID,
+				Message:  msg,
+				Severity: SeverityError,
+			})
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return CompareNodes(g.Nodes[errs[i].NodeID], g.Nodes[errs[j].NodeID]) < 0
+	})
+	return errs
+}
@@ -0,0 +1,158 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+)
+
+// interferenceGraph builds an assembly placing boards of the given sizes.
+type placed struct {
+	part   string
+	dims   Vec3
+	at     Vec3
+	rotate *Vec3
+}
+
+func interferenceGraph(items ...placed) *DesignGraph {
+	g := New()
+	var children []NodeID
+	for i, it := range items {
+		pid := NewNodeID("defpart/" + it.part)
+		if g.Nodes[pid] == nil {
+			g.AddNode(&Node{ID: pid, Kind: NodePrimitive, Name: it.part,
+				Data: BoardData{PrimKind: PrimBoard, Dimensions: it.dims}})
+		}
+		at := it.at
+		id := NewNodeID("place/" + it.part + "/" + string(rune('a'+i)))
+		g.AddNode(&Node{ID: id, Kind: NodeTransform, Source: SourceRef{Line: i + 1, Col: 1},
+			Children: []NodeID{pid}, Data: TransformData{Translation: &at, Rotation: it.rotate}})
+		children = append(children, id)
+	}
+	root := NewNodeID("assembly/box")
+	g.AddNode(&Node{ID: root, Kind: NodeGroup, Name: "box", Children: children, Data: GroupData{}})
+	g.AddRoot(root)
+	return g
+}
+
+func interferenceErrors(g *DesignGraph) []ValidationError {
+	var out []ValidationError
+	for _, e := range ValidateAll(g).Errors {
+		if strings.Contains(e.Message, "overlap") {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func TestInterferenceOverlappingBoards(t *testing.T) {
+	g := interferenceGraph(
+		placed{part: "side", dims: Vec3{19, 720, 300}},
+		placed{part: "shelf", dims: Vec3{500, 19, 300}, at: Vec3{10, 300, 0}},
+	)
+	errs := interferenceErrors(g)
+	if len(errs) != 1 {
+		t.Fatalf("expected one interference error, got %v", errs)
+	}
+	want := `parts "side" (placed at line 1, col 1) and "shelf" (placed at line 2, col 1) overlap by 9 mm (5.13e+04 mm³)`
+	if errs[0].Message != want {
+		t.Errorf("message = %q, want %q", errs[0].Message, want)
+	}
+	if errs[0].NodeID != NewNodeID("place/shelf/b") {
+		t.Error("error should point at the later placement")
+	}
+}
+
+func TestInterferenceTouchingBoards(t *testing.T) {
+	g := interferenceGraph(
+		placed{part: "side", dims: Vec3{19, 720, 300}},
+		placed{part: "shelf", dims: Vec3{500, 19, 300}, at: Vec3{19, 300, 0}},
+		placed{part: "top", dims: Vec3{519, 19, 300}, at: Vec3{0, 720.005, 0}},
+	)
+	if errs := interferenceErrors(g); len(errs) != 0 {
+		t.Errorf("touching boards should not interfere: %v", errs)
+	}
+}
+
+func TestInterferenceSamePartPlacedTwice(t *testing.T) {
+	g := interferenceGraph(
+		placed{part: "side", dims: Vec3{19, 720, 300}},
+		placed{part: "side", dims: Vec3{19, 720, 300}, at: Vec3{5, 0, 0}},
+	)
+	errs := interferenceErrors(g)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "overlap by 14 mm") {
+		t.Errorf("expected the two instances to interfere, got %v", errs)
+	}
+}
+
+func TestInterferenceRotatedBoardsUseExactTest(t *testing.T) {
+	// Two thin boards rotated 45° about Z: their bounding boxes overlap but
+	// the boards themselves are parallel and 20 mm apart.
+	rot := Vec3{0, 0, 45}
+	g := interferenceGraph(
+		placed{part: "a", dims: Vec3{400, 10, 100}, rotate: &rot},
+		placed{part: "b", dims: Vec3{400, 10, 100}, at: Vec3{-21.2132, 21.2132, 0}, rotate: &rot},
+	)
+	if errs := interferenceErrors(g); len(errs) != 0 {
+		t.Errorf("rotated parallel boards should not interfere: %v", errs)
+	}
+
+	g = interferenceGraph(
+		placed{part: "a", dims: Vec3{400, 10, 100}, rotate: &rot},
+		placed{part: "b", dims: Vec3{400, 10, 100}, at: Vec3{-3, 3, 0}, rotate: &rot},
+	)
+	errs := interferenceErrors(g)
+	if len(errs) != 1 || strings.Contains(errs[0].Message, "mm³") {
+		t.Errorf("expected an overlap without an exact volume, got %v", errs)
+	}
+}
+
+func TestInterferenceExplainedByJoint(t *testing.T) {
+	g := interferenceGraph(
+		placed{part: "rail", dims: Vec3{400, 50, 20}, at: Vec3{0, 0, 0}},
+		placed{part: "leg", dims: Vec3{40, 700, 40}, at: Vec3{380, -300, -10}},
+	)
+	if len(interferenceErrors(g)) != 1 {
+		t.Fatal("expected the tenon to interfere before the joint is declared")
+	}
+	g.AddNode(&Node{ID: NewNodeID("mortise"), Kind: NodeJoin, Data: JoinData{
+		Kind:  JoinMortise,
+		PartA: NewNodeID("defpart/rail"), FaceA: FaceRight,
+		PartB: NewNodeID("defpart/leg"), FaceB: FaceLeft,
+	}})
+	box := g.Nodes[NewNodeID("assembly/box")]
+	box.Children = append(box.Children, NewNodeID("mortise"))
+	g.AddNode(box)
+	if errs := interferenceErrors(g); len(errs) != 0 {
+		t.Errorf("a mortise joint should explain the overlap: %v", errs)
+	}
+}
+
+func TestPlacements(t *testing.T) {
+	rot := Vec3{0, 0, 90}
+	g := interferenceGraph(
+		placed{part: "side", dims: Vec3{19, 720, 300}},
+		placed{part: "side", dims: Vec3{19, 720, 300}, at: Vec3{500, 0, 0}},
+		placed{part: "rail", dims: Vec3{100, 20, 10}, at: Vec3{50, 0, 0}, rotate: &rot},
+	)
+	ps := g.PlacementsOf(NewNodeID("defpart/side"))
+	if len(ps) != 2 {
+		t.Fatalf("expected two placements of side, got %d", len(ps))
+	}
+	if b, _ := ps[1].Bounds(); b != (Box{Min: Vec3{500, 0, 0}, Max: Vec3{519, 720, 300}}) {
+		t.Errorf("second side bounds = %v", b)
+	}
+	if got := ps[1].Label(); got != `"side" (placed at line 2, col 1)` {
+		t.Errorf("label = %q", got)
+	}
+
+	rail := g.PlacementsOf(NewNodeID("defpart/rail"))[0]
+	if b, _ := rail.Bounds(); b != (Box{Min: Vec3{30, 0, 0}, Max: Vec3{50, 100, 10}}) {
+		t.Errorf("rotated rail bounds = %v", b)
+	}
+
+	loose := New()
+	loose.AddNode(&Node{ID: NewNodeID("p"), Kind: NodePrimitive, Name: "p", Data: BoardData{Dimensions: Vec3{1, 1, 1}}})
+	if ps := loose.Placements(); len(ps) != 1 || ps[0].Node() != ps[0].Part {
+		t.Errorf("a graph without roots should place each part once, unplaced: %+v", ps)
+	}
+}
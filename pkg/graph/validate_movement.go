@@ -0,0 +1,148 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+)
+
+// MovementSettings configures the seasonal wood movement check.
+type MovementSettings struct {
+	MinHumidity float64 `json:"min_humidity"` // relative humidity %, driest season
+	MaxHumidity float64 `json:"max_humidity"` // relative humidity %, most humid season
+	Threshold   float64 `json:"threshold"`    // cross-grain movement in mm a glued joint tolerates
+}
+
+// DefaultMovement suits furniture in a heated house with humid summers.
+var DefaultMovement = MovementSettings{MinHumidity: 30, MaxHumidity: 70, Threshold: 1.5}
+
+// movementTemperature is the room temperature in °C assumed when
+// converting humidity to wood moisture content.
+const movementTemperature = 20
+
+// EquilibriumMoisture returns the moisture content in percent that wood
+// settles at in air of the given relative humidity (%) and temperature
+// (°C), by the Hailwood–Horrobin fit of the USDA Wood Handbook.
+func EquilibriumMoisture(humidity, temp float64) float64 {
+	h := humidity / 100
+	w := 349 + 1.29*temp + 0.0135*temp*temp
+	k := 0.805 + 0.000736*temp - 0.00000273*temp*temp
+	k1 := 6.27 - 0.00938*temp - 0.000303*temp*temp
+	k2 := 1.91 + 0.0407*temp - 0.000293*temp*temp
+	kh := k * h
+	return 1800 / w * (kh/(1-kh) + (k1*kh+2*k1*k2*kh*kh)/(1+k1*kh+k1*k2*kh*kh))
+}
+
+// MoistureSwing returns the seasonal change in wood moisture content, in
+// percentage points, for the humidity range of s.
+func (s MovementSettings) MoistureSwing() float64 {
+	return math.Abs(EquilibriumMoisture(s.MaxHumidity, movementTemperature) -
+		EquilibriumMoisture(s.MinHumidity, movementTemperature))
+}
+
+// materialSpecies returns the species named by a part's material, falling
+// back to the graph's default material, or "" if neither sets one.
+func materialSpecies(g *DesignGraph, m MaterialSpec) string {
+	if m.Species != "" {
+		return m.Species
+	}
+	return g.Defaults.Material.Species
+}
+
+// partSpecies returns the species properties of a part's material, falling
+// back to the graph's default material. The boolean is false if the
+// species is unset or unknown.
+func partSpecies(g *DesignGraph, m MaterialSpec) (Species, bool) {
+	name := materialSpecies(g, m)
+	if name == "" {
+		return Species{}, false
+	}
+	return g.LookupSpecies(name)
+}
+
+// crossGrainMovement returns how far a placed board moves along the world
+// direction dir over the given span for a moisture swing in percentage
+// points. The board is taken to be flatsawn: across its larger cross-grain
+// dimension it moves tangentially, across the smaller one radially.
+func crossGrainMovement(p Placement, bd BoardData, sp Species, dir Vec3, span, swing float64) float64 {
+	dims := [3]float64{bd.Dimensions.X, bd.Dimensions.Y, bd.Dimensions.Z}
+	axis, best := 0, -1.0
+	for i, local := range []Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}} {
+		if d := math.Abs(p.Transform.ApplyDir(local).Dot(dir)); d > best {
+			axis, best = i, d
+		}
+	}
+	if Axis(axis) == bd.Grain {
+		return 0
+	}
+	other := 3 - axis - int(bd.Grain)
+	coeff := sp.RadialShrinkage
+	if dims[axis] >= dims[other] {
+		coeff = sp.TangentialShrinkage
+	}
+	return span * coeff * swing
+}
+
+// regionSpan returns the extent of a polygon along a unit direction.
+func regionSpan(poly []Vec3, dir Vec3) float64 {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, p := range poly {
+		d := p.Dot(dir)
+		lo, hi = math.Min(lo, d), math.Max(hi, d)
+	}
+	return math.Max(0, hi-lo)
+}
+
+// validateWoodMovement warns about glued butt joints between placed boards
+// whose grain runs across each other. Along the glue line each board is
+// held by the other's long grain, which hardly moves, so the board's
+// seasonal movement across its own grain over that length is restrained.
+// Joints where it exceeds the movement threshold are likely to crack.
+// Boards without known species are skipped.
+func validateWoodMovement(g *DesignGraph) []ValidationWarning {
+	var warnings []ValidationWarning
+	settings := g.Defaults.MovementSettings()
+	swing := settings.MoistureSwing()
+
+	for _, n := range g.Joins() {
+		jd := n.Data.(JoinData)
+		if params, ok := jd.Params.(ButtJoinParams); !ok || !params.GlueUp {
+			continue
+		}
+		c, ok := resolveJoint(g, jd)
+		if !ok || c.problem(jointClearance(g, jd)) != "" {
+			continue
+		}
+		bdA := c.a.Part.Data.(BoardData)
+		bdB := c.b.Part.Data.(BoardData)
+		if math.Abs(c.a.Grain.Dot(c.b.Grain)) >= 0.5 {
+			continue // grain runs the same way; both boards move together
+		}
+
+		// Each board is restrained along the other's grain.
+		var worst, worstSpan float64
+		var moving Placement
+		for _, side := range []struct {
+			p      Placement
+			bd     BoardData
+			across Vec3
+		}{{c.a, bdA, c.b.Grain}, {c.b, bdB, c.a.Grain}} {
+			sp, ok := partSpecies(g, side.bd.Material)
+			if !ok {
+				continue
+			}
+			span := regionSpan(c.region, side.across)
+			if m := crossGrainMovement(side.p, side.bd, sp, side.across, span, swing); m > worst {
+				worst, worstSpan, moving = m, span, side.p
+			}
+		}
+		if worst <= settings.Threshold {
+			continue
+		}
+		warnings = append(warnings, ValidationWarning{
+			NodeID: n.ID,
+			Message: fmt.Sprintf("cross-grain glue joint %s: %s moves %.2f mm across %.0f mm of glue line between %g%% and %g%% humidity (limit %g mm)",
+				n.ID.Short(), moving.Label(), worst, worstSpan, settings.MinHumidity, settings.MaxHumidity, settings.Threshold),
+		})
+	}
+	return warnings
+}
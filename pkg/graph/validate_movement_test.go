@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEquilibriumMoisture(t *testing.T) {
+	// Wood Handbook table 4-2 at 20 °C.
+	for _, tc := range []struct{ humidity, want float64 }{{30, 6.2}, {50, 9.3}, {70, 13.1}} {
+		if got := EquilibriumMoisture(tc.humidity, 20); math.Abs(got-tc.want) > 0.1 {
+			t.Errorf("EquilibriumMoisture(%g, 20) = %.2f, want %.1f", tc.humidity, got, tc.want)
+		}
+	}
+}
+
+// breadboardGraph places an 800 mm white oak top, grain along X, and glues
+// a breadboard end with the given grain to its right end.
+func breadboardGraph(endGrain Axis, glueUp bool) *DesignGraph {
+	return glueBreadboard(interferenceGraph(
+		placed{part: "top", dims: Vec3{800, 19, 600}},
+		placed{part: "end", dims: Vec3{60, 19, 600}, at: Vec3{800, 0, 0}},
+	), endGrain, FaceLeft, glueUp)
+}
+
+// rotatedBreadboardGraph is breadboardGraph with the end modelled lying
+// along X and turned -90° about Y into place, so its local X runs along
+// world Z and its back face meets the top.
+func rotatedBreadboardGraph(endGrain Axis) *DesignGraph {
+	return glueBreadboard(interferenceGraph(
+		placed{part: "top", dims: Vec3{800, 19, 600}},
+		placed{part: "end", dims: Vec3{600, 19, 60}, at: Vec3{860, 0, 0}, rotate: &Vec3{0, -90, 0}},
+	), endGrain, FaceBack, true)
+}
+
+func glueBreadboard(g *DesignGraph, endGrain Axis, endFace FaceID, glueUp bool) *DesignGraph {
+	oak := MaterialSpec{Species: "white-oak"}
+	for name, grain := range map[string]Axis{"top": AxisX, "end": endGrain} {
+		n := g.Nodes[NewNodeID("defpart/"+name)]
+		bd := n.Data.(BoardData)
+		bd.Grain, bd.Material = grain, oak
+		n.Data = bd
+		g.AddNode(n)
+	}
+	join := &Node{ID: NewNodeID("join"), Kind: NodeJoin, Source: SourceRef{Line: 3, Col: 1}, Data: JoinData{
+		Kind:  JoinButt,
+		PartA: NewNodeID("defpart/top"), FaceA: FaceRight,
+		PartB: NewNodeID("defpart/end"), FaceB: endFace,
+		Params: ButtJoinParams{GlueUp: glueUp},
+	}}
+	g.AddNode(join)
+	box := g.Nodes[NewNodeID("assembly/box")]
+	box.Children = append(box.Children, join.ID)
+	g.AddNode(box)
+	return g
+}
+
+func TestWoodMovementCrossGrainGlueJoint(t *testing.T) {
+	warnings := validateWoodMovement(breadboardGraph(AxisZ, true))
+	if len(warnings) != 1 {
+		t.Fatalf("expected one warning, got %v", warnings)
+	}
+	// 600 mm × 0.00365 tangential × 6.94 points of moisture content.
+	want := `"top" (placed at line 1, col 1) moves 15.20 mm across 600 mm of glue line between 30% and 70% humidity (limit 1.5 mm)`
+	if !strings.Contains(warnings[0].Message, want) {
+		t.Errorf("got %q, want it to contain %q", warnings[0].Message, want)
+	}
+}
+
+func TestWoodMovementFollowsRotation(t *testing.T) {
+	// Local grain X becomes world Z: the same breadboard end as above.
+	warnings := validateWoodMovement(rotatedBreadboardGraph(AxisX))
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Message, `"top" (placed at line 1, col 1) moves 15.20 mm`) {
+		t.Errorf("expected the rotated breadboard end to restrain the top, got %v", warnings)
+	}
+	// Local grain Z becomes world X, parallel to the top.
+	if warnings := validateWoodMovement(rotatedBreadboardGraph(AxisZ)); len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+}
+
+func TestEndGrainJointFollowsRotation(t *testing.T) {
+	// Local grain Z runs into the end's back face, so the end abuts the top
+	// end grain to end grain however the end is rotated.
+	if !resultHasWarning(ValidateAll(rotatedBreadboardGraph(AxisZ)), "end-grain to end-grain") {
+		t.Error("expected an end-grain warning for the rotated end")
+	}
+	if resultHasWarning(ValidateAll(rotatedBreadboardGraph(AxisX)), "end-grain to end-grain") {
+		t.Error("unexpected end-grain warning for the rotated long-grain end")
+	}
+}
+
+func TestWoodMovementNoWarning(t *testing.T) {
+	tests := []struct {
+		name string
+		g    *DesignGraph
+	}{
+		{"grain parallel", breadboardGraph(AxisX, true)},
+		{"not glued", breadboardGraph(AxisZ, false)},
+		{"within threshold", func() *DesignGraph {
+			g := breadboardGraph(AxisZ, true)
+			g.Defaults.Movement = &MovementSettings{MinHumidity: 45, MaxHumidity: 55, Threshold: 5}
+			return g
+		}()},
+		{"unknown species", func() *DesignGraph {
+			g := breadboardGraph(AxisZ, true)
+			n := g.Nodes[NewNodeID("defpart/top")]
+			bd := n.Data.(BoardData)
+			bd.Material.Species = "zebrawood"
+			n.Data = bd
+			g.AddNode(n)
+			return g
+		}()},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if warnings := validateWoodMovement(tc.g); len(warnings) != 0 {
+				t.Errorf("unexpected warnings: %v", warnings)
+			}
+		})
+	}
+}
@@ -151,6 +151,32 @@ func TestValidate_CycleDetection(t *testing.T) {
 	}
 }
 
+func TestValidate_CycleIgnoresDataRefs(t *testing.T) {
+	g := New()
+
+	// The assembly holds the join, and the join names the assembly as a
+	// part: a loop through a data reference, not through Children.
+	asmID := NewNodeID("assembly/box")
+	joinID := NewNodeID("join")
+	g.AddNode(&Node{
+		ID: asmID, Kind: NodeGroup, Name: "box",
+		Children: []NodeID{joinID},
+		Data:     GroupData{},
+	})
+	g.AddNode(&Node{
+		ID: joinID, Kind: NodeJoin,
+		Data: JoinData{Kind: JoinButt, PartA: asmID, FaceA: FaceTop, PartB: asmID, FaceB: FaceBottom, Params: ButtJoinParams{}},
+	})
+	g.AddRoot(asmID)
+
+	if errs := validateDAG(g); len(errs) != 0 {
+		t.Errorf("expected no cycle along Children edges, got %v", errs)
+	}
+	if _, err := g.TopoOrder(); err == nil {
+		t.Error("expected TopoOrder, which orders data references too, to report the loop")
+	}
+}
+
 func TestValidate_DanglingReference(t *testing.T) {
 	g := New()
 
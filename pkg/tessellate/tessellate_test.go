@@ -298,3 +298,31 @@ func abs(x float64) float64 {
 	}
 	return x
 }
+
+func TestNoRootsSourceOrder(t *testing.T) {
+	k := newKernel()
+	g := graph.New()
+
+	// Added in reverse; meshes must follow source order, not map order.
+	names := []string{"a", "b", "c", "d", "e"}
+	for i := len(names) - 1; i >= 0; i-- {
+		board := makeBoard(names[i], 100, 50, 18)
+		board.Source = graph.SourceRef{Line: i + 1, Col: 1}
+		g.AddNode(board)
+	}
+
+	for run := 0; run < 5; run++ {
+		meshes, err := tessellate.Tessellate(g, k)
+		if err != nil {
+			t.Fatalf("Tessellate failed: %v", err)
+		}
+		if len(meshes) != len(names) {
+			t.Fatalf("expected %d meshes, got %d", len(names), len(meshes))
+		}
+		for i, m := range meshes {
+			if m.PartName != names[i] {
+				t.Fatalf("run %d: mesh %d is %q, want %q", run, i, m.PartName, names[i])
+			}
+		}
+	}
+}